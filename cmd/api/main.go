@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -9,12 +10,15 @@ import (
 	"gorm.io/gorm"
 	"log"
 	"modules/config"
+	cron "modules/corn"
 	"modules/internal/controllers"
 	"modules/internal/repositories"
 	"modules/internal/routes"
 	"modules/internal/services"
 	"modules/pkg/database"
 	"modules/pkg/logger"
+	"modules/pkg/notifier"
+	"modules/pkg/payments"
 	"os"
 	"path/filepath"
 )
@@ -76,32 +80,49 @@ func main() {
 	// 执行数据库迁移
 	database.Migrate(db)
 
-	// 初始化 UserRepository
-	userRepo := repositories.NewUserRepo(db)
-
-	// 初始化 AuthService，传入 UserRepository 和配置
-	authService := services.NewAuthService(userRepo, cfg)
+	// 启动定时任务
+	cron.StartCronJobs(db, cfg)
 
 	// 初始化控制器
 	ctrls := initializeControllers(db, cfg)
 
 	// 创建 Gin 引擎
 	router := gin.Default()
+	// 只采信可信代理转发的客户端 IP，避免伪造 X-Forwarded-For 绕过按 IP 的登录锁定
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logger.Log.Fatal("配置可信代理失败", zap.Error(err))
+	}
 
 	// 挂载 CORS 中间件
 	router.Use(CORSMiddleware())
 
 	// 初始化路由依赖，注入 authService
 	deps := &routes.RouterDependencies{
-		AuthService:    authService,
-		AuthController: ctrls.AuthController,
-		ParkingService: ctrls.ParkingController,
-		AdminService:   ctrls.AdminController,
-		LeaseService:   ctrls.LeaseController,
-		ReportService:  ctrls.ReportController,
-		VehicleService: ctrls.VehicleController,
-		OwnerService:   ctrls.OwnerController,
-		Cfg:            ctrls.Cfg,
+		AuthService:         ctrls.AuthService,
+		PermissionService:   ctrls.PermissionService,
+		AuthController:      ctrls.AuthController,
+		AccountService:      ctrls.AccountController,
+		TwoFactorService:    ctrls.TwoFactorController,
+		ParkingService:      ctrls.ParkingController,
+		AdminService:        ctrls.AdminController,
+		LeaseService:        ctrls.LeaseController,
+		ReportService:       ctrls.ReportController,
+		VehicleService:      ctrls.VehicleController,
+		OwnerService:        ctrls.OwnerController,
+		TariffService:       ctrls.TariffController,
+		ReservationService:  ctrls.ReservationController,
+		PaymentService:      ctrls.PaymentController,
+		MarketplaceService:  ctrls.MarketplaceController,
+		LedgerService:       ctrls.LedgerController,
+		MaintenanceService:  ctrls.MaintenanceController,
+		AuditService:        ctrls.AuditController,
+		NotificationService: ctrls.NotificationController,
+		WebhookService:      ctrls.WebhookController,
+		OutboxService:       ctrls.OutboxController,
+		SigningKeyService:   ctrls.SigningKeyController,
+		UserAdminService:    ctrls.UserAdminController,
+		RoleService:         ctrls.RoleController,
+		Cfg:                 ctrls.Cfg,
 	}
 
 	// 设置路由
@@ -138,31 +159,113 @@ func main() {
 func initializeControllers(db *gorm.DB, cfg *config.Config) *ControllerDependencies {
 	// Repos
 	userRepo := repositories.NewUserRepo(db) // 初始化 userRepo
+	tokenRepo := repositories.NewTokenRepo(db)
+	loginAttemptRepo := repositories.NewLoginAttemptRepo(db)
+	twoFactorRepo := repositories.NewTwoFactorRepo(db)
 	parkingRepo := repositories.NewParkingRepo(db)
 	purchaseRepo := repositories.NewPurchaseRepo(db)
 	reportRepo := repositories.NewReportRepo(db)
 	leaseRepo := repositories.NewLeaseRepo(db)
 	vehicleRepo := repositories.NewVehicleRepo(db)
+	tariffRepo := repositories.NewTariffRepo(db)
+	reservationRepo := repositories.NewReservationRepo(db)
+	paymentRepo := repositories.NewPaymentRepo(db)
+	listingRepo := repositories.NewListingRepo(db)
+	ledgerRepo := repositories.NewLedgerRepo(db)
+	auditRepo := repositories.NewAuditRepo(db)
+	notificationRepo := repositories.NewNotificationRepo(db)
+	webhookRepo := repositories.NewWebhookRepo(db)
+	outboxRepo := repositories.NewOutboxRepo(db)
+	signingKeyRepo := repositories.NewSigningKeyRepo(db)
+	roleRepo := repositories.NewRoleRepo(db)
+
+	// 支付网关
+	gateway, err := payments.NewGateway(payments.Config{
+		Provider:         cfg.Payment.Provider,
+		Currency:         cfg.Payment.Currency,
+		MockDeclineAbove: cfg.Payment.MockDeclineAbove,
+	})
+	if err != nil {
+		logger.Log.Fatal("初始化支付网关失败", zap.Error(err))
+	}
+
+	// 邮件通知
+	notifyClient, err := notifier.NewClient(notifierConfig(cfg))
+	if err != nil {
+		logger.Log.Fatal("初始化邮件通知失败", zap.Error(err))
+	}
+	smsSender, err := notifier.NewSMSSender(cfg.Notifier.SMSProvider)
+	if err != nil {
+		logger.Log.Fatal("初始化短信通知失败", zap.Error(err))
+	}
 
 	// Services
-	authService := services.NewAuthService(userRepo, cfg)               // 初始化 AuthService
-	parkingService := services.NewParkingService(parkingRepo, userRepo) // 初始化 parkingService
+	tariffService := services.NewTariffService(tariffRepo, parkingRepo)
+	reservationService := services.NewReservationService(reservationRepo, parkingRepo, cfg)
+	paymentService := services.NewPaymentService(paymentRepo, gateway, cfg.Payment.Currency)
+	notificationService := services.NewNotificationService(notificationRepo, userRepo, notifyClient, smsSender)
+	auditService := services.NewAuditService(auditRepo, userRepo, notificationService)
+	twoFactorService := services.NewTwoFactorService(twoFactorRepo, userRepo, tokenRepo, auditService, cfg)
+	tokenIssuer := services.NewTokenIssuer(signingKeyRepo, cfg)
+	authService := services.NewAuthService(userRepo, tokenRepo, loginAttemptRepo, twoFactorService, tokenIssuer, cfg) // 初始化 AuthService
+	accountService := services.NewAccountService(userRepo, tokenRepo, notificationService, cfg)
+	permissionService := services.NewPermissionService(roleRepo, userRepo, auditService)
+	if err := permissionService.EnsureBuiltInRoles(context.Background()); err != nil {
+		logger.Log.Fatal("初始化内置角色失败", zap.Error(err))
+	}
+	userAdminService := services.NewUserAdminService(userRepo, tokenRepo, accountService, permissionService, twoFactorService, auditService)
+	outboxService := services.NewOutboxService(outboxRepo, cfg)
+	webhookService := services.NewWebhookService(webhookRepo, outboxService, cfg)
+	parkingService := services.NewParkingService(parkingRepo, userRepo, vehicleRepo, tariffService, reservationService, paymentService, reportRepo, auditService, notificationService, outboxService) // 初始化 parkingService
 	ownerService := services.NewOwnerService(parkingRepo, userRepo, purchaseRepo)
 	reportService := services.NewReportService(reportRepo, parkingRepo) // 初始化 reportService
-	leaseService := services.NewLeaseService(leaseRepo, parkingRepo)
-	vehicleService := services.NewVehicleService(vehicleRepo, userRepo, parkingRepo, leaseService)
+	leaseService := services.NewLeaseService(leaseRepo, parkingRepo, paymentService, notificationService, outboxService, cfg)
+	ledgerService := services.NewLedgerService(ledgerRepo, leaseRepo, parkingRepo, paymentService, cfg)
+	marketplaceService := services.NewMarketplaceService(listingRepo, parkingRepo, leaseService)
+	vehicleService := services.NewVehicleService(vehicleRepo, userRepo, parkingRepo, marketplaceService)
+	maintenanceService := services.NewMaintenanceService(reportRepo, parkingRepo, userRepo, auditService)
 
 	// Controllers
 	adminController := controllers.NewAdminController(parkingService, reportService, authService) // 初始化 AdminController
 	return &ControllerDependencies{
-		AuthController:    controllers.NewAuthController(authService),
-		ParkingController: controllers.NewParkingController(parkingService),
-		AdminController:   adminController,
-		LeaseController:   controllers.NewLeaseController(leaseService),
-		ReportController:  controllers.NewReportController(reportService),
-		VehicleController: controllers.NewVehicleController(vehicleService),
-		OwnerController:   controllers.NewOwnerController(ownerService),
-		Cfg:               cfg,
+		AuthController:         controllers.NewAuthController(authService, accountService),
+		AccountController:      controllers.NewAccountController(accountService),
+		TwoFactorController:    controllers.NewTwoFactorController(twoFactorService),
+		AuthService:            authService,
+		PermissionService:      permissionService,
+		ParkingController:      controllers.NewParkingController(parkingService),
+		AdminController:        adminController,
+		LeaseController:        controllers.NewLeaseController(leaseService),
+		ReportController:       controllers.NewReportController(reportService),
+		VehicleController:      controllers.NewVehicleController(vehicleService),
+		OwnerController:        controllers.NewOwnerController(ownerService),
+		TariffController:       controllers.NewTariffController(tariffService),
+		ReservationController:  controllers.NewReservationController(reservationService),
+		PaymentController:      controllers.NewPaymentController(paymentService),
+		MarketplaceController:  controllers.NewMarketplaceController(marketplaceService),
+		LedgerController:       controllers.NewLedgerController(ledgerService),
+		MaintenanceController:  controllers.NewMaintenanceController(maintenanceService),
+		AuditController:        controllers.NewAuditController(auditService),
+		NotificationController: controllers.NewNotificationController(notificationService),
+		WebhookController:      controllers.NewWebhookController(webhookService),
+		OutboxController:       controllers.NewOutboxController(outboxService),
+		SigningKeyController:   controllers.NewSigningKeyController(tokenIssuer),
+		UserAdminController:    controllers.NewUserAdminController(userAdminService),
+		RoleController:         controllers.NewRoleController(permissionService),
+		Cfg:                    cfg,
+	}
+}
+
+// notifierConfig 将配置文件中的通知配置转换为通知客户端配置
+func notifierConfig(cfg *config.Config) notifier.Config {
+	return notifier.Config{
+		Mode:         cfg.Notifier.Mode,
+		SMTPHost:     cfg.Notifier.SMTPHost,
+		SMTPPort:     cfg.Notifier.SMTPPort,
+		SMTPUser:     cfg.Notifier.SMTPUser,
+		SMTPPassword: cfg.Notifier.SMTPPassword,
+		From:         cfg.Notifier.From,
+		SinkDir:      cfg.Notifier.SinkDir,
 	}
 }
 
@@ -184,12 +287,30 @@ func CORSMiddleware() gin.HandlerFunc {
 
 // ControllerDependencies 控制器依赖
 type ControllerDependencies struct {
-	AuthController    *controllers.AuthController
-	ParkingController *controllers.ParkingController
-	AdminController   *controllers.AdminController
-	LeaseController   *controllers.LeaseController
-	ReportController  *controllers.ReportController
-	VehicleController *controllers.VehicleController
-	OwnerController   *controllers.OwnerController
+	AuthController         *controllers.AuthController
+	AccountController      *controllers.AccountController
+	TwoFactorController    *controllers.TwoFactorController
+	ParkingController      *controllers.ParkingController
+	AdminController        *controllers.AdminController
+	LeaseController        *controllers.LeaseController
+	ReportController       *controllers.ReportController
+	VehicleController      *controllers.VehicleController
+	OwnerController        *controllers.OwnerController
+	TariffController       *controllers.TariffController
+	ReservationController  *controllers.ReservationController
+	PaymentController      *controllers.PaymentController
+	MarketplaceController  *controllers.MarketplaceController
+	LedgerController       *controllers.LedgerController
+	MaintenanceController  *controllers.MaintenanceController
+	AuditController        *controllers.AuditController
+	NotificationController *controllers.NotificationController
+	WebhookController      *controllers.WebhookController
+	OutboxController       *controllers.OutboxController
+	SigningKeyController   *controllers.SigningKeyController
+	UserAdminController    *controllers.UserAdminController
+	RoleController         *controllers.RoleController
+	// 路由中的认证、权限中间件与控制器共用同一个 AuthService 和 PermissionService
+	AuthService       *services.AuthService
+	PermissionService *services.PermissionService
 	Cfg               *config.Config
 }
@@ -0,0 +1,67 @@
+// cmd/backfill/main.go
+// 按日期区间重建日报表快照：
+//
+//	go run ./cmd/backfill -from 2025-01-01 -to 2025-01-31
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"modules/config"
+	"modules/internal/repositories"
+	"modules/internal/services"
+	"modules/pkg/database"
+	"modules/pkg/logger"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func main() {
+	configPath := flag.String("config", "config/config.yaml", "配置文件路径")
+	fromStr := flag.String("from", "", "开始日期（YYYY-MM-DD）")
+	toStr := flag.String("to", "", "结束日期（YYYY-MM-DD），默认昨天")
+	flag.Parse()
+
+	if *fromStr == "" {
+		log.Fatal("必须指定 -from 开始日期")
+	}
+	from, err := time.ParseInLocation("2006-01-02", *fromStr, time.Local)
+	if err != nil {
+		log.Fatalf("无效的开始日期: %v", err)
+	}
+	to := time.Now().AddDate(0, 0, -1)
+	if *toStr != "" {
+		if to, err = time.ParseInLocation("2006-01-02", *toStr, time.Local); err != nil {
+			log.Fatalf("无效的结束日期: %v", err)
+		}
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+
+	logDir := filepath.Join(filepath.Dir(*configPath), "..", "log")
+	if err := os.MkdirAll(logDir, os.ModePerm); err != nil {
+		log.Fatalf("创建日志目录失败: %v", err)
+	}
+	cfg.LogFilePath = filepath.Join(logDir, "backfill.log")
+	logger.InitLogger(cfg)
+	defer logger.Log.Sync()
+
+	db, err := database.ConnectDB(cfg)
+	if err != nil {
+		log.Fatalf("数据库连接失败: %v", err)
+	}
+	database.Migrate(db)
+
+	reportService := services.NewReportService(repositories.NewReportRepo(db), repositories.NewParkingRepo(db))
+	count, err := reportService.SnapshotDailyReports(context.Background(), from, to)
+	if err != nil {
+		log.Fatalf("重建日报表失败: %v", err)
+	}
+	fmt.Printf("已重建 %s 至 %s 共 %d 天的日报表\n", from.Format("2006-01-02"), to.Format("2006-01-02"), count)
+}
@@ -6,23 +6,157 @@ import (
 )
 
 type JWTConfig struct {
-	Secret    string `yaml:"secret"`
+	// 签名算法：RS256 或 EdDSA
+	Algorithm string `yaml:"algorithm"`
+	// 令牌的 iss 声明，验证时同样校验
+	Issuer string `yaml:"issuer"`
+	// 签名密钥轮换周期
+	RotationInterval string `yaml:"rotation_interval"`
+	// 加密保存签名私钥使用的密钥
+	KeyEncryptionKey string `yaml:"key_encryption_key"`
+	// 访问令牌有效期
 	ExpiresIn string `yaml:"expires_in"`
 	MaxAge    int    `yaml:"max_age"`
+	// 刷新令牌有效期，每次刷新后重新计算
+	RefreshExpiresIn string `yaml:"refresh_expires_in"`
+}
+
+// ReservationConfig 车位预约配置
+type ReservationConfig struct {
+	// 预约开始后保留车位的宽限期，超时未入场视为爽约
+	GracePeriod string `yaml:"grace_period"`
+}
+
+// PaymentConfig 支付网关配置
+type PaymentConfig struct {
+	Provider         string  `yaml:"provider"`
+	Currency         string  `yaml:"currency"`
+	MockDeclineAbove float64 `yaml:"mock_decline_above"`
+}
+
+// LeaseTerminationConfig 租赁提前终止违约金规则
+type LeaseTerminationConfig struct {
+	// 违约金占剩余租金的比例
+	PenaltyRate float64 `yaml:"penalty_rate"`
+	// 最低违约金
+	MinPenalty float64 `yaml:"min_penalty"`
+	// 租期开始后多少小时内终止免收违约金
+	FreeCancelHours int `yaml:"free_cancel_hours"`
+}
+
+// LeaseConfig 租赁配置
+type LeaseConfig struct {
+	Termination LeaseTerminationConfig `yaml:"termination"`
+	// 到期前多少天提醒承租人和业主，例如 [7, 3, 1]
+	ReminderDays []int `yaml:"reminder_days"`
+	// 待支付订单的保留时长，超时未支付自动取消并释放车位
+	PendingTTL string `yaml:"pending_ttl"`
+}
+
+// LedgerConfig 业主收入分账配置
+type LedgerConfig struct {
+	// 平台佣金占收入的比例
+	CommissionRate float64 `yaml:"commission_rate"`
+}
+
+// NotifierConfig 邮件通知配置
+type NotifierConfig struct {
+	// 发送方式：smtp 真实发送，file 写入本地目录，memory 仅保存在内存
+	Mode         string `yaml:"mode"`
+	SMTPHost     string `yaml:"smtp_host"`
+	SMTPPort     int    `yaml:"smtp_port"`
+	SMTPUser     string `yaml:"smtp_user"`
+	SMTPPassword string `yaml:"smtp_password"`
+	From         string `yaml:"from"`
+	// file 模式下邮件的保存目录
+	SinkDir string `yaml:"sink_dir"`
+	// 短信服务商，目前仅支持 console（打印到标准输出）
+	SMSProvider string `yaml:"sms_provider"`
+}
+
+// WebhookConfig Webhook 投递配置
+type WebhookConfig struct {
+	// 最多投递次数（含首次）
+	MaxAttempts int `yaml:"max_attempts"`
+	// 首次重试间隔，之后每次翻倍
+	BackoffBase string `yaml:"backoff_base"`
+	// 单次请求超时时间
+	Timeout string `yaml:"timeout"`
+}
+
+// OutboxConfig 发件箱分发配置
+type OutboxConfig struct {
+	// 分发器扫描间隔
+	PollInterval string `yaml:"poll_interval"`
+	// 最多分发次数（含首次），之后标记为失败
+	MaxAttempts int `yaml:"max_attempts"`
+	// 首次重试间隔，之后每次翻倍
+	BackoffBase string `yaml:"backoff_base"`
+}
+
+// AccountConfig 账户安全邮件配置
+type AccountConfig struct {
+	// 前端重置密码页面地址，令牌以 token 参数附加；为空时邮件中只包含令牌
+	PasswordResetURL string `yaml:"password_reset_url"`
+	// 邮箱验证地址，令牌以 token 参数附加
+	VerifyEmailURL string `yaml:"verify_email_url"`
+	// 密码重置令牌有效期
+	PasswordResetTTL string `yaml:"password_reset_ttl"`
+	// 邮箱验证令牌有效期
+	EmailVerificationTTL string `yaml:"email_verification_ttl"`
+}
+
+// LockoutConfig 登录失败锁定配置。连续失败达到阈值后锁定，之后每多失败一次锁定时长翻倍
+type LockoutConfig struct {
+	// 同一账户连续失败多少次后锁定
+	AccountThreshold int `yaml:"account_threshold"`
+	// 同一 IP 连续失败多少次后锁定
+	IPThreshold int `yaml:"ip_threshold"`
+	// 首次锁定时长
+	BaseDuration string `yaml:"base_duration"`
+	// 锁定时长上限
+	MaxDuration string `yaml:"max_duration"`
+	// 最后一次失败超过该时长后失败次数清零
+	ResetAfter string `yaml:"reset_after"`
+}
+
+// TwoFactorConfig TOTP 两步验证配置
+type TwoFactorConfig struct {
+	// 验证器应用中显示的发行方名称
+	Issuer string `yaml:"issuer"`
+	// 加密保存 TOTP 密钥使用的密钥，为空时使用 jwt.key_encryption_key
+	EncryptionKey string `yaml:"encryption_key"`
+	// 密码校验通过后完成两步验证的时限
+	ChallengeTTL string `yaml:"challenge_ttl"`
+	// 允许的时钟偏差（步数），每步 30 秒
+	Skew int `yaml:"skew"`
 }
 
 type Config struct {
 	Env  string `yaml:"env"`
 	Port string `yaml:"port"`
-	DB   struct {
+	// 可信的反向代理地址或网段，只有来自这些地址的请求才采信 X-Forwarded-For；
+	// 为空时不信任任何代理，客户端 IP 取连接的对端地址
+	TrustedProxies []string `yaml:"trusted_proxies"`
+	DB             struct {
 		Host     string `yaml:"host"`
 		Port     string `yaml:"port"`
 		User     string `yaml:"user"`
 		Password string `yaml:"password"`
 		Name     string `yaml:"name"`
 	} `yaml:"db"`
-	JWT         JWTConfig `yaml:"jwt"`
-	LogFilePath string    `yaml:"log_file_path"` // 添加 LogFilePath 字段
+	JWT         JWTConfig         `yaml:"jwt"`
+	Reservation ReservationConfig `yaml:"reservation"`
+	Payment     PaymentConfig     `yaml:"payment"`
+	Lease       LeaseConfig       `yaml:"lease"`
+	Ledger      LedgerConfig      `yaml:"ledger"`
+	Notifier    NotifierConfig    `yaml:"notifier"`
+	Webhook     WebhookConfig     `yaml:"webhook"`
+	Outbox      OutboxConfig      `yaml:"outbox"`
+	Account     AccountConfig     `yaml:"account"`
+	Lockout     LockoutConfig     `yaml:"lockout"`
+	TwoFactor   TwoFactorConfig   `yaml:"two_factor"`
+	LogFilePath string            `yaml:"log_file_path"` // 添加 LogFilePath 字段
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -6,12 +6,16 @@ import (
 	"github.com/robfig/cron/v3"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"modules/config"
 	"modules/internal/repositories"
 	"modules/internal/services"
 	"modules/pkg/logger"
+	"modules/pkg/notifier"
+	"modules/pkg/payments"
+	"time"
 )
 
-func StartCronJobs(db *gorm.DB) {
+func StartCronJobs(db *gorm.DB, cfg *config.Config) {
 	c := cron.New()
 
 	// 初始化仓库
@@ -19,27 +23,81 @@ func StartCronJobs(db *gorm.DB) {
 	userRepo := repositories.NewUserRepo(db)
 	leaseRepo := repositories.NewLeaseRepo(db)
 	reportRepo := repositories.NewReportRepo(db)
+	tariffRepo := repositories.NewTariffRepo(db)
+	reservationRepo := repositories.NewReservationRepo(db)
+	reservationService := services.NewReservationService(reservationRepo, parkingRepo, cfg)
+	paymentRepo := repositories.NewPaymentRepo(db)
+	ledgerRepo := repositories.NewLedgerRepo(db)
+
+	// 初始化支付网关
+	gateway, err := payments.NewGateway(payments.Config{
+		Provider:         cfg.Payment.Provider,
+		Currency:         cfg.Payment.Currency,
+		MockDeclineAbove: cfg.Payment.MockDeclineAbove,
+	})
+	if err != nil {
+		logger.Log.Error("初始化支付网关失败，定时任务未启动", zap.Error(err))
+		return
+	}
+	paymentService := services.NewPaymentService(paymentRepo, gateway, cfg.Payment.Currency)
+
+	// 初始化邮件通知
+	notifyClient, err := notifier.NewClient(notifier.Config{
+		Mode:         cfg.Notifier.Mode,
+		SMTPHost:     cfg.Notifier.SMTPHost,
+		SMTPPort:     cfg.Notifier.SMTPPort,
+		SMTPUser:     cfg.Notifier.SMTPUser,
+		SMTPPassword: cfg.Notifier.SMTPPassword,
+		From:         cfg.Notifier.From,
+		SinkDir:      cfg.Notifier.SinkDir,
+	})
+	if err != nil {
+		logger.Log.Error("初始化邮件通知失败，定时任务未启动", zap.Error(err))
+		return
+	}
+	smsSender, err := notifier.NewSMSSender(cfg.Notifier.SMSProvider)
+	if err != nil {
+		logger.Log.Error("初始化短信通知失败，定时任务未启动", zap.Error(err))
+		return
+	}
+	notificationService := services.NewNotificationService(repositories.NewNotificationRepo(db), userRepo, notifyClient, smsSender)
+	auditService := services.NewAuditService(repositories.NewAuditRepo(db), userRepo, notificationService)
+	outboxService := services.NewOutboxService(repositories.NewOutboxRepo(db), cfg)
+	webhookService := services.NewWebhookService(repositories.NewWebhookRepo(db), outboxService, cfg)
+
+	// 租赁、停车和分账服务会向支付服务或发件箱注册回调，只初始化一次
+	leaseService := services.NewLeaseService(
+		leaseRepo,
+		parkingRepo,
+		paymentService,
+		notificationService,
+		outboxService,
+		cfg,
+	)
+	services.NewLedgerService(ledgerRepo, leaseRepo, parkingRepo, paymentService, cfg)
+	parkingService := services.NewParkingService(
+		parkingRepo,
+		userRepo,
+		repositories.NewVehicleRepo(db),
+		services.NewTariffService(tariffRepo, parkingRepo),
+		reservationService,
+		paymentService,
+		reportRepo,
+		auditService,
+		notificationService,
+		outboxService,
+	)
 
 	// 每天凌晨1点执行
 	c.AddFunc("0 1 * * *", func() {
 		ctx := context.Background()
 
-		// 初始化租赁服务（移除了支付和通知依赖）
-		leaseService := services.NewLeaseService(
-			leaseRepo,
-			parkingRepo,
-		)
-
-		// 过期租赁处理
-		if err := leaseService.CheckLeaseExpirations(ctx); err != nil {
-			logger.Log.Error("处理过期租赁失败", zap.Error(err))
-		}
-
 		// 初始化报表服务
 		reportService := services.NewReportService(reportRepo, parkingRepo)
 
-		// 生成日报表
-		if _, err := reportService.GenerateDailyReport(ctx, 1); err != nil {
+		// 保存前一天的日报表快照
+		yesterday := time.Now().AddDate(0, 0, -1)
+		if _, err := reportService.SnapshotDailyReports(ctx, yesterday, yesterday); err != nil {
 			logger.Log.Error("生成日报表失败", zap.Error(err))
 		}
 	})
@@ -47,16 +105,71 @@ func StartCronJobs(db *gorm.DB) {
 	// 每小时检查车位状态
 	c.AddFunc("@hourly", func() {
 		ctx := context.Background()
-		// 初始化停车服务
-		parkingService := services.NewParkingService(
-			parkingRepo,
-			userRepo,
-		)
-
 		if err := parkingService.CheckFaultySpots(ctx); err != nil {
 			logger.Log.Error("检查故障车位失败", zap.Error(err))
 		}
 	})
 
+	// 每15分钟处理到期租赁并发送到期提醒，使租赁在实际结束时间过期
+	c.AddFunc("@every 15m", func() {
+		ctx := context.Background()
+
+		// 过期租赁处理
+		if err := leaseService.CheckLeaseExpirations(ctx); err != nil {
+			logger.Log.Error("处理过期租赁失败", zap.Error(err))
+		}
+
+		// 到期提醒
+		if err := leaseService.SendExpiryReminders(ctx); err != nil {
+			logger.Log.Error("发送租赁到期提醒失败", zap.Error(err))
+		}
+	})
+
+	// 每5分钟取消超时未支付的租赁订单，释放被占用的租期
+	c.AddFunc("@every 5m", func() {
+		if err := leaseService.CancelStalePendingLeases(context.Background()); err != nil {
+			logger.Log.Error("取消超时未支付订单失败", zap.Error(err))
+		}
+	})
+
+	// 每天清理过期的刷新令牌、访问令牌撤销记录和登录失败记录
+	tokenRepo := repositories.NewTokenRepo(db)
+	twoFactorService := services.NewTwoFactorService(repositories.NewTwoFactorRepo(db), userRepo, tokenRepo, auditService, cfg)
+	tokenIssuer := services.NewTokenIssuer(repositories.NewSigningKeyRepo(db), cfg)
+	authService := services.NewAuthService(userRepo, tokenRepo, repositories.NewLoginAttemptRepo(db), twoFactorService, tokenIssuer, cfg)
+	c.AddFunc("@daily", func() {
+		if err := authService.PurgeExpiredTokens(context.Background()); err != nil {
+			logger.Log.Error("清理过期令牌失败", zap.Error(err))
+		}
+	})
+
+	// 每小时检查 JWT 签名密钥，超过轮换周期时轮换，并清理已过期的旧密钥
+	c.AddFunc("@hourly", func() {
+		if err := tokenIssuer.RotateIfDue(context.Background()); err != nil {
+			logger.Log.Error("轮换 JWT 签名密钥失败", zap.Error(err))
+		}
+	})
+
+	// 每5分钟清理超过宽限期仍未入场的预约
+	c.AddFunc("@every 5m", func() {
+		if err := reservationService.ExpireNoShows(context.Background()); err != nil {
+			logger.Log.Error("清理爽约预约失败", zap.Error(err))
+		}
+	})
+
+	// 分发发件箱中的领域事件，失败的事件按退避策略重试
+	c.AddFunc("@every "+outboxService.PollInterval().String(), func() {
+		if err := outboxService.Dispatch(context.Background()); err != nil {
+			logger.Log.Error("分发发件箱事件失败", zap.Error(err))
+		}
+	})
+
+	// 每分钟重试到期的 Webhook 投递
+	c.AddFunc("@every 1m", func() {
+		if err := webhookService.RetryDueDeliveries(context.Background()); err != nil {
+			logger.Log.Error("重试 Webhook 投递失败", zap.Error(err))
+		}
+	})
+
 	c.Start()
 }
@@ -0,0 +1,336 @@
+// internal/controllers/account_controller.go
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"modules/internal/models"
+	"modules/internal/services"
+	"net/http"
+	"time"
+)
+
+type AccountController struct {
+	service *services.AccountService
+}
+
+func NewAccountController(service *services.AccountService) *AccountController {
+	return &AccountController{service: service}
+}
+
+// ForgotPasswordRequest 找回密码请求
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest 重置密码请求
+type ResetPasswordRequest struct {
+	// 重置邮件中的令牌
+	Token string `json:"token" binding:"required"`
+	// 新密码，至少 8 位
+	Password string `json:"password" binding:"required"`
+}
+
+// ProfileResponse 当前用户资料
+type ProfileResponse struct {
+	ID            uint     `json:"id"`
+	Username      string   `json:"username"`
+	Email         string   `json:"email"`
+	EmailVerified bool     `json:"email_verified"`
+	Phone         string   `json:"phone"`
+	Roles         []string `json:"roles"`
+	Language      string   `json:"language"`
+	CreatedAt     string   `json:"created_at"`
+}
+
+// UpdateProfileRequest 修改资料请求
+type UpdateProfileRequest struct {
+	// 手机号，可带 + 国际区号，为空表示清除
+	Phone string `json:"phone" binding:"max=20"`
+	// 通知语言：zh 或 en
+	Language string `json:"language" binding:"required"`
+}
+
+// ChangePasswordRequest 修改密码请求
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	// 新密码，至少 8 位
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// ChangeEmailRequest 更换邮箱请求
+type ChangeEmailRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// DeleteAccountRequest 注销账户请求
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// ForgotPassword 找回密码
+// @Summary 找回密码
+// @Description 向账户邮箱发送密码重置链接。无论邮箱是否注册都返回相同结果
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body ForgotPasswordRequest true "注册邮箱"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /auth/password/forgot [post]
+func (c *AccountController) ForgotPassword(ctx *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "请求参数错误"})
+		return
+	}
+
+	if err := c.service.ForgotPassword(ctx, req.Email); err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "如果该邮箱已注册，重置链接已发送至邮箱"})
+}
+
+// ResetPassword 重置密码
+// @Summary 重置密码
+// @Description 使用重置邮件中的令牌设置新密码，令牌只能使用一次。重置后该账户已有的登录会话全部失效
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body ResetPasswordRequest true "令牌和新密码"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} ErrorResponse "链接无效或已过期，或密码不符合要求"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /auth/password/reset [post]
+func (c *AccountController) ResetPassword(ctx *gin.Context) {
+	var req ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "请求参数错误"})
+		return
+	}
+
+	if err := c.service.ResetPassword(ctx, req.Token, req.Password); err != nil {
+		respondAccountError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "密码已重置，请重新登录"})
+}
+
+// VerifyEmail 验证邮箱
+// @Summary 验证邮箱
+// @Description 打开验证邮件中的链接完成邮箱验证，令牌只能使用一次
+// @Tags auth
+// @Produce json
+// @Param token query string true "验证邮件中的令牌"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} ErrorResponse "链接无效或已过期"
+// @Failure 409 {object} ErrorResponse "该邮箱已被其他账户使用"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /auth/verify-email [get]
+func (c *AccountController) VerifyEmail(ctx *gin.Context) {
+	if _, err := c.service.VerifyEmail(ctx, ctx.Query("token")); err != nil {
+		respondAccountError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "邮箱验证成功"})
+}
+
+// ResendVerification 重新发送验证邮件
+// @Summary 重新发送验证邮件
+// @Description 向当前账户邮箱重新发送验证邮件，之前发出的验证链接随即失效
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} ErrorResponse "邮箱已验证"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /auth/verify-email/resend [post]
+func (c *AccountController) ResendVerification(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uint)
+	if err := c.service.ResendVerification(ctx, userID); err != nil {
+		respondAccountError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "验证邮件已发送"})
+}
+
+// GetMe 查询个人资料
+// @Summary 查询个人资料
+// @Tags account
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} ProfileResponse
+// @Failure 404 {object} ErrorResponse "用户不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /me [get]
+func (c *AccountController) GetMe(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uint)
+	user, err := c.service.GetProfile(ctx, userID)
+	if err != nil {
+		respondAccountError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ToProfileResponse(user))
+}
+
+// UpdateMe 修改个人资料
+// @Summary 修改个人资料
+// @Description 修改手机号和通知语言。邮箱和密码分别通过 /me/email 和 /me/password 修改
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param input body UpdateProfileRequest true "个人资料"
+// @Security BearerAuth
+// @Success 200 {object} ProfileResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误、手机号格式不正确或不支持的语言"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /me [put]
+func (c *AccountController) UpdateMe(ctx *gin.Context) {
+	var req UpdateProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "请求参数错误"})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	user, err := c.service.UpdateProfile(ctx, userID, req.Phone, req.Language)
+	if err != nil {
+		respondAccountError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ToProfileResponse(user))
+}
+
+// ChangePassword 修改密码
+// @Summary 修改密码
+// @Description 校验当前密码后设置新密码。当前会话保持登录，其他设备上的登录会话全部失效
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param input body ChangePasswordRequest true "当前密码和新密码"
+// @Security BearerAuth
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误、当前密码错误或新密码不符合要求"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /me/password [put]
+func (c *AccountController) ChangePassword(ctx *gin.Context) {
+	var req ChangePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "请求参数错误"})
+		return
+	}
+
+	claims := ctx.MustGet("claims").(*services.Claims)
+	if err := c.service.ChangePassword(ctx, claims.UserID, claims.SessionID, req.CurrentPassword, req.NewPassword); err != nil {
+		respondAccountError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "密码已修改"})
+}
+
+// ChangeEmail 更换邮箱
+// @Summary 更换邮箱
+// @Description 校验当前密码后向新邮箱发送验证邮件，打开邮件中的链接完成验证后账户邮箱才会更新
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param input body ChangeEmailRequest true "新邮箱和当前密码"
+// @Security BearerAuth
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误、邮箱格式不正确或当前密码错误"
+// @Failure 409 {object} ErrorResponse "该邮箱已被其他账户使用"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /me/email [post]
+func (c *AccountController) ChangeEmail(ctx *gin.Context) {
+	var req ChangeEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "请求参数错误"})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	if err := c.service.ChangeEmail(ctx, userID, req.Password, req.Email); err != nil {
+		respondAccountError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "验证邮件已发送至新邮箱，验证后生效"})
+}
+
+// DeleteMe 注销账户
+// @Summary 注销账户
+// @Description 校验当前密码后注销账户：用户名、邮箱和手机号被匿名化，车辆、通知和两步验证等个人数据被删除，
+// @Description 全部登录会话失效。停车记录和租赁订单作为财务记录保留。
+// @Description 存在进行中的租赁、停车、预约、预订申请或名下车位时不能注销，管理员账户不能注销
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param input body DeleteAccountRequest true "当前密码"
+// @Security BearerAuth
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误或当前密码错误"
+// @Failure 403 {object} ErrorResponse "管理员账户不能注销"
+// @Failure 409 {object} ErrorResponse "存在未结清的业务"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /me [delete]
+func (c *AccountController) DeleteMe(ctx *gin.Context) {
+	var req DeleteAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "请求参数错误"})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	if err := c.service.DeleteAccount(ctx, userID, req.Password); err != nil {
+		respondAccountError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "账户已注销"})
+}
+
+// ToProfileResponse 将用户转为个人资料响应
+func ToProfileResponse(u *models.User) *ProfileResponse {
+	roles := []models.Role{}
+	if len(u.Roles) > 0 {
+		// 角色列格式错误时按无角色展示
+		_ = u.Roles.Unmarshal(&roles)
+	}
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+	return &ProfileResponse{
+		ID:            u.ID,
+		Username:      u.Username,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified(),
+		Phone:         u.Phone,
+		Roles:         names,
+		Language:      u.Language,
+		CreatedAt:     u.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// respondAccountError 将账户流程的错误转为响应
+func respondAccountError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidUserToken),
+		errors.Is(err, models.ErrWeakPassword),
+		errors.Is(err, models.ErrEmailAlreadyVerified),
+		errors.Is(err, models.ErrIncorrectPassword),
+		errors.Is(err, models.ErrInvalidEmail),
+		errors.Is(err, models.ErrInvalidPhone),
+		errors.Is(err, models.ErrInvalidLanguage):
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, models.ErrAdminAccountDeletion):
+		ctx.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, models.ErrUserNotFound):
+		ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, models.ErrEmailInUse), errors.Is(err, models.ErrAccountHasObligations):
+		ctx.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
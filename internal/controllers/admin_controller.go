@@ -6,6 +6,7 @@ import (
 	"modules/internal/services"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -109,6 +110,108 @@ func (c *AdminController) UpdateSpotStatus(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, ToParkingSpotResponse(spot))
 }
 
+// RecoveryPolicyRequest 故障车位自动恢复策略请求
+type RecoveryPolicyRequest struct {
+	// 是否允许自动恢复
+	AutoRecover *bool `json:"auto_recover" binding:"required"`
+	// 故障持续多少小时后自动恢复
+	AfterHours int `json:"after_hours" binding:"required,min=1"`
+}
+
+// RecoveryPolicyResponse 故障车位自动恢复策略响应
+type RecoveryPolicyResponse struct {
+	SpotID      uint   `json:"spot_id"`
+	AutoRecover bool   `json:"auto_recover"`
+	AfterHours  int    `json:"after_hours"`
+	UpdatedBy   uint   `json:"updated_by,omitempty"`
+	UpdatedAt   string `json:"updated_at,omitempty"`
+}
+
+// GetRecoveryPolicy 查询故障车位自动恢复策略
+// @Summary 查询故障车位自动恢复策略
+// @Description 未配置策略的车位不会自动恢复
+// @Tags admin
+// @Produce json
+// @Param id path int true "车位ID"
+// @Security BearerAuth
+// @Success 200 {object} RecoveryPolicyResponse
+// @Failure 400 {object} ErrorResponse "无效的车位 ID"
+// @Failure 404 {object} ErrorResponse "车位不存在"
+// @Router /admin/spots/{id}/recovery-policy [get]
+func (c *AdminController) GetRecoveryPolicy(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的车位 ID"})
+		return
+	}
+
+	policy, err := c.parkingService.GetRecoveryPolicy(ctx, uint(id))
+	if err != nil {
+		if errors.Is(err, models.ErrParkingSpotNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ToRecoveryPolicyResponse(policy))
+}
+
+// SetRecoveryPolicy 配置故障车位自动恢复策略
+// @Summary 配置故障车位自动恢复策略
+// @Description 开启后，车位故障持续指定时长且没有未关闭的维修工单时由系统自动恢复为空闲
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Example {"auto_recover": true, "after_hours": 24}
+// @Param id path int true "车位ID"
+// @Param input body RecoveryPolicyRequest true "恢复策略"
+// @Security BearerAuth
+// @Success 200 {object} RecoveryPolicyResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "车位不存在"
+// @Router /admin/spots/{id}/recovery-policy [put]
+func (c *AdminController) SetRecoveryPolicy(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的车位 ID"})
+		return
+	}
+	var req RecoveryPolicyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	adminID := ctx.MustGet("userID").(uint)
+	policy, err := c.parkingService.SetRecoveryPolicy(ctx, adminID, uint(id), *req.AutoRecover, req.AfterHours)
+	if err != nil {
+		if errors.Is(err, models.ErrParkingSpotNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ToRecoveryPolicyResponse(policy))
+}
+
+// ToRecoveryPolicyResponse 将恢复策略转为响应结构
+func ToRecoveryPolicyResponse(p *models.SpotRecoveryPolicy) *RecoveryPolicyResponse {
+	resp := &RecoveryPolicyResponse{
+		SpotID:      p.SpotID,
+		AutoRecover: p.AutoRecover,
+		AfterHours:  p.AfterHours,
+		UpdatedBy:   p.UpdatedBy,
+	}
+	if !p.UpdatedAt.IsZero() {
+		resp.UpdatedAt = p.UpdatedAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
 // GetSystemStats 获取系统统计数据
 // @Summary 获取系统统计数据
 // @Description 返回当前系统的车位总数、可用车位数、各类型车位利用率等
@@ -129,13 +232,25 @@ func (c *AdminController) GetSystemStats(ctx *gin.Context) {
 }
 
 type AdminLoginResponse struct {
-	// JWT Token
-	Token string `json:"token"`
+	// 访问令牌（JWT），管理员必须完成两步验证，登录接口本身不返回令牌
+	Token string `json:"token,omitempty"`
+	// 刷新令牌，每次刷新后轮换，只能使用一次
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// 访问令牌有效期（秒）
+	ExpiresIn int64 `json:"expires_in,omitempty"`
+	// 固定为 true，使用 challenge_token 调用 /auth/login/2fa 完成登录
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
+	// 登录挑战有效期（秒）
+	ChallengeExpiresIn int64 `json:"challenge_expires_in,omitempty"`
+	// 尚未绑定两步验证时返回新生成的密钥
+	TwoFactorSetup *TwoFactorSetupResponse `json:"two_factor_setup,omitempty"`
+	RecoveryCodes  []string                `json:"recovery_codes,omitempty"`
 }
 
 // AdminLogin 管理员登录
 // @Summary 管理员登录
-// @Description 管理员登录并返回 JWT token
+// @Description 校验管理员密码并返回两步验证的登录挑战，调用 /auth/login/2fa 完成登录；首次登录时同时返回待绑定的 TOTP 密钥
 // @Tags admin
 // @Accept json
 // @Produce json
@@ -143,7 +258,8 @@ type AdminLoginResponse struct {
 // @Success 200 {object} AdminLoginResponse "登录成功，返回token"
 // @Failure 400 {object} ErrorResponse "请求参数错误"
 // @Failure 401 {object} ErrorResponse "认证失败，用户名或密码错误"
-// @Failure 403 {object} ErrorResponse "非管理员用户，无权访问"
+// @Failure 403 {object} ErrorResponse "非管理员用户或账户已停用"
+// @Failure 429 {object} ErrorResponse "登录失败次数过多，账户或 IP 暂时锁定"
 // @Router /admin/login [post]
 func (c *AdminController) AdminLogin(ctx *gin.Context) {
 	var req AdminLoginRequest
@@ -152,22 +268,18 @@ func (c *AdminController) AdminLogin(ctx *gin.Context) {
 		return
 	}
 
-	token, err := c.authService.AdminLogin(ctx, req.Username, req.Password)
+	result, err := c.authService.AdminLogin(ctx, req.Username, req.Password, ctx.ClientIP())
 	if err != nil {
-		if errors.Is(err, errors.New("非管理员用户，无权访问")) {
-			ctx.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
-		} else {
-			ctx.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
-		}
+		respondLoginError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, AdminLoginResponse{Token: token})
+	ctx.JSON(http.StatusOK, AdminLoginResponse(ToLoginResultResponse(result)))
 }
 
 // GetUserInfo 查询用户信息
 // @Summary 查询用户信息
-// @Description 管理员根据用户名查询用户 ID、注册邮箱、用户的停车位和密码
+// @Description 管理员根据用户名查询用户 ID、注册邮箱和用户的停车位
 // @Tags admin
 // @Produce json
 // @Param username path string true "用户名"
@@ -198,11 +310,40 @@ func (c *AdminController) GetUserInfo(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, userInfo)
 }
 
+// UnlockUser 解除账户登录锁定
+// @Summary 解除账户登录锁定
+// @Description 清零账户的连续登录失败次数并立即解除锁定，不影响按 IP 的锁定
+// @Tags admin
+// @Produce json
+// @Param id path int true "用户ID"
+// @Security BearerAuth
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} ErrorResponse "无效的用户 ID"
+// @Failure 404 {object} ErrorResponse "用户不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /admin/users/{id}/unlock [post]
+func (c *AdminController) UnlockUser(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的用户 ID"})
+		return
+	}
+
+	if err := c.authService.UnlockUser(ctx, uint(id)); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "已解除登录锁定"})
+}
+
 // AdminUserInfoResponse 管理员查询用户信息响应结构
 type AdminUserInfoResponse struct {
 	ID           uint                  `json:"id"`
 	Email        string                `json:"email"`
-	Password     string                `json:"password"`
 	ParkingSpots []*models.ParkingSpot `json:"parking_spots"`
 }
 
@@ -0,0 +1,94 @@
+// internal/controllers/audit_controller.go
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"modules/internal/models"
+	"modules/internal/repositories"
+	"modules/internal/services"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type AuditController struct {
+	service *services.AuditService
+}
+
+func NewAuditController(service *services.AuditService) *AuditController {
+	return &AuditController{service: service}
+}
+
+// AuditEventResponse 审计事件响应
+type AuditEventResponse struct {
+	ID         uint   `json:"id"`
+	ActorID    uint   `json:"actor_id"` // 操作人，0 表示系统自动处理
+	Action     string `json:"action"`
+	TargetType string `json:"target_type"`
+	TargetID   uint   `json:"target_id"`
+	Detail     string `json:"detail"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// ListEvents 查询审计事件
+// @Summary 查询审计事件
+// @Description 按动作、目标对象分页查询审计事件，按时间倒序排列
+// @Tags admin
+// @Produce json
+// @Param action query string false "动作，例如 spot.auto_recovered"
+// @Param target_type query string false "目标类型，例如 spot"
+// @Param target_id query int false "目标ID"
+// @Param page query int false "页码，从 1 开始"
+// @Param page_size query int false "每页条数，默认 20，最大 100"
+// @Security BearerAuth
+// @Success 200 {object} PageResponse{items=[]AuditEventResponse}
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /admin/audit-events [get]
+func (c *AuditController) ListEvents(ctx *gin.Context) {
+	page, pageSize, err := parsePagination(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	filter := repositories.AuditFilter{
+		Action:     ctx.Query("action"),
+		TargetType: ctx.Query("target_type"),
+		Offset:     (page - 1) * pageSize,
+		Limit:      pageSize,
+	}
+	if v := ctx.Query("target_id"); v != "" {
+		targetID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的目标 ID"})
+			return
+		}
+		filter.TargetID = uint(targetID)
+	}
+
+	events, total, err := c.service.ListEvents(ctx, filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	items := make([]*AuditEventResponse, 0, len(events))
+	for _, e := range events {
+		items = append(items, ToAuditEventResponse(e))
+	}
+	ctx.JSON(http.StatusOK, PageResponse{Items: items, Total: total, Page: page, PageSize: pageSize})
+}
+
+// ToAuditEventResponse 将审计事件转为响应结构
+func ToAuditEventResponse(e *models.AuditEvent) *AuditEventResponse {
+	return &AuditEventResponse{
+		ID:         e.ID,
+		ActorID:    e.ActorID,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		Detail:     e.Detail,
+		CreatedAt:  e.CreatedAt.Format(time.RFC3339),
+	}
+}
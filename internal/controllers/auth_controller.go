@@ -3,15 +3,18 @@ package controllers
 import (
 	"errors"
 	"log"
+	"math"
 	"modules/internal/models"
 	"modules/internal/services"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AuthController struct {
-	service *services.AuthService
+	service        *services.AuthService
+	accountService *services.AccountService
 }
 
 // RegisterResponse 用户注册响应
@@ -31,8 +34,40 @@ type SuccessResponse struct {
 
 // LoginResponse 用户登录响应
 type LoginResponse struct {
-	// JWT Token
-	Token string `json:"token"`
+	// 访问令牌（JWT）
+	Token string `json:"token,omitempty"`
+	// 刷新令牌，每次刷新后轮换，只能使用一次
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// 访问令牌有效期（秒）
+	ExpiresIn int64 `json:"expires_in,omitempty"`
+	// 需要两步验证时为 true，此时不返回令牌，使用 challenge_token 调用 /auth/login/2fa 完成登录
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
+	// 登录挑战有效期（秒）
+	ChallengeExpiresIn int64 `json:"challenge_expires_in,omitempty"`
+	// 必须启用两步验证但尚未绑定时返回，在验证器应用中添加后用生成的验证码完成登录
+	TwoFactorSetup *TwoFactorSetupResponse `json:"two_factor_setup,omitempty"`
+	// 登录时完成两步验证绑定返回的恢复码，只展示这一次
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// VerifyLoginRequest 两步验证登录请求
+type VerifyLoginRequest struct {
+	// 密码校验通过后返回的登录挑战
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	// 验证器应用中的 6 位验证码或恢复码
+	Code string `json:"code" binding:"required"`
+}
+
+// RefreshRequest 刷新令牌请求
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutRequest 退出登录请求
+type LogoutRequest struct {
+	// 可选，同时撤销该刷新令牌
+	RefreshToken string `json:"refresh_token"`
 }
 
 // RegisterRequest 用户注册请求
@@ -63,13 +98,13 @@ type TokenResponse struct {
 	Token string `json:"token"`
 }
 
-func NewAuthController(service *services.AuthService) *AuthController {
-	return &AuthController{service: service}
+func NewAuthController(service *services.AuthService, accountService *services.AccountService) *AuthController {
+	return &AuthController{service: service, accountService: accountService}
 }
 
 // Register 用户注册
 // @Summary 用户注册
-// @Description 注册一个新用户
+// @Description 注册一个新用户，并向注册邮箱发送验证邮件。邮箱验证前不能租赁或购买车位
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -86,26 +121,30 @@ func (c *AuthController) Register(ctx *gin.Context) {
 		return
 	}
 
-	err := c.service.Register(ctx, req.Username, req.Password, req.Email)
+	user, err := c.service.Register(ctx, req.Username, req.Password, req.Email)
 	if err != nil {
-		if errors.Is(err, models.ErrUserNotFound) {
-			// 用户不存在，正常注册，不应该返回错误
-			// 继续执行创建用户逻辑，这里代码逻辑已在 service 里处理
-		} else if errors.Is(err, errors.New("用户名已存在")) {
-			ctx.JSON(http.StatusConflict, ErrorResponse{Error: "用户名已存在"})
-			return
-		} else {
+		switch {
+		case errors.Is(err, models.ErrInvalidEmail):
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		case errors.Is(err, models.ErrUserExists):
+			ctx.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		default:
 			ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
-			return
 		}
+		return
+	}
+
+	// 验证邮件发送失败不影响注册，用户可以稍后重新发送
+	if err := c.accountService.SendVerificationEmail(ctx, user, user.Email); err != nil {
+		log.Printf("发送验证邮件失败: %v", err)
 	}
 
-	ctx.JSON(http.StatusCreated, SuccessResponse{Message: "注册成功"})
+	ctx.JSON(http.StatusCreated, SuccessResponse{Message: "注册成功，请查收验证邮件"})
 }
 
 // UserLogin 用户登录
 // @Summary 用户登录
-// @Description 用户登录并返回 JWT token
+// @Description 用户登录并返回 JWT token。启用了两步验证的账户返回 challenge_token，需调用 /auth/login/2fa 完成登录
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -114,6 +153,8 @@ func (c *AuthController) Register(ctx *gin.Context) {
 // @Success 200 {object} LoginResponse "登录成功，返回token"
 // @Failure 400 {object} ErrorResponse "请求参数错误"
 // @Failure 401 {object} ErrorResponse "认证失败，用户名或密码错误"
+// @Failure 403 {object} ErrorResponse "账户已停用"
+// @Failure 429 {object} ErrorResponse "登录失败次数过多，账户或 IP 暂时锁定"
 // @Router /login [post]
 func (c *AuthController) UserLogin(ctx *gin.Context) {
 	var req LoginRequest
@@ -123,12 +164,147 @@ func (c *AuthController) UserLogin(ctx *gin.Context) {
 		return
 	}
 
-	token, err := c.service.Login(ctx, req.Username, req.Password, false)
+	result, err := c.service.Login(ctx, req.Username, req.Password, ctx.ClientIP(), false)
 	if err != nil {
 		log.Printf("用户登录失败: %v", err)
-		ctx.JSON(http.StatusUnauthorized, ErrorResponse{Error: "认证失败，用户名或密码错误"})
+		respondLoginError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, LoginResponse{Token: token})
+	ctx.JSON(http.StatusOK, ToLoginResultResponse(result))
+}
+
+// VerifyLogin 两步验证登录
+// @Summary 两步验证登录
+// @Description 使用登录接口返回的 challenge_token 和验证码（或恢复码）完成登录，用户登录和管理员登录共用。
+// @Description 登录接口返回了 two_factor_setup 时，使用新密钥生成的验证码即完成绑定，响应中包含恢复码
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body VerifyLoginRequest true "登录挑战和验证码"
+// @Success 200 {object} LoginResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 401 {object} ErrorResponse "验证码错误或登录挑战已失效"
+// @Failure 403 {object} ErrorResponse "账户已停用"
+// @Failure 429 {object} ErrorResponse "失败次数过多，账户或 IP 暂时锁定"
+// @Router /auth/login/2fa [post]
+func (c *AuthController) VerifyLogin(ctx *gin.Context) {
+	var req VerifyLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "请求参数错误"})
+		return
+	}
+
+	result, err := c.service.VerifyLogin(ctx, req.ChallengeToken, req.Code, ctx.ClientIP())
+	if err != nil {
+		log.Printf("两步验证登录失败: %v", err)
+		respondLoginError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ToLoginResultResponse(result))
+}
+
+// Refresh 刷新令牌
+// @Summary 刷新令牌
+// @Description 使用刷新令牌换取新的访问令牌和刷新令牌，旧刷新令牌随即失效。已使用过的刷新令牌再次提交时，该登录会话的全部刷新令牌都会被撤销
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body RefreshRequest true "刷新令牌"
+// @Success 200 {object} LoginResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 401 {object} ErrorResponse "刷新令牌无效、已过期或已被使用"
+// @Failure 403 {object} ErrorResponse "账户已停用或需重新登录启用两步验证"
+// @Router /auth/refresh [post]
+func (c *AuthController) Refresh(ctx *gin.Context) {
+	var req RefreshRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "请求参数错误"})
+		return
+	}
+
+	tokens, err := c.service.Refresh(ctx, req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidRefreshToken) || errors.Is(err, models.ErrRefreshTokenReused) {
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
+		} else if errors.Is(err, models.ErrAccountDisabled) || errors.Is(err, models.ErrTwoFactorMandatory) {
+			ctx.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, ToLoginResponse(tokens))
+}
+
+// Logout 退出登录
+// @Summary 退出登录
+// @Description 撤销当前访问令牌以及本次登录签发的刷新令牌
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body LogoutRequest false "可选的刷新令牌"
+// @Security BearerAuth
+// @Success 200 {object} MessageResponse
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /auth/logout [post]
+func (c *AuthController) Logout(ctx *gin.Context) {
+	var req LogoutRequest
+	// 请求体可以为空
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "请求参数错误"})
+			return
+		}
+	}
+
+	claims := ctx.MustGet("claims").(*services.Claims)
+	if err := c.service.Logout(ctx, claims, req.RefreshToken); err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "已退出登录"})
+}
+
+// respondLoginError 将登录错误转为响应，锁定时通过 Retry-After 告知剩余秒数
+func respondLoginError(ctx *gin.Context, err error) {
+	var locked *services.LoginLockedError
+	switch {
+	case errors.As(err, &locked):
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+		ctx.JSON(http.StatusTooManyRequests, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, models.ErrInvalidCredentials),
+		errors.Is(err, models.ErrInvalidOTP),
+		errors.Is(err, models.ErrInvalidChallenge),
+		errors.Is(err, models.ErrTwoFactorSetupRequired):
+		ctx.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, models.ErrAccountDisabled), errors.Is(err, models.ErrNotAdmin):
+		ctx.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: "登录失败，请稍后再试"})
+	}
+}
+
+// ToLoginResponse 将签发的令牌转为响应结构
+func ToLoginResponse(tokens *services.TokenPair) LoginResponse {
+	return LoginResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    int64(tokens.ExpiresIn.Seconds()),
+	}
+}
+
+// ToLoginResultResponse 将登录结果转为响应结构，需要两步验证时返回登录挑战
+func ToLoginResultResponse(result *services.LoginResult) LoginResponse {
+	if result.Challenge != nil {
+		return LoginResponse{
+			TwoFactorRequired:  true,
+			ChallengeToken:     result.Challenge.Token,
+			ChallengeExpiresIn: int64(result.Challenge.ExpiresIn.Seconds()),
+			TwoFactorSetup:     ToTwoFactorSetupResponse(result.Challenge.Setup),
+		}
+	}
+	resp := ToLoginResponse(result.Tokens)
+	resp.RecoveryCodes = result.RecoveryCodes
+	return resp
 }
@@ -1,10 +1,12 @@
 package controllers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"modules/internal/models"
 	"modules/internal/services"
 	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -18,15 +20,17 @@ func NewLeaseController(service *services.LeaseService) *LeaseController {
 
 // CreateLease 创建租赁订单
 // @Summary 创建租赁订单
-// @Description 用户根据车位ID和租赁时长创建订单
+// @Description 用户根据车位ID和租赁时长创建订单，按车位的月租价格计价。订单处于待支付状态，支付成功后生效
 // @Tags lease
 // @Accept json
 // @Produce json
-// @Example {"spot_id": 2, "months": 3, "rate": 300}
+// @Example {"spot_id": 2, "months": 3}
 // @Param input body LeaseRequest true "租赁信息"
 // @Security BearerAuth
 // @Success 200 {object} LeaseResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "车位不存在"
+// @Failure 409 {object} ErrorResponse "车位在该时段已被租用"
 // @Failure 500 {object} ErrorResponse
 // @Router /lease [post]
 func (c *LeaseController) CreateLease(ctx *gin.Context) {
@@ -38,34 +42,384 @@ func (c *LeaseController) CreateLease(ctx *gin.Context) {
 
 	userID := ctx.MustGet("userID").(uint)
 
-	lease, err := c.service.CreateLease(ctx, userID, req.SpotID, req.Months, req.Rate)
+	lease, err := c.service.CreateLease(ctx, userID, req.SpotID, req.Months)
+	if err != nil {
+		if errors.Is(err, models.ErrParkingSpotNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else if errors.Is(err, models.ErrLeaseConflict) {
+			ctx.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ToLeaseResponse(lease))
+}
+
+// ListLeases 查询我的租赁订单
+// @Summary 查询我的租赁订单
+// @Description 分页查询当前用户作为承租人的租赁订单，可按状态过滤
+// @Tags lease
+// @Produce json
+// @Param status query string false "订单状态：pending/active/expired/terminated/cancelled"
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页条数，默认20，最大100"
+// @Security BearerAuth
+// @Success 200 {object} PageResponse{items=[]LeaseResponse}
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /lease [get]
+func (c *LeaseController) ListLeases(ctx *gin.Context) {
+	page, pageSize, err := parsePagination(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	leases, total, err := c.service.ListLeases(ctx, userID, models.LeaseStatus(ctx.Query("status")), page, pageSize)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		return
 	}
 
+	ctx.JSON(http.StatusOK, toLeasePage(leases, total, page, pageSize))
+}
+
+// ListOwnerLeases 查询业主车位上的租赁订单
+// @Summary 查询业主车位上的租赁订单
+// @Description 分页查询当前业主名下所有车位上的租赁订单，可按状态过滤
+// @Tags owner
+// @Produce json
+// @Param status query string false "订单状态：pending/active/expired/terminated/cancelled"
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页条数，默认20，最大100"
+// @Security BearerAuth
+// @Success 200 {object} PageResponse{items=[]LeaseResponse}
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /owner/leases [get]
+func (c *LeaseController) ListOwnerLeases(ctx *gin.Context) {
+	page, pageSize, err := parsePagination(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	leases, total, err := c.service.ListOwnerLeases(ctx, userID, models.LeaseStatus(ctx.Query("status")), page, pageSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, toLeasePage(leases, total, page, pageSize))
+}
+
+// GetLease 查询租赁订单详情
+// @Summary 查询租赁订单详情
+// @Description 承租人或车位业主查询租赁订单详情，其他用户返回不存在
+// @Tags lease
+// @Produce json
+// @Param id path int true "租赁订单ID"
+// @Security BearerAuth
+// @Success 200 {object} LeaseResponse
+// @Failure 400 {object} ErrorResponse "无效的租赁订单 ID"
+// @Failure 404 {object} ErrorResponse "租赁订单不存在"
+// @Router /lease/{id} [get]
+func (c *LeaseController) GetLease(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的租赁订单 ID"})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	lease, err := c.service.GetLease(ctx, userID, uint(id))
+	if err != nil {
+		if errors.Is(err, models.ErrLeaseNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ToLeaseResponse(lease))
+}
+
+// PayLease 支付租赁订单
+// @Summary 支付租赁订单
+// @Description 对待支付的租赁订单扣款，支付成功后订单生效；上次支付失败时会重新发起支付
+// @Tags lease
+// @Accept json
+// @Produce json
+// @Example {"lease_id": 1, "amount": 900}
+// @Param input body PaymentRequest true "支付信息"
+// @Security BearerAuth
+// @Success 200 {object} PaymentResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 402 {object} ErrorResponse "支付失败"
+// @Failure 404 {object} ErrorResponse "租赁订单不存在"
+// @Failure 409 {object} ErrorResponse "支付正在处理中"
+// @Router /lease/pay [post]
+func (c *LeaseController) PayLease(ctx *gin.Context) {
+	var req PaymentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	payment, err := c.service.PayLease(ctx, userID, req.LeaseID, req.Amount)
+	if err != nil {
+		if errors.Is(err, models.ErrLeaseNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else if errors.Is(err, models.ErrPaymentProcessing) {
+			ctx.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	if payment.Status == models.PaymentFailed {
+		ctx.JSON(http.StatusPaymentRequired, ToPaymentResponse(payment))
+		return
+	}
+	ctx.JSON(http.StatusOK, ToPaymentResponse(payment))
+}
+
+// TerminateLease 提前终止租赁
+// @Summary 提前终止租赁
+// @Description 待支付订单直接取消；生效中的订单提前终止并释放车位，按剩余租期折算租金、扣除违约金后生成退款申请，待管理员审核
+// @Tags lease
+// @Accept json
+// @Produce json
+// @Param id path int true "租赁订单ID"
+// @Param input body TerminateLeaseRequest false "终止原因"
+// @Security BearerAuth
+// @Success 200 {object} TerminateLeaseResponse
+// @Failure 400 {object} ErrorResponse "订单状态不允许终止"
+// @Failure 404 {object} ErrorResponse "租赁订单不存在"
+// @Failure 409 {object} ErrorResponse "订单状态已变更或正在支付"
+// @Router /lease/{id}/terminate [post]
+func (c *LeaseController) TerminateLease(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的租赁订单 ID"})
+		return
+	}
+
+	var req TerminateLeaseRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	lease, refund, err := c.service.TerminateLease(ctx, userID, uint(id), req.Reason)
+	if err != nil {
+		if errors.Is(err, models.ErrLeaseNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else if errors.Is(err, models.ErrLeaseStateChanged) {
+			ctx.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	response := &TerminateLeaseResponse{Lease: ToLeaseResponse(lease)}
+	if refund != nil {
+		response.Refund = ToRefundResponse(refund)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// SetAutoRenew 设置自动续租
+// @Summary 设置自动续租
+// @Description 开启后，订单到期时按原租期和租金自动生成下一期订单并扣款；车位已出售或转让时续租失败并记录原因
+// @Tags lease
+// @Accept json
+// @Produce json
+// @Example {"auto_renew": true}
+// @Param id path int true "租赁订单ID"
+// @Param input body AutoRenewRequest true "自动续租设置"
+// @Security BearerAuth
+// @Success 200 {object} LeaseResponse
+// @Failure 400 {object} ErrorResponse "订单状态不允许设置"
+// @Failure 404 {object} ErrorResponse "租赁订单不存在"
+// @Failure 409 {object} ErrorResponse "订单状态已变更"
+// @Router /lease/{id}/auto-renew [put]
+func (c *LeaseController) SetAutoRenew(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的租赁订单 ID"})
+		return
+	}
+
+	var req AutoRenewRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	lease, err := c.service.SetAutoRenew(ctx, userID, uint(id), *req.AutoRenew)
+	if err != nil {
+		if errors.Is(err, models.ErrLeaseNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else if errors.Is(err, models.ErrLeaseStateChanged) {
+			ctx.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
 	ctx.JSON(http.StatusOK, ToLeaseResponse(lease))
 }
 
+// ListUpcomingRenewals 查询即将自动续租的订单
+// @Summary 查询即将自动续租的订单
+// @Description 查询未来若干天内到期且开启自动续租的订单，以及预计的下一期时段和扣款金额
+// @Tags lease
+// @Produce json
+// @Param days query int false "查询天数，默认30"
+// @Security BearerAuth
+// @Success 200 {array} UpcomingRenewalResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /lease/renewals/upcoming [get]
+func (c *LeaseController) ListUpcomingRenewals(ctx *gin.Context) {
+	days, err := strconv.Atoi(ctx.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的查询天数"})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	renewals, err := c.service.ListUpcomingRenewals(ctx, userID, days)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := make([]*UpcomingRenewalResponse, 0, len(renewals))
+	for _, r := range renewals {
+		response = append(response, &UpcomingRenewalResponse{
+			Lease:     ToLeaseResponse(r.Lease),
+			NextStart: r.NextStart.Format("2006-01-02"),
+			NextEnd:   r.NextEnd.Format("2006-01-02"),
+			Amount:    r.Amount,
+		})
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// ListRenewals 查询订单的自动续租记录
+// @Summary 查询自动续租记录
+// @Description 查询订单每次自动续租的结果，失败时包含失败原因
+// @Tags lease
+// @Produce json
+// @Param id path int true "租赁订单ID"
+// @Security BearerAuth
+// @Success 200 {array} LeaseRenewalResponse
+// @Failure 400 {object} ErrorResponse "无效的租赁订单 ID"
+// @Failure 404 {object} ErrorResponse "租赁订单不存在"
+// @Router /lease/{id}/renewals [get]
+func (c *LeaseController) ListRenewals(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的租赁订单 ID"})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	renewals, err := c.service.ListRenewals(ctx, userID, uint(id))
+	if err != nil {
+		if errors.Is(err, models.ErrLeaseNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	response := make([]*LeaseRenewalResponse, 0, len(renewals))
+	for _, r := range renewals {
+		response = append(response, &LeaseRenewalResponse{
+			ID:         r.ID,
+			LeaseID:    r.LeaseID,
+			NewLeaseID: r.NewLeaseID,
+			Status:     string(r.Status),
+			Reason:     r.Reason,
+			CreatedAt:  r.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
 //
 // ========== DTO 定义与响应结构 ==========
 //
 
+// AutoRenewRequest 自动续租设置请求
+type AutoRenewRequest struct {
+	AutoRenew *bool `json:"auto_renew" binding:"required"` // 是否自动续租
+}
+
+// UpcomingRenewalResponse 即将续租订单响应
+type UpcomingRenewalResponse struct {
+	Lease     *LeaseResponse `json:"lease"`      // 当前订单
+	NextStart string         `json:"next_start"` // 下一期起始日期
+	NextEnd   string         `json:"next_end"`   // 下一期结束日期
+	Amount    float64        `json:"amount"`     // 预计扣款金额
+}
+
+// LeaseRenewalResponse 续租记录响应
+type LeaseRenewalResponse struct {
+	ID         uint   `json:"id"`                     // 记录ID
+	LeaseID    uint   `json:"lease_id"`               // 原订单ID
+	NewLeaseID *uint  `json:"new_lease_id,omitempty"` // 续租订单ID
+	Status     string `json:"status"`                 // 续租结果
+	Reason     string `json:"reason,omitempty"`       // 失败原因
+	CreatedAt  string `json:"created_at"`             // 执行时间
+}
+
+// TerminateLeaseRequest 终止租赁请求
+type TerminateLeaseRequest struct {
+	Reason string `json:"reason"` // 终止原因
+}
+
+// TerminateLeaseResponse 终止租赁响应
+type TerminateLeaseResponse struct {
+	Lease  *LeaseResponse  `json:"lease"`
+	Refund *RefundResponse `json:"refund,omitempty"`
+}
+
 // LeaseRequest 租赁订单请求结构
 type LeaseRequest struct {
-	SpotID uint    `json:"spot_id" binding:"required"`      // 车位ID
-	Months int     `json:"months" binding:"required,min=1"` // 租赁月数
-	Rate   float64 `json:"rate" binding:"required"`         // 每月租金
+	SpotID uint `json:"spot_id" binding:"required"`      // 车位ID
+	Months int  `json:"months" binding:"required,min=1"` // 租赁月数
 }
 
 // LeaseResponse 租赁订单响应结构
 type LeaseResponse struct {
-	ID        uint    `json:"id"`         // 订单ID
-	SpotID    uint    `json:"spot_id"`    // 车位ID
-	StartDate string  `json:"start_date"` // 起始日期（格式：YYYY-MM-DD）
-	EndDate   string  `json:"end_date"`   // 结束日期
-	Total     float64 `json:"total"`      // 总金额
-	Status    string  `json:"status"`     // 当前状态
+	ID           uint    `json:"id"`                      // 订单ID
+	UserID       uint    `json:"user_id"`                 // 承租人ID
+	SpotID       uint    `json:"spot_id"`                 // 车位ID
+	StartDate    string  `json:"start_date"`              // 起始日期（格式：YYYY-MM-DD）
+	EndDate      string  `json:"end_date"`                // 结束日期
+	Total        float64 `json:"total"`                   // 总金额
+	Status       string  `json:"status"`                  // 当前状态
+	AutoRenew    bool    `json:"auto_renew"`              // 是否自动续租
+	CreatedAt    string  `json:"created_at"`              // 下单时间
+	TerminatedAt string  `json:"terminated_at,omitempty"` // 提前终止时间
 }
 
 // RentParkingSpotRequest 出租车位请求
@@ -103,14 +457,65 @@ type PaymentResponse struct {
 	Status      string  `json:"status"`      // 支付状态
 }
 
+// RefundResponse 退款申请响应结构
+type RefundResponse struct {
+	ID         uint    `json:"id"`          // 退款申请ID
+	PaymentID  uint    `json:"payment_id"`  // 支付单ID
+	Amount     float64 `json:"amount"`      // 退款金额
+	Penalty    float64 `json:"penalty"`     // 违约金
+	Reason     string  `json:"reason"`      // 退款原因
+	Status     string  `json:"status"`      // 审核状态
+	ReviewNote string  `json:"review_note"` // 审核备注
+	CreatedAt  string  `json:"created_at"`  // 申请时间
+}
+
+// ToRefundResponse 将退款申请转为响应结构
+func ToRefundResponse(r *models.Refund) *RefundResponse {
+	return &RefundResponse{
+		ID:         r.ID,
+		PaymentID:  r.PaymentID,
+		Amount:     r.Amount,
+		Penalty:    r.Penalty,
+		Reason:     r.Reason,
+		Status:     string(r.Status),
+		ReviewNote: r.ReviewNote,
+		CreatedAt:  r.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// ToPaymentResponse 将支付单转为响应结构
+func ToPaymentResponse(p *models.Payment) *PaymentResponse {
+	return &PaymentResponse{
+		ID:          strconv.FormatUint(uint64(p.ID), 10),
+		Amount:      p.Amount,
+		Description: p.Description,
+		Status:      string(p.Status),
+	}
+}
+
 // ToLeaseResponse 将租赁模型转为响应结构
 func ToLeaseResponse(l *models.LeaseOrder) *LeaseResponse {
-	return &LeaseResponse{
+	res := &LeaseResponse{
 		ID:        l.ID,
+		UserID:    l.UserID,
 		SpotID:    l.SpotID,
 		StartDate: l.StartDate.Format("2006-01-02"),
 		EndDate:   l.EndDate.Format("2006-01-02"),
 		Total:     l.TotalPrice,
 		Status:    string(l.Status),
+		AutoRenew: l.AutoRenew,
+		CreatedAt: l.CreatedAt.Format(time.RFC3339),
+	}
+	if l.TerminatedAt != nil {
+		res.TerminatedAt = l.TerminatedAt.Format(time.RFC3339)
+	}
+	return res
+}
+
+func toLeasePage(leases []*models.LeaseOrder, total int64, page, pageSize int) *PageResponse {
+	items := make([]*LeaseResponse, 0, len(leases))
+	for _, l := range leases {
+		items = append(items, ToLeaseResponse(l))
 	}
+	return &PageResponse{Items: items, Total: total, Page: page, PageSize: pageSize}
 }
@@ -0,0 +1,160 @@
+// internal/controllers/ledger_controller.go
+package controllers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"modules/internal/services"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type LedgerController struct {
+	service *services.LedgerService
+}
+
+func NewLedgerController(service *services.LedgerService) *LedgerController {
+	return &LedgerController{service: service}
+}
+
+// StatementEntryResponse 对账单明细
+type StatementEntryResponse struct {
+	ID          uint    `json:"id"`
+	SourceType  string  `json:"source_type"` // 来源：lease 租赁 / parking 停车 / refund 退款冲销（金额为负）
+	SourceID    uint    `json:"source_id"`
+	SpotID      uint    `json:"spot_id"`
+	Description string  `json:"description"`
+	Gross       float64 `json:"gross"`       // 总收入
+	Commission  float64 `json:"commission"`  // 平台佣金
+	OwnerShare  float64 `json:"owner_share"` // 业主分成
+	OccurredAt  string  `json:"occurred_at"`
+}
+
+// StatementResponse 业主月度对账单
+type StatementResponse struct {
+	OwnerID        uint                      `json:"owner_id"`
+	Month          string                    `json:"month"`
+	OpeningBalance float64                   `json:"opening_balance"` // 期初应付余额
+	Gross          float64                   `json:"gross"`
+	Commission     float64                   `json:"commission"`
+	OwnerShare     float64                   `json:"owner_share"`
+	ClosingBalance float64                   `json:"closing_balance"` // 期末应付余额
+	Entries        []*StatementEntryResponse `json:"entries"`
+}
+
+// GetOwnerStatement 查询业主月度对账单
+// @Summary 查询业主月度对账单
+// @Description 汇总业主名下车位当月的租赁和停车收入，列出每笔收入的平台佣金和业主分成，以及期初、期末应付余额
+// @Tags owner
+// @Produce json
+// @Param month query string false "月份（YYYY-MM），默认当月"
+// @Security BearerAuth
+// @Success 200 {object} StatementResponse
+// @Failure 400 {object} ErrorResponse "无效的月份"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /owner/statements [get]
+func (c *LedgerController) GetOwnerStatement(ctx *gin.Context) {
+	statement, ok := c.loadStatement(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, ToStatementResponse(statement))
+}
+
+// ExportOwnerStatement 导出业主月度对账单
+// @Summary 导出业主月度对账单
+// @Description 以 CSV 格式导出业主月度对账单明细及汇总
+// @Tags owner
+// @Produce text/csv
+// @Param month query string false "月份（YYYY-MM），默认当月"
+// @Security BearerAuth
+// @Success 200 {file} file "CSV 文件"
+// @Failure 400 {object} ErrorResponse "无效的月份"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /owner/statements/export [get]
+func (c *LedgerController) ExportOwnerStatement(ctx *gin.Context) {
+	statement, ok := c.loadStatement(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=statement-%s.csv", statement.Month))
+	ctx.Status(http.StatusOK)
+	// 写入 BOM，便于 Excel 正确识别中文
+	ctx.Writer.WriteString("\xEF\xBB\xBF")
+
+	w := csv.NewWriter(ctx.Writer)
+	w.Write([]string{"日期", "来源", "来源ID", "车位ID", "说明", "总收入", "平台佣金", "业主分成"})
+	for _, e := range statement.Entries {
+		w.Write([]string{
+			e.OccurredAt.Format("2006-01-02 15:04:05"),
+			string(e.SourceType),
+			strconv.FormatUint(uint64(e.SourceID), 10),
+			strconv.FormatUint(uint64(e.SpotID), 10),
+			e.Description,
+			formatAmount(e.Gross),
+			formatAmount(e.Commission),
+			formatAmount(e.OwnerShare),
+		})
+	}
+	w.Write([]string{"合计", "", "", "", "", formatAmount(statement.Gross), formatAmount(statement.Commission), formatAmount(statement.OwnerShare)})
+	w.Write([]string{"期初余额", "", "", "", "", "", "", formatAmount(statement.OpeningBalance)})
+	w.Write([]string{"期末余额", "", "", "", "", "", "", formatAmount(statement.ClosingBalance)})
+	w.Flush()
+}
+
+// loadStatement 解析月份参数并生成当前业主的对账单，失败时已写入响应
+func (c *LedgerController) loadStatement(ctx *gin.Context) (*services.OwnerStatement, bool) {
+	month := time.Now()
+	if v := ctx.Query("month"); v != "" {
+		parsed, err := time.ParseInLocation("2006-01", v, time.Local)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的月份，格式应为 YYYY-MM"})
+			return nil, false
+		}
+		month = parsed
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	statement, err := c.service.OwnerStatement(ctx, userID, month)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return nil, false
+	}
+	return statement, true
+}
+
+// ToStatementResponse 将对账单转为响应结构
+func ToStatementResponse(s *services.OwnerStatement) *StatementResponse {
+	entries := make([]*StatementEntryResponse, 0, len(s.Entries))
+	for _, e := range s.Entries {
+		entries = append(entries, &StatementEntryResponse{
+			ID:          e.ID,
+			SourceType:  string(e.SourceType),
+			SourceID:    e.SourceID,
+			SpotID:      e.SpotID,
+			Description: e.Description,
+			Gross:       e.Gross,
+			Commission:  e.Commission,
+			OwnerShare:  e.OwnerShare,
+			OccurredAt:  e.OccurredAt.Format(time.RFC3339),
+		})
+	}
+	return &StatementResponse{
+		OwnerID:        s.OwnerID,
+		Month:          s.Month,
+		OpeningBalance: s.OpeningBalance,
+		Gross:          s.Gross,
+		Commission:     s.Commission,
+		OwnerShare:     s.OwnerShare,
+		ClosingBalance: s.ClosingBalance,
+		Entries:        entries,
+	}
+}
+
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
@@ -0,0 +1,333 @@
+// internal/controllers/maintenance_controller.go
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"modules/internal/models"
+	"modules/internal/repositories"
+	"modules/internal/services"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type MaintenanceController struct {
+	service *services.MaintenanceService
+}
+
+func NewMaintenanceController(service *services.MaintenanceService) *MaintenanceController {
+	return &MaintenanceController{service: service}
+}
+
+// ReportProblemRequest 报修请求
+type ReportProblemRequest struct {
+	// 车位ID
+	SpotID uint `json:"spot_id" binding:"required"`
+	// 故障描述
+	Description string `json:"description" binding:"required"`
+}
+
+// TriageRequest 分诊请求
+type TriageRequest struct {
+	// 优先级：low / normal / high / urgent
+	Priority string `json:"priority" binding:"required,oneof=low normal high urgent"`
+	// 备注
+	Note string `json:"note"`
+}
+
+// AssignRequest 指派请求
+type AssignRequest struct {
+	// 维修人员用户ID
+	AssigneeID uint `json:"assignee_id" binding:"required"`
+	// 备注
+	Note string `json:"note"`
+}
+
+// ResolveRequest 关闭工单请求
+type ResolveRequest struct {
+	// 处理结果
+	Resolution string `json:"resolution" binding:"required"`
+}
+
+// MaintenanceHistoryResponse 工单状态变更记录
+type MaintenanceHistoryResponse struct {
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	ActorID    uint   `json:"actor_id"`
+	Note       string `json:"note"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// MaintenanceResponse 维修工单响应
+type MaintenanceResponse struct {
+	ID          uint                          `json:"id"`
+	SpotID      uint                          `json:"spot_id"`
+	Description string                        `json:"description"`
+	ReportedBy  uint                          `json:"reported_by"`
+	Status      string                        `json:"status"`
+	Priority    string                        `json:"priority"`
+	AssigneeID  *uint                         `json:"assignee_id,omitempty"`
+	Resolution  string                        `json:"resolution,omitempty"`
+	CreatedAt   string                        `json:"created_at"`
+	ResolvedAt  string                        `json:"resolved_at,omitempty"`
+	History     []*MaintenanceHistoryResponse `json:"history,omitempty"`
+}
+
+// ReportProblem 上报车位故障
+// @Summary 上报车位故障
+// @Description 任意登录用户均可报修，报修后车位被标记为故障，直到工单全部处理完成
+// @Tags maintenance
+// @Accept json
+// @Produce json
+// @Example {"spot_id": 1, "description": "地锁无法升起"}
+// @Param input body ReportProblemRequest true "报修信息"
+// @Security BearerAuth
+// @Success 201 {object} MaintenanceResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "车位不存在"
+// @Router /maintenance [post]
+func (c *MaintenanceController) ReportProblem(ctx *gin.Context) {
+	var req ReportProblemRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	record, err := c.service.ReportProblem(ctx, userID, req.SpotID, req.Description)
+	if err != nil {
+		if errors.Is(err, models.ErrParkingSpotNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, ToMaintenanceResponse(record))
+}
+
+// ListMyTickets 查询我上报的维修工单
+// @Summary 查询我上报的维修工单
+// @Tags maintenance
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} MaintenanceResponse
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /maintenance/mine [get]
+func (c *MaintenanceController) ListMyTickets(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uint)
+	records, err := c.service.ListMyTickets(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, toMaintenanceList(records))
+}
+
+// ListTickets 查询维修工单
+// @Summary 查询维修工单
+// @Tags admin
+// @Produce json
+// @Param status query string false "工单状态：pending / triaged / assigned / resolved"
+// @Param spot_id query int false "车位ID"
+// @Security BearerAuth
+// @Success 200 {array} MaintenanceResponse
+// @Failure 400 {object} ErrorResponse "无效的车位 ID"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /admin/maintenance [get]
+func (c *MaintenanceController) ListTickets(ctx *gin.Context) {
+	filter := repositories.MaintenanceFilter{Status: ctx.Query("status")}
+	if v := ctx.Query("spot_id"); v != "" {
+		spotID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的车位 ID"})
+			return
+		}
+		filter.SpotID = uint(spotID)
+	}
+
+	records, err := c.service.ListTickets(ctx, filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, toMaintenanceList(records))
+}
+
+// GetTicket 查询维修工单详情
+// @Summary 查询维修工单详情
+// @Description 包含工单的全部状态变更历史
+// @Tags admin
+// @Produce json
+// @Param id path int true "工单ID"
+// @Security BearerAuth
+// @Success 200 {object} MaintenanceResponse
+// @Failure 400 {object} ErrorResponse "无效的工单 ID"
+// @Failure 404 {object} ErrorResponse "工单不存在"
+// @Router /admin/maintenance/{id} [get]
+func (c *MaintenanceController) GetTicket(ctx *gin.Context) {
+	id, ok := parseMaintenanceID(ctx)
+	if !ok {
+		return
+	}
+
+	record, err := c.service.GetTicket(ctx, id)
+	if err != nil {
+		respondMaintenanceError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ToMaintenanceResponse(record))
+}
+
+// TriageTicket 分诊维修工单
+// @Summary 分诊维修工单
+// @Description 确认待处理工单并设置优先级
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "工单ID"
+// @Param input body TriageRequest true "分诊信息"
+// @Security BearerAuth
+// @Success 200 {object} MaintenanceResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误或状态不允许"
+// @Failure 404 {object} ErrorResponse "工单不存在"
+// @Router /admin/maintenance/{id}/triage [post]
+func (c *MaintenanceController) TriageTicket(ctx *gin.Context) {
+	id, ok := parseMaintenanceID(ctx)
+	if !ok {
+		return
+	}
+	var req TriageRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	adminID := ctx.MustGet("userID").(uint)
+	record, err := c.service.Triage(ctx, adminID, id, req.Priority, req.Note)
+	if err != nil {
+		respondMaintenanceError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ToMaintenanceResponse(record))
+}
+
+// AssignTicket 指派维修工单
+// @Summary 指派维修工单
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "工单ID"
+// @Param input body AssignRequest true "指派信息"
+// @Security BearerAuth
+// @Success 200 {object} MaintenanceResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误或状态不允许"
+// @Failure 404 {object} ErrorResponse "工单或维修人员不存在"
+// @Router /admin/maintenance/{id}/assign [post]
+func (c *MaintenanceController) AssignTicket(ctx *gin.Context) {
+	id, ok := parseMaintenanceID(ctx)
+	if !ok {
+		return
+	}
+	var req AssignRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	adminID := ctx.MustGet("userID").(uint)
+	record, err := c.service.Assign(ctx, adminID, id, req.AssigneeID, req.Note)
+	if err != nil {
+		respondMaintenanceError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ToMaintenanceResponse(record))
+}
+
+// ResolveTicket 关闭维修工单
+// @Summary 关闭维修工单
+// @Description 车位上所有工单关闭后，车位恢复为空闲
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "工单ID"
+// @Param input body ResolveRequest true "处理结果"
+// @Security BearerAuth
+// @Success 200 {object} MaintenanceResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误或状态不允许"
+// @Failure 404 {object} ErrorResponse "工单不存在"
+// @Router /admin/maintenance/{id}/resolve [post]
+func (c *MaintenanceController) ResolveTicket(ctx *gin.Context) {
+	id, ok := parseMaintenanceID(ctx)
+	if !ok {
+		return
+	}
+	var req ResolveRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	adminID := ctx.MustGet("userID").(uint)
+	record, err := c.service.Resolve(ctx, adminID, id, req.Resolution)
+	if err != nil {
+		respondMaintenanceError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ToMaintenanceResponse(record))
+}
+
+func parseMaintenanceID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的工单 ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondMaintenanceError(ctx *gin.Context, err error) {
+	if errors.Is(err, models.ErrMaintenanceNotFound) || errors.Is(err, models.ErrUserNotFound) {
+		ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+	} else {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+}
+
+func toMaintenanceList(records []*models.MaintenanceRecord) []*MaintenanceResponse {
+	response := make([]*MaintenanceResponse, 0, len(records))
+	for _, r := range records {
+		response = append(response, ToMaintenanceResponse(r))
+	}
+	return response
+}
+
+// ToMaintenanceResponse 将维修工单转为响应结构
+func ToMaintenanceResponse(r *models.MaintenanceRecord) *MaintenanceResponse {
+	resp := &MaintenanceResponse{
+		ID:          r.ID,
+		SpotID:      r.SpotID,
+		Description: r.Description,
+		ReportedBy:  r.ReportedBy,
+		Status:      r.Status,
+		Priority:    r.Priority,
+		AssigneeID:  r.AssigneeID,
+		Resolution:  r.Resolution,
+		CreatedAt:   r.CreatedAt.Format(time.RFC3339),
+	}
+	if r.ResolvedAt != nil {
+		resp.ResolvedAt = r.ResolvedAt.Format(time.RFC3339)
+	}
+	for _, h := range r.History {
+		resp.History = append(resp.History, &MaintenanceHistoryResponse{
+			FromStatus: h.FromStatus,
+			ToStatus:   h.ToStatus,
+			ActorID:    h.ActorID,
+			Note:       h.Note,
+			CreatedAt:  h.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return resp
+}
@@ -0,0 +1,560 @@
+// internal/controllers/marketplace_controller.go
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"modules/internal/models"
+	"modules/internal/repositories"
+	"modules/internal/services"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type MarketplaceController struct {
+	service *services.MarketplaceService
+}
+
+func NewMarketplaceController(service *services.MarketplaceService) *MarketplaceController {
+	return &MarketplaceController{service: service}
+}
+
+// ListingWindowRequest 可租时段
+type ListingWindowRequest struct {
+	StartTime time.Time `json:"start_time" binding:"required"`
+	EndTime   time.Time `json:"end_time" binding:"required"`
+}
+
+// CreateListingRequest 发布出租信息请求
+type CreateListingRequest struct {
+	// 车位ID
+	SpotID uint `json:"spot_id" binding:"required"`
+	// 标题，不填时自动生成
+	Title string `json:"title"`
+	// 计价单位：monthly 按月，hourly 按小时
+	PriceUnit models.PriceUnit `json:"price_unit" binding:"required,oneof=monthly hourly"`
+	// 单价
+	Price float64 `json:"price" binding:"required,gt=0"`
+	// 出租条款
+	Terms string `json:"terms"`
+	// 可租时段
+	Windows []ListingWindowRequest `json:"windows" binding:"required,min=1,dive"`
+}
+
+// BookingRequestBody 预订申请请求
+type BookingRequestBody struct {
+	// 租用开始时间
+	StartTime time.Time `json:"start_time" binding:"required"`
+	// 租用结束时间
+	EndTime time.Time `json:"end_time" binding:"required"`
+	// 给业主的留言
+	Message string `json:"message"`
+}
+
+// BookingDecisionRequest 业主处理预订申请请求
+type BookingDecisionRequest struct {
+	// 处理备注
+	Note string `json:"note"`
+}
+
+// ListingWindowResponse 可租时段响应
+type ListingWindowResponse struct {
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+}
+
+// ListingResponse 出租信息响应
+type ListingResponse struct {
+	ID        uint                     `json:"id"`
+	SpotID    uint                     `json:"spot_id"`
+	OwnerID   uint                     `json:"owner_id"`
+	SpotType  string                   `json:"spot_type"`
+	Title     string                   `json:"title"`
+	PriceUnit string                   `json:"price_unit"`
+	Price     float64                  `json:"price"`
+	Terms     string                   `json:"terms"`
+	Status    string                   `json:"status"`
+	Windows   []*ListingWindowResponse `json:"windows"`
+	CreatedAt string                   `json:"created_at"`
+}
+
+// BookingResponse 预订申请响应
+type BookingResponse struct {
+	ID           uint    `json:"id"`
+	ListingID    uint    `json:"listing_id"`
+	SpotID       uint    `json:"spot_id"`
+	RenterID     uint    `json:"renter_id"`
+	OwnerID      uint    `json:"owner_id"`
+	StartTime    string  `json:"start_time"`
+	EndTime      string  `json:"end_time"`
+	Amount       float64 `json:"amount"`
+	Message      string  `json:"message"`
+	Status       string  `json:"status"`
+	DecisionNote string  `json:"decision_note,omitempty"`
+	LeaseID      *uint   `json:"lease_id,omitempty"`
+	CreatedAt    string  `json:"created_at"`
+}
+
+// AcceptBookingResponse 接受预订申请响应
+type AcceptBookingResponse struct {
+	Booking *BookingResponse `json:"booking"`
+	Lease   *LeaseResponse   `json:"lease"`
+}
+
+// CreateListing 发布出租信息
+// @Summary 发布出租信息
+// @Description 业主发布自己车位的出租信息，包括可租时段、按月或按小时的价格及出租条款
+// @Tags owner
+// @Accept json
+// @Produce json
+// @Example {"spot_id": 1, "price_unit": "monthly", "price": 300, "terms": "仅限小型车", "windows": [{"start_time": "2025-07-01T00:00:00+08:00", "end_time": "2025-12-31T23:59:59+08:00"}]}
+// @Param input body CreateListingRequest true "出租信息"
+// @Security BearerAuth
+// @Success 201 {object} ListingResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "车位不存在"
+// @Router /owner/listings [post]
+func (c *MarketplaceController) CreateListing(ctx *gin.Context) {
+	var req CreateListingRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	windows := make([]models.ListingWindow, 0, len(req.Windows))
+	for _, w := range req.Windows {
+		windows = append(windows, models.ListingWindow{StartTime: w.StartTime, EndTime: w.EndTime})
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	listing, err := c.service.CreateListing(ctx, userID, req.SpotID, req.Title, req.PriceUnit, req.Price, req.Terms, windows)
+	if err != nil {
+		if errors.Is(err, models.ErrParkingSpotNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, ToListingResponse(listing))
+}
+
+// ListOwnerListings 查询我发布的出租信息
+// @Summary 查询我发布的出租信息
+// @Tags owner
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} ListingResponse
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /owner/listings [get]
+func (c *MarketplaceController) ListOwnerListings(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uint)
+	listings, err := c.service.ListOwnerListings(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := make([]*ListingResponse, 0, len(listings))
+	for _, l := range listings {
+		response = append(response, ToListingResponse(l))
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// CloseListing 下架出租信息
+// @Summary 下架出租信息
+// @Description 下架后不再接受新的预订申请，未处理的申请将被自动拒绝
+// @Tags owner
+// @Produce json
+// @Param id path int true "出租信息ID"
+// @Security BearerAuth
+// @Success 200 {object} ListingResponse
+// @Failure 400 {object} ErrorResponse "无效的出租信息 ID"
+// @Failure 404 {object} ErrorResponse "出租信息不存在"
+// @Router /owner/listings/{id}/close [post]
+func (c *MarketplaceController) CloseListing(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的出租信息 ID"})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	listing, err := c.service.CloseListing(ctx, userID, uint(id))
+	if err != nil {
+		if errors.Is(err, models.ErrListingNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ToListingResponse(listing))
+}
+
+// SearchListings 搜索出租信息
+// @Summary 搜索出租信息
+// @Description 分页搜索上架中的车位出租信息，可按车位类型、计价单位、价格区间和租用时段过滤
+// @Tags marketplace
+// @Produce json
+// @Param spot_type query string false "车位类型：permanent/short_term/temporary"
+// @Param price_unit query string false "计价单位：monthly/hourly"
+// @Param min_price query number false "最低价格"
+// @Param max_price query number false "最高价格"
+// @Param from query string false "租用开始时间（RFC3339）"
+// @Param to query string false "租用结束时间（RFC3339）"
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页条数，默认20，最大100"
+// @Security BearerAuth
+// @Success 200 {object} PageResponse{items=[]ListingResponse}
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /listings [get]
+func (c *MarketplaceController) SearchListings(ctx *gin.Context) {
+	page, pageSize, err := parsePagination(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	filter := repositories.ListingFilter{
+		SpotType:  models.ParkingType(ctx.Query("spot_type")),
+		PriceUnit: models.PriceUnit(ctx.Query("price_unit")),
+	}
+	if v := ctx.Query("min_price"); v != "" {
+		if filter.MinPrice, err = strconv.ParseFloat(v, 64); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的最低价格"})
+			return
+		}
+	}
+	if v := ctx.Query("max_price"); v != "" {
+		if filter.MaxPrice, err = strconv.ParseFloat(v, 64); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的最高价格"})
+			return
+		}
+	}
+	if v := ctx.Query("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的开始时间"})
+			return
+		}
+		filter.From = &from
+	}
+	if v := ctx.Query("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的结束时间"})
+			return
+		}
+		filter.To = &to
+	}
+
+	listings, total, err := c.service.SearchListings(ctx, filter, page, pageSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	items := make([]*ListingResponse, 0, len(listings))
+	for _, l := range listings {
+		items = append(items, ToListingResponse(l))
+	}
+	ctx.JSON(http.StatusOK, &PageResponse{Items: items, Total: total, Page: page, PageSize: pageSize})
+}
+
+// GetListing 查询出租信息详情
+// @Summary 查询出租信息详情
+// @Tags marketplace
+// @Produce json
+// @Param id path int true "出租信息ID"
+// @Security BearerAuth
+// @Success 200 {object} ListingResponse
+// @Failure 400 {object} ErrorResponse "无效的出租信息 ID"
+// @Failure 404 {object} ErrorResponse "出租信息不存在"
+// @Router /listings/{id} [get]
+func (c *MarketplaceController) GetListing(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的出租信息 ID"})
+		return
+	}
+
+	listing, err := c.service.GetListing(ctx, uint(id))
+	if err != nil {
+		if errors.Is(err, models.ErrListingNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ToListingResponse(listing))
+}
+
+// RequestBooking 申请预订
+// @Summary 申请预订
+// @Description 租客在出租信息的某个可租时段内申请租用，按计价单位计算租金，等待业主处理
+// @Tags marketplace
+// @Accept json
+// @Produce json
+// @Example {"start_time": "2025-07-01T00:00:00+08:00", "end_time": "2025-10-01T00:00:00+08:00", "message": "长期租用"}
+// @Param id path int true "出租信息ID"
+// @Param input body BookingRequestBody true "预订信息"
+// @Security BearerAuth
+// @Success 201 {object} BookingResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "出租信息不存在"
+// @Failure 409 {object} ErrorResponse "出租信息已下架"
+// @Router /listings/{id}/bookings [post]
+func (c *MarketplaceController) RequestBooking(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的出租信息 ID"})
+		return
+	}
+
+	var req BookingRequestBody
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	booking, err := c.service.RequestBooking(ctx, userID, uint(id), req.StartTime, req.EndTime, req.Message)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrListingNotFound):
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		case errors.Is(err, models.ErrListingClosed):
+			ctx.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		default:
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, ToBookingResponse(booking))
+}
+
+// ListMyBookings 查询我的预订申请
+// @Summary 查询我的预订申请
+// @Tags marketplace
+// @Produce json
+// @Param status query string false "申请状态：pending/accepted/declined/cancelled"
+// @Security BearerAuth
+// @Success 200 {array} BookingResponse
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /bookings [get]
+func (c *MarketplaceController) ListMyBookings(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uint)
+	bookings, err := c.service.ListRenterBookings(ctx, userID, models.BookingStatus(ctx.Query("status")))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, toBookingResponses(bookings))
+}
+
+// CancelBooking 撤回预订申请
+// @Summary 撤回预订申请
+// @Tags marketplace
+// @Produce json
+// @Param id path int true "预订申请ID"
+// @Security BearerAuth
+// @Success 200 {object} BookingResponse
+// @Failure 400 {object} ErrorResponse "申请状态不允许撤回"
+// @Failure 404 {object} ErrorResponse "预订申请不存在"
+// @Failure 409 {object} ErrorResponse "申请已被业主处理"
+// @Router /bookings/{id}/cancel [post]
+func (c *MarketplaceController) CancelBooking(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的预订申请 ID"})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	booking, err := c.service.CancelBooking(ctx, userID, uint(id))
+	if err != nil {
+		if errors.Is(err, models.ErrBookingNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else if errors.Is(err, models.ErrBookingNotPending) {
+			ctx.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ToBookingResponse(booking))
+}
+
+// ListOwnerBookings 查询收到的预订申请
+// @Summary 查询收到的预订申请
+// @Tags owner
+// @Produce json
+// @Param status query string false "申请状态：pending/accepted/declined/cancelled"
+// @Security BearerAuth
+// @Success 200 {array} BookingResponse
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /owner/bookings [get]
+func (c *MarketplaceController) ListOwnerBookings(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uint)
+	bookings, err := c.service.ListOwnerBookings(ctx, userID, models.BookingStatus(ctx.Query("status")))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, toBookingResponses(bookings))
+}
+
+// AcceptBooking 接受预订申请
+// @Summary 接受预订申请
+// @Description 业主接受后为租客生成待支付的租赁订单，同一时段的其他申请将被自动拒绝
+// @Tags owner
+// @Accept json
+// @Produce json
+// @Param id path int true "预订申请ID"
+// @Param input body BookingDecisionRequest false "处理备注"
+// @Security BearerAuth
+// @Success 200 {object} AcceptBookingResponse
+// @Failure 400 {object} ErrorResponse "申请状态不允许处理"
+// @Failure 404 {object} ErrorResponse "预订申请不存在"
+// @Failure 409 {object} ErrorResponse "所选时段车位已被租用，或申请已被处理或撤回"
+// @Router /owner/bookings/{id}/accept [post]
+func (c *MarketplaceController) AcceptBooking(ctx *gin.Context) {
+	id, note, ok := bindBookingDecision(ctx)
+	if !ok {
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	booking, lease, err := c.service.AcceptBooking(ctx, userID, id, note)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrBookingNotFound):
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		case errors.Is(err, models.ErrLeaseConflict), errors.Is(err, models.ErrListingClosed),
+			errors.Is(err, models.ErrBookingNotPending):
+			ctx.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		default:
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, &AcceptBookingResponse{
+		Booking: ToBookingResponse(booking),
+		Lease:   ToLeaseResponse(lease),
+	})
+}
+
+// DeclineBooking 拒绝预订申请
+// @Summary 拒绝预订申请
+// @Tags owner
+// @Accept json
+// @Produce json
+// @Param id path int true "预订申请ID"
+// @Param input body BookingDecisionRequest false "拒绝原因"
+// @Security BearerAuth
+// @Success 200 {object} BookingResponse
+// @Failure 400 {object} ErrorResponse "申请状态不允许处理"
+// @Failure 404 {object} ErrorResponse "预订申请不存在"
+// @Failure 409 {object} ErrorResponse "申请已被处理或撤回"
+// @Router /owner/bookings/{id}/decline [post]
+func (c *MarketplaceController) DeclineBooking(ctx *gin.Context) {
+	id, note, ok := bindBookingDecision(ctx)
+	if !ok {
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	booking, err := c.service.DeclineBooking(ctx, userID, id, note)
+	if err != nil {
+		if errors.Is(err, models.ErrBookingNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else if errors.Is(err, models.ErrBookingNotPending) {
+			ctx.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ToBookingResponse(booking))
+}
+
+// bindBookingDecision 解析预订申请ID和可选的处理备注，失败时已写入响应
+func bindBookingDecision(ctx *gin.Context) (uint, string, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的预订申请 ID"})
+		return 0, "", false
+	}
+
+	var req BookingDecisionRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return 0, "", false
+		}
+	}
+	return uint(id), req.Note, true
+}
+
+// ToListingResponse 将出租信息转为响应结构
+func ToListingResponse(l *models.SpotListing) *ListingResponse {
+	windows := make([]*ListingWindowResponse, 0, len(l.Windows))
+	for _, w := range l.Windows {
+		windows = append(windows, &ListingWindowResponse{
+			StartTime: w.StartTime.Format(time.RFC3339),
+			EndTime:   w.EndTime.Format(time.RFC3339),
+		})
+	}
+	return &ListingResponse{
+		ID:        l.ID,
+		SpotID:    l.SpotID,
+		OwnerID:   l.OwnerID,
+		SpotType:  string(l.SpotType),
+		Title:     l.Title,
+		PriceUnit: string(l.PriceUnit),
+		Price:     l.Price,
+		Terms:     l.Terms,
+		Status:    string(l.Status),
+		Windows:   windows,
+		CreatedAt: l.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// ToBookingResponse 将预订申请转为响应结构
+func ToBookingResponse(b *models.BookingRequest) *BookingResponse {
+	return &BookingResponse{
+		ID:           b.ID,
+		ListingID:    b.ListingID,
+		SpotID:       b.SpotID,
+		RenterID:     b.RenterID,
+		OwnerID:      b.OwnerID,
+		StartTime:    b.StartTime.Format(time.RFC3339),
+		EndTime:      b.EndTime.Format(time.RFC3339),
+		Amount:       b.Amount,
+		Message:      b.Message,
+		Status:       string(b.Status),
+		DecisionNote: b.DecisionNote,
+		LeaseID:      b.LeaseID,
+		CreatedAt:    b.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func toBookingResponses(bookings []*models.BookingRequest) []*BookingResponse {
+	response := make([]*BookingResponse, 0, len(bookings))
+	for _, b := range bookings {
+		response = append(response, ToBookingResponse(b))
+	}
+	return response
+}
@@ -0,0 +1,176 @@
+// internal/controllers/notification_controller.go
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"modules/internal/models"
+	"modules/internal/services"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type NotificationController struct {
+	service *services.NotificationService
+}
+
+func NewNotificationController(service *services.NotificationService) *NotificationController {
+	return &NotificationController{service: service}
+}
+
+// NotificationResponse 站内信响应
+type NotificationResponse struct {
+	ID        uint   `json:"id"`
+	Event     string `json:"event"`
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+	Read      bool   `json:"read"`
+	ReadAt    string `json:"read_at,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// NotificationPreferencesRequest 通知偏好请求，键为事件类型，值为投递渠道
+type NotificationPreferencesRequest struct {
+	// 事件类型：lease_created / lease_expiring / exit_receipt / admin_alert
+	// 投递渠道：email 邮件，sms 短信，inbox 仅站内信
+	Preferences map[models.NotificationEvent]models.NotificationChannel `json:"preferences" binding:"required"`
+}
+
+// NotificationPreferencesResponse 通知偏好响应
+type NotificationPreferencesResponse struct {
+	Preferences map[models.NotificationEvent]models.NotificationChannel `json:"preferences"`
+}
+
+// ListNotifications 查询站内信
+// @Summary 查询站内信
+// @Tags notifications
+// @Produce json
+// @Param unread query bool false "仅查询未读"
+// @Param page query int false "页码，从 1 开始"
+// @Param page_size query int false "每页条数，默认 20，最大 100"
+// @Security BearerAuth
+// @Success 200 {object} PageResponse{items=[]NotificationResponse}
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /notifications [get]
+func (c *NotificationController) ListNotifications(ctx *gin.Context) {
+	page, pageSize, err := parsePagination(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	unreadOnly := false
+	if v := ctx.Query("unread"); v != "" {
+		if unreadOnly, err = strconv.ParseBool(v); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的 unread 参数"})
+			return
+		}
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	items, total, err := c.service.ListNotifications(ctx, userID, unreadOnly, page, pageSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := make([]*NotificationResponse, 0, len(items))
+	for _, n := range items {
+		response = append(response, ToNotificationResponse(n))
+	}
+	ctx.JSON(http.StatusOK, PageResponse{Items: response, Total: total, Page: page, PageSize: pageSize})
+}
+
+// MarkRead 标记站内信已读
+// @Summary 标记站内信已读
+// @Tags notifications
+// @Produce json
+// @Param id path int true "站内信ID"
+// @Security BearerAuth
+// @Success 200 {object} NotificationResponse
+// @Failure 400 {object} ErrorResponse "无效的通知 ID"
+// @Failure 404 {object} ErrorResponse "通知不存在"
+// @Router /notifications/{id}/read [post]
+func (c *NotificationController) MarkRead(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的通知 ID"})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	n, err := c.service.MarkRead(ctx, userID, uint(id))
+	if err != nil {
+		if errors.Is(err, models.ErrNotificationNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, ToNotificationResponse(n))
+}
+
+// GetPreferences 查询通知偏好
+// @Summary 查询通知偏好
+// @Description 返回每类事件的投递渠道，所有通知都会同时保存为站内信
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} NotificationPreferencesResponse
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /notifications/preferences [get]
+func (c *NotificationController) GetPreferences(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uint)
+	prefs, err := c.service.GetPreferences(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, NotificationPreferencesResponse{Preferences: prefs})
+}
+
+// UpdatePreferences 更新通知偏好
+// @Summary 更新通知偏好
+// @Description 为指定事件选择投递渠道：email 邮件、sms 短信或 inbox 仅站内信，未提及的事件保持不变
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Example {"preferences": {"lease_expiring": "sms", "exit_receipt": "inbox"}}
+// @Param input body NotificationPreferencesRequest true "通知偏好"
+// @Security BearerAuth
+// @Success 200 {object} NotificationPreferencesResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Router /notifications/preferences [put]
+func (c *NotificationController) UpdatePreferences(ctx *gin.Context) {
+	var req NotificationPreferencesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	prefs, err := c.service.UpdatePreferences(ctx, userID, req.Preferences)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, NotificationPreferencesResponse{Preferences: prefs})
+}
+
+// ToNotificationResponse 将站内信转为响应结构
+func ToNotificationResponse(n *models.Notification) *NotificationResponse {
+	resp := &NotificationResponse{
+		ID:        n.ID,
+		Event:     string(n.Event),
+		Title:     n.Title,
+		Body:      n.Body,
+		Read:      n.ReadAt != nil,
+		CreatedAt: n.CreatedAt.Format(time.RFC3339),
+	}
+	if n.ReadAt != nil {
+		resp.ReadAt = n.ReadAt.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -0,0 +1,167 @@
+// internal/controllers/outbox_controller.go
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"modules/internal/models"
+	"modules/internal/services"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type OutboxController struct {
+	service *services.OutboxService
+}
+
+func NewOutboxController(service *services.OutboxService) *OutboxController {
+	return &OutboxController{service: service}
+}
+
+// OutboxEventResponse 发件箱事件响应
+type OutboxEventResponse struct {
+	ID            uint     `json:"id"`
+	EventID       string   `json:"event_id"`
+	Event         string   `json:"event"`
+	AggregateType string   `json:"aggregate_type"`
+	AggregateID   uint     `json:"aggregate_id"`
+	Status        string   `json:"status"`
+	Attempts      int      `json:"attempts"`
+	NextAttemptAt string   `json:"next_attempt_at,omitempty"`
+	LastError     string   `json:"last_error,omitempty"`
+	DeliveredAt   string   `json:"delivered_at,omitempty"`
+	Payload       string   `json:"payload,omitempty"`
+	Handled       []string `json:"handled,omitempty"` // 已成功处理该事件的处理器
+	CreatedAt     string   `json:"created_at"`
+}
+
+// ListEvents 查询发件箱事件
+// @Summary 查询发件箱事件
+// @Description 按状态、事件类型分页查询，status=failed 可查看重试次数用尽的事件
+// @Tags admin
+// @Produce json
+// @Param status query string false "事件状态：pending / delivered / failed"
+// @Param event query string false "事件类型，例如 parking.exit"
+// @Param page query int false "页码，从 1 开始"
+// @Param page_size query int false "每页条数，默认 20，最大 100"
+// @Security BearerAuth
+// @Success 200 {object} PageResponse{items=[]OutboxEventResponse}
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /admin/outbox [get]
+func (c *OutboxController) ListEvents(ctx *gin.Context) {
+	page, pageSize, err := parsePagination(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	status := models.OutboxStatus(ctx.Query("status"))
+	switch status {
+	case "", models.OutboxPending, models.OutboxDelivered, models.OutboxFailed:
+	default:
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的事件状态"})
+		return
+	}
+
+	events, total, err := c.service.ListEvents(ctx, status, models.WebhookEvent(ctx.Query("event")), page, pageSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	items := make([]*OutboxEventResponse, 0, len(events))
+	for _, e := range events {
+		items = append(items, ToOutboxEventResponse(e, false))
+	}
+	ctx.JSON(http.StatusOK, PageResponse{Items: items, Total: total, Page: page, PageSize: pageSize})
+}
+
+// GetEvent 查询发件箱事件详情
+// @Summary 查询发件箱事件详情
+// @Description 包含事件内容和已成功处理的处理器
+// @Tags admin
+// @Produce json
+// @Param id path int true "事件ID"
+// @Security BearerAuth
+// @Success 200 {object} OutboxEventResponse
+// @Failure 400 {object} ErrorResponse "无效的事件 ID"
+// @Failure 404 {object} ErrorResponse "事件不存在"
+// @Router /admin/outbox/{id} [get]
+func (c *OutboxController) GetEvent(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的事件 ID"})
+		return
+	}
+
+	event, err := c.service.GetEvent(ctx, uint(id))
+	if err != nil {
+		if errors.Is(err, models.ErrOutboxEventNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, ToOutboxEventResponse(event, true))
+}
+
+// RetryEvent 重新分发事件
+// @Summary 重新分发发件箱事件
+// @Description 立即重新分发一条未完成的事件，只执行尚未成功的处理器，返回分发后的事件详情
+// @Tags admin
+// @Produce json
+// @Param id path int true "事件ID"
+// @Security BearerAuth
+// @Success 200 {object} OutboxEventResponse
+// @Failure 400 {object} ErrorResponse "事件已分发完成"
+// @Failure 404 {object} ErrorResponse "事件不存在"
+// @Router /admin/outbox/{id}/retry [post]
+func (c *OutboxController) RetryEvent(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的事件 ID"})
+		return
+	}
+
+	event, err := c.service.Retry(ctx, uint(id))
+	if err != nil {
+		if errors.Is(err, models.ErrOutboxEventNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, ToOutboxEventResponse(event, true))
+}
+
+// ToOutboxEventResponse 将发件箱事件转为响应结构，detail 为 true 时包含事件内容和处理进度
+func ToOutboxEventResponse(e *models.OutboxEvent, detail bool) *OutboxEventResponse {
+	resp := &OutboxEventResponse{
+		ID:            e.ID,
+		EventID:       e.EventID,
+		Event:         string(e.Event),
+		AggregateType: e.AggregateType,
+		AggregateID:   e.AggregateID,
+		Status:        string(e.Status),
+		Attempts:      e.Attempts,
+		LastError:     e.LastError,
+		CreatedAt:     e.CreatedAt.Format(time.RFC3339),
+	}
+	if e.Status == models.OutboxPending {
+		resp.NextAttemptAt = e.NextAttemptAt.Format(time.RFC3339)
+	}
+	if e.DeliveredAt != nil {
+		resp.DeliveredAt = e.DeliveredAt.Format(time.RFC3339)
+	}
+	if detail {
+		resp.Payload = e.Payload
+		resp.Handled = make([]string, 0, len(e.Deliveries))
+		for _, d := range e.Deliveries {
+			resp.Handled = append(resp.Handled, d.Handler)
+		}
+	}
+	return resp
+}
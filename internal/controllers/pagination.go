@@ -0,0 +1,34 @@
+// internal/controllers/pagination.go
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// PageResponse 分页响应结构
+type PageResponse struct {
+	Items    interface{} `json:"items"`     // 当前页数据
+	Total    int64       `json:"total"`     // 总条数
+	Page     int         `json:"page"`      // 当前页码
+	PageSize int         `json:"page_size"` // 每页条数
+}
+
+// parsePagination 解析 page、page_size 查询参数，page 从 1 开始
+func parsePagination(ctx *gin.Context) (page, pageSize int, err error) {
+	page, err = strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		return 0, 0, errors.New("无效的页码")
+	}
+	pageSize, err = strconv.Atoi(ctx.DefaultQuery("page_size", strconv.Itoa(defaultPageSize)))
+	if err != nil || pageSize < 1 || pageSize > maxPageSize {
+		return 0, 0, errors.New("每页条数须在 1 到 100 之间")
+	}
+	return page, pageSize, nil
+}
@@ -2,6 +2,7 @@
 package controllers
 
 import (
+	"errors"
 	"modules/internal/models"
 	"modules/internal/services"
 	"net/http"
@@ -52,7 +53,7 @@ func (c *ParkingController) ListSpots(ctx *gin.Context) {
 }
 
 // @Summary 车辆入场登记
-// @Description 车辆入场时登记车牌号，开始计费
+// @Description 出入口值守登记入场车牌号，开始计费。停车记录归属预约人或登记该车牌的用户，需要 gates.operate 权限
 // @Tags parking
 // @Accept json
 // @Produce json
@@ -61,6 +62,7 @@ func (c *ParkingController) ListSpots(ctx *gin.Context) {
 // @Success 200 {object} RecordResponse "入场记录"
 // @Failure 400 {object} ErrorResponse "请求参数错误"
 // @Failure 401 {object} ErrorResponse "未授权访问"
+// @Failure 403 {object} ErrorResponse "权限不足"
 // @Failure 500 {object} ErrorResponse "服务器内部错误"
 // @Router /parking/entry [post]
 func (c *ParkingController) Entry(ctx *gin.Context) {
@@ -70,14 +72,7 @@ func (c *ParkingController) Entry(ctx *gin.Context) {
 		return
 	}
 
-	// 获取当前用户ID
-	userID, _ := ctx.Get("userID")
-	var uid *uint
-	if v, ok := userID.(uint); ok {
-		uid = &v
-	}
-
-	record, err := c.service.ProcessEntry(ctx, req.License, uid)
+	record, err := c.service.ProcessEntry(ctx, req.License)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -87,7 +82,7 @@ func (c *ParkingController) Entry(ctx *gin.Context) {
 }
 
 // @Summary 车辆出场结算
-// @Description 车辆出场时结算停车费用，返回停车记录和费用信息
+// @Description 出入口值守为出场车辆结算停车费用，返回停车记录和费用信息，需要 gates.operate 权限
 // @Tags parking
 // @Accept json
 // @Produce json
@@ -97,6 +92,7 @@ func (c *ParkingController) Entry(ctx *gin.Context) {
 // @Success 200 {object} RecordResponse "出场结算记录"
 // @Failure 400 {object} ErrorResponse "无效的ID参数"
 // @Failure 401 {object} ErrorResponse "未授权访问"
+// @Failure 403 {object} ErrorResponse "权限不足"
 // @Failure 500 {object} ErrorResponse "服务器内部错误"
 // @Router /parking/exit/{id} [post]
 func (c *ParkingController) Exit(ctx *gin.Context) {
@@ -115,6 +111,89 @@ func (c *ParkingController) Exit(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, ToRecordResponse(record))
 }
 
+// @Summary 出场缴费
+// @Description 支付本人已出场停车记录的停车费，上次支付失败时会重新发起支付
+// @Tags parking
+// @Produce json
+// @Param id path int true "停车记录ID"
+// @Security BearerAuth
+// @Success 200 {object} PaymentResponse "支付结果"
+// @Failure 400 {object} ErrorResponse "无效的ID参数"
+// @Failure 402 {object} PaymentResponse "支付失败"
+// @Failure 404 {object} ErrorResponse "停车记录不存在"
+// @Failure 409 {object} ErrorResponse "停车记录尚未出场结算或支付正在处理中"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /parking/exit/{id}/pay [post]
+func (c *ParkingController) PayExit(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	payment, err := c.service.PayExit(ctx, userID, uint(id))
+	if err != nil {
+		if errors.Is(err, models.ErrParkingNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else if errors.Is(err, models.ErrParkingNotCompleted) || errors.Is(err, models.ErrPaymentProcessing) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if payment.Status == models.PaymentFailed {
+		ctx.JSON(http.StatusPaymentRequired, ToPaymentResponse(payment))
+		return
+	}
+	ctx.JSON(http.StatusOK, ToPaymentResponse(payment))
+}
+
+// @Summary 出入口代收停车费
+// @Description 出入口值守人员为非注册用户的已出场停车记录收取停车费，注册用户的记录需由本人缴费
+// @Tags parking
+// @Produce json
+// @Param id path int true "停车记录ID"
+// @Security BearerAuth
+// @Success 200 {object} PaymentResponse "支付结果"
+// @Failure 400 {object} ErrorResponse "无效的ID参数"
+// @Failure 402 {object} PaymentResponse "支付失败"
+// @Failure 403 {object} ErrorResponse "注册用户的停车费需由本人缴纳"
+// @Failure 404 {object} ErrorResponse "停车记录不存在"
+// @Failure 409 {object} ErrorResponse "停车记录尚未出场结算或支付正在处理中"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /parking/exit/{id}/gate-pay [post]
+func (c *ParkingController) PayGateExit(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	operatorID := ctx.MustGet("userID").(uint)
+	payment, err := c.service.PayGateExit(ctx, operatorID, uint(id))
+	if err != nil {
+		if errors.Is(err, models.ErrParkingNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else if errors.Is(err, models.ErrParkingHasUser) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else if errors.Is(err, models.ErrParkingNotCompleted) || errors.Is(err, models.ErrPaymentProcessing) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if payment.Status == models.PaymentFailed {
+		ctx.JSON(http.StatusPaymentRequired, ToPaymentResponse(payment))
+		return
+	}
+	ctx.JSON(http.StatusOK, ToPaymentResponse(payment))
+}
+
 // DTOs和转换方法
 type EntryRequest struct {
 	// 车牌号
@@ -0,0 +1,176 @@
+// internal/controllers/payment_controller.go
+package controllers
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"modules/internal/models"
+	"modules/internal/services"
+	"net/http"
+	"strconv"
+)
+
+type PaymentController struct {
+	service *services.PaymentService
+}
+
+func NewPaymentController(service *services.PaymentService) *PaymentController {
+	return &PaymentController{service: service}
+}
+
+// ListPayments 查询我的支付记录
+// @Summary 查询我的支付记录
+// @Tags payment
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} PaymentResponse
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /payments [get]
+func (c *PaymentController) ListPayments(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uint)
+	payments, err := c.service.ListUserPayments(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := make([]*PaymentResponse, 0, len(payments))
+	for _, p := range payments {
+		response = append(response, ToPaymentResponse(p))
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetPayment 查询支付单
+// @Summary 查询支付单
+// @Tags payment
+// @Produce json
+// @Param id path int true "支付单ID"
+// @Security BearerAuth
+// @Success 200 {object} PaymentResponse
+// @Failure 400 {object} ErrorResponse "无效的支付单 ID"
+// @Failure 404 {object} ErrorResponse "支付单不存在"
+// @Router /payments/{id} [get]
+func (c *PaymentController) GetPayment(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的支付单 ID"})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	payment, err := c.service.GetPayment(ctx, uint(id))
+	if err == nil && payment.UserID != userID {
+		err = models.ErrPaymentNotFound
+	}
+	if err != nil {
+		if errors.Is(err, models.ErrPaymentNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ToPaymentResponse(payment))
+}
+
+// ReviewRefundRequest 审核退款请求
+type ReviewRefundRequest struct {
+	Note string `json:"note"` // 审核备注
+}
+
+// ListRefunds 查询退款申请
+// @Summary 查询退款申请
+// @Description 管理员按状态查询退款申请
+// @Tags admin
+// @Produce json
+// @Param status query string false "状态：pending/approved/rejected"
+// @Security BearerAuth
+// @Success 200 {array} RefundResponse
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /admin/refunds [get]
+func (c *PaymentController) ListRefunds(ctx *gin.Context) {
+	refunds, err := c.service.ListRefunds(ctx, models.RefundStatus(ctx.Query("status")))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := make([]*RefundResponse, 0, len(refunds))
+	for _, r := range refunds {
+		response = append(response, ToRefundResponse(r))
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// ApproveRefund 审核通过退款
+// @Summary 审核通过退款
+// @Description 管理员审核通过退款申请，并通过支付网关原路退款
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "退款申请ID"
+// @Param input body ReviewRefundRequest false "审核备注"
+// @Security BearerAuth
+// @Success 200 {object} RefundResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "退款申请不存在"
+// @Failure 409 {object} ErrorResponse "退款申请已处理"
+// @Router /admin/refunds/{id}/approve [post]
+func (c *PaymentController) ApproveRefund(ctx *gin.Context) {
+	c.reviewRefund(ctx, c.service.ApproveRefund)
+}
+
+// RejectRefund 驳回退款
+// @Summary 驳回退款
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "退款申请ID"
+// @Param input body ReviewRefundRequest false "审核备注"
+// @Security BearerAuth
+// @Success 200 {object} RefundResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "退款申请不存在"
+// @Failure 409 {object} ErrorResponse "退款申请已处理"
+// @Router /admin/refunds/{id}/reject [post]
+func (c *PaymentController) RejectRefund(ctx *gin.Context) {
+	c.reviewRefund(ctx, c.service.RejectRefund)
+}
+
+func (c *PaymentController) reviewRefund(
+	ctx *gin.Context,
+	review func(ctx context.Context, refundID, adminID uint, note string) (*models.Refund, error),
+) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的退款申请 ID"})
+		return
+	}
+
+	var req ReviewRefundRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	adminID := ctx.MustGet("userID").(uint)
+	refund, err := review(ctx, uint(id), adminID, req.Note)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRefundNotFound):
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		case errors.Is(err, models.ErrRefundNotPending):
+			ctx.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ToRefundResponse(refund))
+}
@@ -2,10 +2,12 @@
 package controllers
 
 import (
+	"errors"
 	"modules/internal/models"
 	"modules/internal/services"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,26 +21,186 @@ func NewReportController(service *services.ReportService) *ReportController {
 }
 
 // @Summary 获取日报表
-// @Description 根据指定天数获取每日停车收入和车位使用统计，默认返回最近7天的数据。
+// @Description 查询已保存的每日停车收入和车位使用快照，按日期升序返回；未指定 from 时返回 to 之前 days 天（默认7天）的数据。
 // @Tags reports
 // @Produce json
-// @Param days query int false "查询天数，默认为7天" default(7)
+// @Param from query string false "开始日期（YYYY-MM-DD）"
+// @Param to query string false "结束日期（YYYY-MM-DD），默认今天"
+// @Param days query int false "未指定开始日期时的查询天数，默认为7天" default(7)
 // @Security BearerAuth
-// @Success 200 {object} DailyReportResponse "日报表数据"
+// @Success 200 {array} DailyReportResponse "日报表数据"
 // @Failure 400 {object} ErrorResponse "无效的查询参数"
 // @Failure 401 {object} ErrorResponse "未授权访问"
+// @Failure 403 {object} ErrorResponse "权限不足"
 // @Failure 500 {object} ErrorResponse "服务器内部错误"
 // @Router /reports/daily [get]
 func (c *ReportController) GetDailyReport(ctx *gin.Context) {
-	days, _ := strconv.Atoi(ctx.DefaultQuery("days", "7"))
+	to := time.Now()
+	if v := ctx.Query("to"); v != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的结束日期"})
+			return
+		}
+		to = parsed
+	}
+
+	var from time.Time
+	if v := ctx.Query("from"); v != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的开始日期"})
+			return
+		}
+		from = parsed
+	} else {
+		days, err := strconv.Atoi(ctx.DefaultQuery("days", "7"))
+		if err != nil || days <= 0 {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的查询天数"})
+			return
+		}
+		from = to.AddDate(0, 0, -days+1)
+	}
 
-	report, err := c.service.GenerateDailyReport(ctx, days)
+	reports, err := c.service.ListDailyReports(ctx, from, to)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, ToDailyReportResponse(report))
+	response := make([]*DailyReportResponse, 0, len(reports))
+	for _, r := range reports {
+		response = append(response, ToDailyReportResponse(r))
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetRevenueReport 收入报表
+// @Summary 收入报表
+// @Description 按日、周（周一起）或月统计停车费和租金收入，可按车位、业主或车位类型分组，每个周期附带与上一周期相比的变化额和变化率。未指定 from 时默认统计最近30天、12周或12个月。仅管理员可访问。
+// @Tags reports
+// @Produce json
+// @Param granularity query string false "统计粒度：day/week/month，默认 day"
+// @Param group_by query string false "分组维度：spot/owner/type，不填时不分组"
+// @Param from query string false "开始日期（YYYY-MM-DD）"
+// @Param to query string false "结束日期（YYYY-MM-DD），默认今天"
+// @Security BearerAuth
+// @Success 200 {object} RevenueReportResponse "收入报表"
+// @Failure 400 {object} ErrorResponse "无效的查询参数，或统计范围超过两年"
+// @Failure 403 {object} ErrorResponse "权限不足"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /reports/revenue [get]
+func (c *ReportController) GetRevenueReport(ctx *gin.Context) {
+	granularity := ctx.DefaultQuery("granularity", services.GranularityDay)
+
+	to := time.Now()
+	if v := ctx.Query("to"); v != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的结束日期"})
+			return
+		}
+		to = parsed
+	}
+
+	var from time.Time
+	if v := ctx.Query("from"); v != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的开始日期"})
+			return
+		}
+		from = parsed
+	} else {
+		switch granularity {
+		case services.GranularityWeek:
+			from = to.AddDate(0, 0, -7*11)
+		case services.GranularityMonth:
+			from = to.AddDate(0, -11, 0)
+		default:
+			from = to.AddDate(0, 0, -29)
+		}
+	}
+
+	report, err := c.service.RevenueReport(ctx, granularity, ctx.Query("group_by"), from, to)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidReportQuery) {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ToRevenueReportResponse(report))
+}
+
+// RevenueGroupResponse 分组收入
+type RevenueGroupResponse struct {
+	Key       string   `json:"key"` // 车位ID、业主ID（0 表示平台自营）或车位类型
+	Parking   float64  `json:"parking_income"`
+	Lease     float64  `json:"lease_income"`
+	Total     float64  `json:"total"`
+	Count     int      `json:"count"`
+	PrevTotal float64  `json:"prev_total"`
+	Delta     float64  `json:"delta"`
+	DeltaRate *float64 `json:"delta_rate"` // 上一周期收入为 0 时为 null
+}
+
+// RevenuePeriodResponse 周期收入
+type RevenuePeriodResponse struct {
+	Start     string                  `json:"start"`
+	End       string                  `json:"end"` // 不含
+	Parking   float64                 `json:"parking_income"`
+	Lease     float64                 `json:"lease_income"`
+	Total     float64                 `json:"total"`
+	Count     int                     `json:"count"`
+	PrevTotal float64                 `json:"prev_total"`
+	Delta     float64                 `json:"delta"`
+	DeltaRate *float64                `json:"delta_rate"`
+	Groups    []*RevenueGroupResponse `json:"groups,omitempty"`
+}
+
+// RevenueReportResponse 收入报表
+type RevenueReportResponse struct {
+	Granularity string                   `json:"granularity"`
+	GroupBy     string                   `json:"group_by,omitempty"`
+	Periods     []*RevenuePeriodResponse `json:"periods"`
+}
+
+func ToRevenueReportResponse(r *services.RevenueReport) *RevenueReportResponse {
+	periods := make([]*RevenuePeriodResponse, 0, len(r.Periods))
+	for _, p := range r.Periods {
+		period := &RevenuePeriodResponse{
+			Start:     p.Start.Format("2006-01-02"),
+			End:       p.End.Format("2006-01-02"),
+			Parking:   p.Parking,
+			Lease:     p.Lease,
+			Total:     p.Total,
+			Count:     p.Count,
+			PrevTotal: p.PrevTotal,
+			Delta:     p.Delta,
+			DeltaRate: p.DeltaRate,
+		}
+		for _, g := range p.Groups {
+			period.Groups = append(period.Groups, &RevenueGroupResponse{
+				Key:       g.Key,
+				Parking:   g.Parking,
+				Lease:     g.Lease,
+				Total:     g.Total,
+				Count:     g.Count,
+				PrevTotal: g.PrevTotal,
+				Delta:     g.Delta,
+				DeltaRate: g.DeltaRate,
+			})
+		}
+		periods = append(periods, period)
+	}
+	return &RevenueReportResponse{
+		Granularity: r.Granularity,
+		GroupBy:     r.GroupBy,
+		Periods:     periods,
+	}
 }
 
 // DTO转换
@@ -0,0 +1,153 @@
+// internal/controllers/reservation_controller.go
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"modules/internal/models"
+	"modules/internal/services"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type ReservationController struct {
+	service *services.ReservationService
+}
+
+func NewReservationController(service *services.ReservationService) *ReservationController {
+	return &ReservationController{service: service}
+}
+
+// CreateReservationRequest 创建预约请求，spot_id 与 spot_type 二选一
+type CreateReservationRequest struct {
+	// 指定车位ID
+	SpotID uint `json:"spot_id"`
+	// 车位类型，未指定车位时按类型自动分配
+	SpotType models.ParkingType `json:"spot_type" binding:"omitempty,oneof=permanent short_term temporary"`
+	// 车牌号
+	License string `json:"license" binding:"required"`
+	// 预约开始时间
+	StartTime time.Time `json:"start_time" binding:"required"`
+	// 预约结束时间
+	EndTime time.Time `json:"end_time" binding:"required"`
+}
+
+// ReservationResponse 预约响应
+type ReservationResponse struct {
+	ID        uint   `json:"id"`
+	SpotID    uint   `json:"spot_id"`
+	SpotType  string `json:"spot_type"`
+	License   string `json:"license"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Status    string `json:"status"`
+	RecordID  *uint  `json:"record_id,omitempty"`
+}
+
+// CreateReservation 预约车位
+// @Summary 预约车位
+// @Description 按时段预约指定车位或某一类型的车位，时段与已有预约重叠时拒绝；超过宽限期未入场的预约自动过期
+// @Tags reservation
+// @Accept json
+// @Produce json
+// @Example {"spot_type": "temporary", "license": "粤B12345", "start_time": "2025-06-01T09:00:00+08:00", "end_time": "2025-06-01T12:00:00+08:00"}
+// @Param input body CreateReservationRequest true "预约信息"
+// @Security BearerAuth
+// @Success 201 {object} ReservationResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "车位不存在"
+// @Failure 409 {object} ErrorResponse "所选时段没有可预约的车位"
+// @Router /reservations [post]
+func (c *ReservationController) CreateReservation(ctx *gin.Context) {
+	var req CreateReservationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	reservation, err := c.service.CreateReservation(ctx, userID, req.License, req.SpotID, req.SpotType, req.StartTime, req.EndTime)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrReservationConflict):
+			ctx.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		case errors.Is(err, models.ErrParkingSpotNotFound):
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		default:
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, ToReservationResponse(reservation))
+}
+
+// ListReservations 查询我的预约
+// @Summary 查询我的预约
+// @Tags reservation
+// @Produce json
+// @Param status query string false "预约状态：booked/fulfilled/cancelled/expired"
+// @Security BearerAuth
+// @Success 200 {array} ReservationResponse
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /reservations [get]
+func (c *ReservationController) ListReservations(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uint)
+	reservations, err := c.service.ListReservations(ctx, userID, models.ReservationStatus(ctx.Query("status")))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := make([]*ReservationResponse, 0, len(reservations))
+	for _, r := range reservations {
+		response = append(response, ToReservationResponse(r))
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// CancelReservation 取消预约
+// @Summary 取消预约
+// @Tags reservation
+// @Produce json
+// @Param id path int true "预约ID"
+// @Security BearerAuth
+// @Success 200 {object} ReservationResponse
+// @Failure 400 {object} ErrorResponse "预约状态不允许取消"
+// @Failure 404 {object} ErrorResponse "预约不存在"
+// @Router /reservations/{id}/cancel [post]
+func (c *ReservationController) CancelReservation(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的预约 ID"})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	reservation, err := c.service.CancelReservation(ctx, userID, uint(id))
+	if err != nil {
+		if errors.Is(err, models.ErrReservationNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ToReservationResponse(reservation))
+}
+
+// ToReservationResponse 将预约模型转为响应结构
+func ToReservationResponse(r *models.Reservation) *ReservationResponse {
+	return &ReservationResponse{
+		ID:        r.ID,
+		SpotID:    r.SpotID,
+		SpotType:  string(r.SpotType),
+		License:   r.License,
+		StartTime: r.StartTime.Format(time.RFC3339),
+		EndTime:   r.EndTime.Format(time.RFC3339),
+		Status:    string(r.Status),
+		RecordID:  r.RecordID,
+	}
+}
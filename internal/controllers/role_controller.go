@@ -0,0 +1,198 @@
+// internal/controllers/role_controller.go
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"modules/internal/models"
+	"modules/internal/services"
+	"net/http"
+	"time"
+)
+
+type RoleController struct {
+	service *services.PermissionService
+}
+
+func NewRoleController(service *services.PermissionService) *RoleController {
+	return &RoleController{service: service}
+}
+
+// PermissionResponse 权限说明
+type PermissionResponse struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// RoleResponse 角色及其权限
+type RoleResponse struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	BuiltIn     bool     `json:"built_in"`
+	Permissions []string `json:"permissions"`
+	CreatedAt   string   `json:"created_at"`
+}
+
+// CreateRoleRequest 创建角色请求
+type CreateRoleRequest struct {
+	// 小写字母开头，可包含小写字母、数字和下划线，2 到 32 位
+	Name        string              `json:"name" binding:"required"`
+	Description string              `json:"description" binding:"max=255"`
+	Permissions []models.Permission `json:"permissions" binding:"required"`
+}
+
+// UpdateRoleRequest 修改角色请求，替换角色现有的全部权限
+type UpdateRoleRequest struct {
+	Description string              `json:"description" binding:"max=255"`
+	Permissions []models.Permission `json:"permissions" binding:"required"`
+}
+
+// ListPermissions 查询全部权限
+// @Summary 查询全部权限
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} PermissionResponse
+// @Router /admin/permissions [get]
+func (c *RoleController) ListPermissions(ctx *gin.Context) {
+	resp := make([]PermissionResponse, 0, len(models.Permissions))
+	for _, p := range models.Permissions {
+		resp = append(resp, PermissionResponse{Name: string(p.Name), Description: p.Description})
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// ListRoles 查询角色
+// @Summary 查询角色
+// @Description 查询全部角色及其权限，管理员角色始终拥有全部权限
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} RoleResponse
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /admin/roles [get]
+func (c *RoleController) ListRoles(ctx *gin.Context) {
+	roles, err := c.service.ListRoles(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	resp := make([]*RoleResponse, 0, len(roles))
+	for _, r := range roles {
+		resp = append(resp, ToRoleResponse(r))
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// CreateRole 创建角色
+// @Summary 创建角色
+// @Description 创建自定义角色，例如只负责出入口和车位状态的 operator，之后可通过 /admin/users/{id}/roles 分配给用户
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param input body CreateRoleRequest true "角色信息"
+// @Security BearerAuth
+// @Success 201 {object} RoleResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误、无效的角色名或权限"
+// @Failure 409 {object} ErrorResponse "角色已存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /admin/roles [post]
+func (c *RoleController) CreateRole(ctx *gin.Context) {
+	var req CreateRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "请求参数错误"})
+		return
+	}
+
+	adminID := ctx.MustGet("userID").(uint)
+	role, err := c.service.CreateRole(ctx, adminID, models.Role(req.Name), req.Description, req.Permissions)
+	if err != nil {
+		respondRoleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, ToRoleResponse(role))
+}
+
+// UpdateRole 修改角色权限
+// @Summary 修改角色权限
+// @Description 替换角色的全部权限，拥有该角色的用户立即生效。管理员角色的权限不能修改
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param name path string true "角色名"
+// @Param input body UpdateRoleRequest true "角色信息"
+// @Security BearerAuth
+// @Success 200 {object} RoleResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误或无效的权限"
+// @Failure 403 {object} ErrorResponse "管理员角色或自己拥有的角色的权限不能修改"
+// @Failure 404 {object} ErrorResponse "角色不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /admin/roles/{name} [put]
+func (c *RoleController) UpdateRole(ctx *gin.Context) {
+	var req UpdateRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "请求参数错误"})
+		return
+	}
+
+	adminID := ctx.MustGet("userID").(uint)
+	role, err := c.service.UpdateRole(ctx, adminID, models.Role(ctx.Param("name")), req.Description, req.Permissions)
+	if err != nil {
+		respondRoleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ToRoleResponse(role))
+}
+
+// DeleteRole 删除角色
+// @Summary 删除角色
+// @Description 只能删除没有用户使用的自定义角色
+// @Tags admin
+// @Produce json
+// @Param name path string true "角色名"
+// @Security BearerAuth
+// @Success 200 {object} MessageResponse
+// @Failure 403 {object} ErrorResponse "内置角色不能删除"
+// @Failure 404 {object} ErrorResponse "角色不存在"
+// @Failure 409 {object} ErrorResponse "角色仍有用户使用"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /admin/roles/{name} [delete]
+func (c *RoleController) DeleteRole(ctx *gin.Context) {
+	adminID := ctx.MustGet("userID").(uint)
+	if err := c.service.DeleteRole(ctx, adminID, models.Role(ctx.Param("name"))); err != nil {
+		respondRoleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "角色已删除"})
+}
+
+// ToRoleResponse 将角色转为响应结构
+func ToRoleResponse(r *services.RoleInfo) *RoleResponse {
+	permissions := make([]string, len(r.Permissions))
+	for i, p := range r.Permissions {
+		permissions[i] = string(p)
+	}
+	return &RoleResponse{
+		Name:        string(r.Name),
+		Description: r.Description,
+		BuiltIn:     r.BuiltIn,
+		Permissions: permissions,
+		CreatedAt:   r.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// respondRoleError 将角色管理的错误转为响应
+func respondRoleError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidRole), errors.Is(err, models.ErrInvalidPermission):
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, models.ErrBuiltInRole), errors.Is(err, models.ErrOwnRole):
+		ctx.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, models.ErrRoleNotFound):
+		ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, models.ErrRoleExists), errors.Is(err, models.ErrRoleInUse):
+		ctx.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
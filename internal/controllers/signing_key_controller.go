@@ -0,0 +1,107 @@
+// internal/controllers/signing_key_controller.go
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"modules/internal/models"
+	"modules/internal/services"
+	"net/http"
+	"time"
+)
+
+type SigningKeyController struct {
+	issuer *services.TokenIssuer
+}
+
+func NewSigningKeyController(issuer *services.TokenIssuer) *SigningKeyController {
+	return &SigningKeyController{issuer: issuer}
+}
+
+// SigningKeyResponse 签名密钥信息，不含私钥
+type SigningKeyResponse struct {
+	KID       string `json:"kid"`
+	Algorithm string `json:"algorithm"`
+	// 是否用于签发新令牌；已停用的密钥仅用于验证，过期后删除
+	Active    bool   `json:"active"`
+	PublicKey string `json:"public_key"`
+	RetiredAt string `json:"retired_at,omitempty"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// JWKS 发布验证访问令牌的公钥
+// @Summary 查询 JWT 公钥集合
+// @Description 返回所有仍可用于验证的公钥（RFC 7517），其他服务按令牌头部的 kid 选择公钥验证签名
+// @Tags auth
+// @Produce json
+// @Success 200 {object} services.JWKS
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /.well-known/jwks.json [get]
+func (c *SigningKeyController) JWKS(ctx *gin.Context) {
+	set, err := c.issuer.JWKS(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	// 轮换后新公钥需要尽快可见，缓存时间不宜过长
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, set)
+}
+
+// ListKeys 查询签名密钥
+// @Summary 查询签名密钥
+// @Description 查询当前签发密钥和仍可用于验证的旧密钥，最新的在前
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} SigningKeyResponse
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /admin/signing-keys [get]
+func (c *SigningKeyController) ListKeys(ctx *gin.Context) {
+	keys, err := c.issuer.ListKeys(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	resp := make([]*SigningKeyResponse, 0, len(keys))
+	for _, k := range keys {
+		resp = append(resp, ToSigningKeyResponse(k))
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// RotateKey 立即轮换签名密钥
+// @Summary 轮换签名密钥
+// @Description 生成新的签发密钥，旧密钥在其签发的访问令牌过期前仍发布在 JWKS 中。怀疑密钥泄露时使用
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SigningKeyResponse
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /admin/signing-keys/rotate [post]
+func (c *SigningKeyController) RotateKey(ctx *gin.Context) {
+	key, err := c.issuer.Rotate(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, ToSigningKeyResponse(key))
+}
+
+// ToSigningKeyResponse 将签名密钥转为响应结构
+func ToSigningKeyResponse(k *models.SigningKey) *SigningKeyResponse {
+	resp := &SigningKeyResponse{
+		KID:       k.KID,
+		Algorithm: k.Algorithm,
+		Active:    k.Active(),
+		PublicKey: k.PublicKey,
+		CreatedAt: k.CreatedAt.Format(time.RFC3339),
+	}
+	if k.RetiredAt != nil {
+		resp.RetiredAt = k.RetiredAt.Format(time.RFC3339)
+	}
+	if k.ExpiresAt != nil {
+		resp.ExpiresAt = k.ExpiresAt.Format(time.RFC3339)
+	}
+	return resp
+}
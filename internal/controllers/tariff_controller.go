@@ -0,0 +1,231 @@
+// internal/controllers/tariff_controller.go
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"modules/internal/models"
+	"modules/internal/services"
+	"modules/internal/tariff"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type TariffController struct {
+	service *services.TariffService
+}
+
+func NewTariffController(service *services.TariffService) *TariffController {
+	return &TariffController{service: service}
+}
+
+// CreateTariffRequest 创建资费版本请求
+type CreateTariffRequest struct {
+	// 资费名称，同名资费的版本号自动递增
+	Name string `json:"name" binding:"required"`
+	// 说明
+	Description string `json:"description"`
+	// 计费规则集
+	Schedule *tariff.Schedule `json:"schedule" binding:"required"`
+}
+
+// TariffResponse 资费版本响应
+type TariffResponse struct {
+	ID          uint            `json:"id"`
+	Name        string          `json:"name"`
+	Version     int             `json:"version"`
+	Description string          `json:"description"`
+	Status      string          `json:"status"`
+	Schedule    json.RawMessage `json:"schedule"`
+	CreatedBy   uint            `json:"created_by"`
+	CreatedAt   string          `json:"created_at"`
+	ActivatedAt string          `json:"activated_at,omitempty"`
+}
+
+// FeeExplanationResponse 停车记录计费说明响应
+type FeeExplanationResponse struct {
+	Record *RecordResponse `json:"record"`
+	Tariff *TariffResponse `json:"tariff,omitempty"`
+	Quote  *tariff.Quote   `json:"quote"`
+}
+
+// CreateTariff 创建资费版本
+// @Summary 创建资费版本
+// @Description 管理员提交资费规则（免费时长、计费单位、每日封顶、夜间统一价、节假日日历，按车位类型区分），生成一个新的草稿版本
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param input body CreateTariffRequest true "资费信息"
+// @Security BearerAuth
+// @Success 201 {object} TariffResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /admin/tariffs [post]
+func (c *TariffController) CreateTariff(ctx *gin.Context) {
+	var req CreateTariffRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := req.Schedule.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	t, err := c.service.CreateTariff(ctx, req.Name, req.Description, req.Schedule, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, ToTariffResponse(t))
+}
+
+// ListTariffs 查询资费版本列表
+// @Summary 查询资费版本列表
+// @Description 管理员查询所有资费版本，可按名称过滤
+// @Tags admin
+// @Produce json
+// @Param name query string false "资费名称"
+// @Security BearerAuth
+// @Success 200 {array} TariffResponse
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /admin/tariffs [get]
+func (c *TariffController) ListTariffs(ctx *gin.Context) {
+	tariffs, err := c.service.ListTariffs(ctx, ctx.Query("name"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := make([]*TariffResponse, 0, len(tariffs))
+	for _, t := range tariffs {
+		response = append(response, ToTariffResponse(t))
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetTariff 查询资费版本详情
+// @Summary 查询资费版本详情
+// @Tags admin
+// @Produce json
+// @Param id path int true "资费ID"
+// @Security BearerAuth
+// @Success 200 {object} TariffResponse
+// @Failure 400 {object} ErrorResponse "无效的资费 ID"
+// @Failure 404 {object} ErrorResponse "资费不存在"
+// @Router /admin/tariffs/{id} [get]
+func (c *TariffController) GetTariff(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的资费 ID"})
+		return
+	}
+
+	t, err := c.service.GetTariff(ctx, uint(id))
+	if err != nil {
+		if errors.Is(err, models.ErrTariffNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ToTariffResponse(t))
+}
+
+// ActivateTariff 启用资费版本
+// @Summary 启用资费版本
+// @Description 启用指定资费版本，当前生效的版本将被归档；此后出场结算均按该版本计费
+// @Tags admin
+// @Produce json
+// @Param id path int true "资费ID"
+// @Security BearerAuth
+// @Success 200 {object} TariffResponse
+// @Failure 400 {object} ErrorResponse "无效的资费 ID"
+// @Failure 404 {object} ErrorResponse "资费不存在"
+// @Failure 409 {object} ErrorResponse "资费已归档"
+// @Router /admin/tariffs/{id}/activate [post]
+func (c *TariffController) ActivateTariff(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的资费 ID"})
+		return
+	}
+
+	t, err := c.service.ActivateTariff(ctx, uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrTariffNotFound):
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		case errors.Is(err, models.ErrTariffArchived):
+			ctx.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ToTariffResponse(t))
+}
+
+// ExplainRecordFee 查询停车记录计费说明
+// @Summary 查询停车记录计费说明
+// @Description 返回停车记录结算时保存的费用明细（含车位类型、费率以及短租租期内免费的情况），用于解释历史账单
+// @Tags admin
+// @Produce json
+// @Param id path int true "停车记录ID"
+// @Security BearerAuth
+// @Success 200 {object} FeeExplanationResponse
+// @Failure 400 {object} ErrorResponse "无效的记录 ID"
+// @Failure 404 {object} ErrorResponse "记录不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /admin/parking-records/{id}/fee [get]
+func (c *TariffController) ExplainRecordFee(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的记录 ID"})
+		return
+	}
+
+	explanation, err := c.service.ExplainRecord(ctx, uint(id))
+	if err != nil {
+		if errors.Is(err, models.ErrParkingNotFound) || errors.Is(err, models.ErrTariffNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		}
+		return
+	}
+
+	response := &FeeExplanationResponse{
+		Record: ToRecordResponse(explanation.Record),
+		Quote:  explanation.Quote,
+	}
+	if explanation.Tariff != nil {
+		response.Tariff = ToTariffResponse(explanation.Tariff)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// ToTariffResponse 将资费模型转为响应结构
+func ToTariffResponse(t *models.Tariff) *TariffResponse {
+	res := &TariffResponse{
+		ID:          t.ID,
+		Name:        t.Name,
+		Version:     t.Version,
+		Description: t.Description,
+		Status:      string(t.Status),
+		Schedule:    json.RawMessage(t.Rules),
+		CreatedBy:   t.CreatedBy,
+		CreatedAt:   t.CreatedAt.Format(time.RFC3339),
+	}
+	if t.ActivatedAt != nil {
+		res.ActivatedAt = t.ActivatedAt.Format(time.RFC3339)
+	}
+	return res
+}
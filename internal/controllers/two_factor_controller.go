@@ -0,0 +1,225 @@
+// internal/controllers/two_factor_controller.go
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"modules/internal/models"
+	"modules/internal/services"
+	"net/http"
+	"strconv"
+)
+
+type TwoFactorController struct {
+	service *services.TwoFactorService
+}
+
+func NewTwoFactorController(service *services.TwoFactorService) *TwoFactorController {
+	return &TwoFactorController{service: service}
+}
+
+// TwoFactorStatusResponse 两步验证状态
+type TwoFactorStatusResponse struct {
+	Enabled bool `json:"enabled"`
+	// 管理员账户必须启用
+	Required               bool  `json:"required"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorSetupResponse 待绑定的 TOTP 密钥
+type TwoFactorSetupResponse struct {
+	// Base32 密钥，可在验证器应用中手动输入
+	Secret string `json:"secret"`
+	// otpauth 地址，可生成二维码供验证器应用扫描
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+// TwoFactorCodeRequest 验证码请求
+type TwoFactorCodeRequest struct {
+	// 验证器应用中的 6 位验证码；关闭两步验证和重新生成恢复码时也可以使用恢复码
+	Code string `json:"code" binding:"required"`
+}
+
+// RecoveryCodesResponse 恢复码，只展示这一次
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// GetStatus 查询两步验证状态
+// @Summary 查询两步验证状态
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} TwoFactorStatusResponse
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /auth/2fa [get]
+func (c *TwoFactorController) GetStatus(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uint)
+	status, err := c.service.Status(ctx, userID)
+	if err != nil {
+		respondTwoFactorError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, TwoFactorStatusResponse{
+		Enabled:                status.Enabled,
+		Required:               status.Required,
+		RecoveryCodesRemaining: status.RecoveryCodesRemaining,
+	})
+}
+
+// Setup 生成两步验证密钥
+// @Summary 生成两步验证密钥
+// @Description 生成新的 TOTP 密钥，在验证器应用中添加后调用 /auth/2fa/enable 确认启用。重复调用会替换尚未确认的密钥
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} TwoFactorSetupResponse
+// @Failure 400 {object} ErrorResponse "已启用两步验证"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /auth/2fa/setup [post]
+func (c *TwoFactorController) Setup(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uint)
+	setup, err := c.service.Setup(ctx, userID)
+	if err != nil {
+		respondTwoFactorError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ToTwoFactorSetupResponse(setup))
+}
+
+// Enable 启用两步验证
+// @Summary 启用两步验证
+// @Description 使用验证器应用生成的验证码确认密钥并启用两步验证，返回一次性恢复码，请妥善保存
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body TwoFactorCodeRequest true "验证码"
+// @Security BearerAuth
+// @Success 200 {object} RecoveryCodesResponse
+// @Failure 400 {object} ErrorResponse "验证码错误，或尚未生成密钥"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /auth/2fa/enable [post]
+func (c *TwoFactorController) Enable(ctx *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "请求参数错误"})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	codes, err := c.service.Enable(ctx, userID, req.Code)
+	if err != nil {
+		respondTwoFactorError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Disable 关闭两步验证
+// @Summary 关闭两步验证
+// @Description 需要当前验证码或恢复码，管理员账户不能关闭
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body TwoFactorCodeRequest true "验证码或恢复码"
+// @Security BearerAuth
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} ErrorResponse "验证码错误"
+// @Failure 403 {object} ErrorResponse "管理员账户必须启用两步验证"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /auth/2fa/disable [post]
+func (c *TwoFactorController) Disable(ctx *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "请求参数错误"})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	if err := c.service.Disable(ctx, userID, req.Code); err != nil {
+		respondTwoFactorError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "已关闭两步验证"})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+// @Summary 重新生成恢复码
+// @Description 需要当前验证码或恢复码，旧恢复码全部作废
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body TwoFactorCodeRequest true "验证码或恢复码"
+// @Security BearerAuth
+// @Success 200 {object} RecoveryCodesResponse
+// @Failure 400 {object} ErrorResponse "验证码错误，或尚未启用两步验证"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /auth/2fa/recovery-codes [post]
+func (c *TwoFactorController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "请求参数错误"})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uint)
+	codes, err := c.service.RegenerateRecoveryCodes(ctx, userID, req.Code)
+	if err != nil {
+		respondTwoFactorError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// ResetUser 重置用户的两步验证
+// @Summary 重置用户的两步验证
+// @Description 用户丢失验证器和恢复码时由管理员重置，用户的登录会话同时失效。管理员账户下次登录时需要重新绑定
+// @Tags admin
+// @Produce json
+// @Param id path int true "用户ID"
+// @Security BearerAuth
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} ErrorResponse "无效的用户 ID"
+// @Failure 403 {object} ErrorResponse "管理员账户只能由管理员重置"
+// @Failure 404 {object} ErrorResponse "用户不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /admin/users/{id}/2fa/reset [post]
+func (c *TwoFactorController) ResetUser(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的用户 ID"})
+		return
+	}
+
+	adminID := ctx.MustGet("userID").(uint)
+	if err := c.service.Reset(ctx, adminID, uint(id)); err != nil {
+		respondTwoFactorError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "已重置两步验证"})
+}
+
+// ToTwoFactorSetupResponse 将待绑定的密钥转为响应结构
+func ToTwoFactorSetupResponse(setup *services.TwoFactorSetup) *TwoFactorSetupResponse {
+	if setup == nil {
+		return nil
+	}
+	return &TwoFactorSetupResponse{Secret: setup.Secret, OTPAuthURL: setup.URI}
+}
+
+// respondTwoFactorError 将两步验证的错误转为响应
+func respondTwoFactorError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidOTP),
+		errors.Is(err, models.ErrTwoFactorNotEnabled),
+		errors.Is(err, models.ErrTwoFactorAlreadyEnabled),
+		errors.Is(err, models.ErrTwoFactorSetupRequired):
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, models.ErrTwoFactorMandatory), errors.Is(err, models.ErrAdminRequired):
+		ctx.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, models.ErrUserNotFound):
+		ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
@@ -0,0 +1,238 @@
+// internal/controllers/user_admin_controller.go
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"modules/internal/models"
+	"modules/internal/repositories"
+	"modules/internal/services"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type UserAdminController struct {
+	service *services.UserAdminService
+}
+
+func NewUserAdminController(service *services.UserAdminService) *UserAdminController {
+	return &UserAdminController{service: service}
+}
+
+// AdminUserResponse 管理员查看的用户信息，不含密码
+type AdminUserResponse struct {
+	ID            uint     `json:"id"`
+	Username      string   `json:"username"`
+	Email         string   `json:"email"`
+	EmailVerified bool     `json:"email_verified"`
+	Phone         string   `json:"phone,omitempty"`
+	Roles         []string `json:"roles"`
+	IsActive      bool     `json:"is_active"`
+	Language      string   `json:"language"`
+	CreatedAt     string   `json:"created_at"`
+}
+
+// UpdateUserRolesRequest 修改用户角色请求，替换用户现有的全部角色
+type UpdateUserRolesRequest struct {
+	Roles []models.Role `json:"roles" binding:"required"`
+}
+
+// ListUsers 查询用户列表
+// @Summary 查询用户列表
+// @Description 按用户名、邮箱或手机号搜索，可按角色和账户状态过滤，按注册时间倒序分页
+// @Tags admin
+// @Produce json
+// @Param q query string false "用户名、邮箱或手机号关键字"
+// @Param role query string false "角色名，例如 admin、owner、operator"
+// @Param active query bool false "账户是否启用"
+// @Param page query int false "页码，从 1 开始"
+// @Param page_size query int false "每页条数，默认 20，最大 100"
+// @Security BearerAuth
+// @Success 200 {object} PageResponse{items=[]AdminUserResponse}
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /admin/users [get]
+func (c *UserAdminController) ListUsers(ctx *gin.Context) {
+	page, pageSize, err := parsePagination(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	filter := repositories.UserFilter{
+		Query:  ctx.Query("q"),
+		Role:   models.Role(ctx.Query("role")),
+		Offset: (page - 1) * pageSize,
+		Limit:  pageSize,
+	}
+	if v := ctx.Query("active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的账户状态"})
+			return
+		}
+		filter.Active = &active
+	}
+
+	users, total, err := c.service.ListUsers(ctx, filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	items := make([]*AdminUserResponse, 0, len(users))
+	for _, u := range users {
+		items = append(items, ToAdminUserResponse(u))
+	}
+	ctx.JSON(http.StatusOK, PageResponse{Items: items, Total: total, Page: page, PageSize: pageSize})
+}
+
+// UpdateRoles 修改用户角色
+// @Summary 修改用户角色
+// @Description 替换用户的全部角色，立即生效。管理员不能移除自己的管理员角色，被授予管理员角色的用户下次登录时需要绑定两步验证
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "用户ID"
+// @Param input body UpdateUserRolesRequest true "角色列表"
+// @Security BearerAuth
+// @Success 200 {object} AdminUserResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 403 {object} ErrorResponse "不能移除自己的管理员角色，或授予、移除管理员角色需要管理员"
+// @Failure 404 {object} ErrorResponse "用户不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /admin/users/{id}/roles [put]
+func (c *UserAdminController) UpdateRoles(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的用户 ID"})
+		return
+	}
+	var req UpdateUserRolesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "请求参数错误"})
+		return
+	}
+
+	adminID := ctx.MustGet("userID").(uint)
+	user, err := c.service.SetRoles(ctx, adminID, uint(id), req.Roles)
+	if err != nil {
+		respondUserAdminError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ToAdminUserResponse(user))
+}
+
+// ActivateUser 启用账户
+// @Summary 启用账户
+// @Tags admin
+// @Produce json
+// @Param id path int true "用户ID"
+// @Security BearerAuth
+// @Success 200 {object} AdminUserResponse
+// @Failure 400 {object} ErrorResponse "无效的用户 ID"
+// @Failure 403 {object} ErrorResponse "管理员账户只能由管理员启用"
+// @Failure 404 {object} ErrorResponse "用户不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /admin/users/{id}/activate [post]
+func (c *UserAdminController) ActivateUser(ctx *gin.Context) {
+	c.setActive(ctx, true)
+}
+
+// DeactivateUser 停用账户
+// @Summary 停用账户
+// @Description 停用后账户无法登录，已签发的令牌立即失效。管理员不能停用自己
+// @Tags admin
+// @Produce json
+// @Param id path int true "用户ID"
+// @Security BearerAuth
+// @Success 200 {object} AdminUserResponse
+// @Failure 400 {object} ErrorResponse "无效的用户 ID"
+// @Failure 403 {object} ErrorResponse "不能停用自己的账户，管理员账户只能由管理员停用"
+// @Failure 404 {object} ErrorResponse "用户不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /admin/users/{id}/deactivate [post]
+func (c *UserAdminController) DeactivateUser(ctx *gin.Context) {
+	c.setActive(ctx, false)
+}
+
+func (c *UserAdminController) setActive(ctx *gin.Context, active bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的用户 ID"})
+		return
+	}
+
+	adminID := ctx.MustGet("userID").(uint)
+	user, err := c.service.SetActive(ctx, adminID, uint(id), active)
+	if err != nil {
+		respondUserAdminError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ToAdminUserResponse(user))
+}
+
+// ForcePasswordReset 强制重置密码
+// @Summary 强制重置密码
+// @Description 用户当前密码和登录会话立即失效，并向其邮箱发送密码重置邮件
+// @Tags admin
+// @Produce json
+// @Param id path int true "用户ID"
+// @Security BearerAuth
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} ErrorResponse "无效的用户 ID"
+// @Failure 403 {object} ErrorResponse "管理员账户只能由管理员重置"
+// @Failure 404 {object} ErrorResponse "用户不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /admin/users/{id}/password-reset [post]
+func (c *UserAdminController) ForcePasswordReset(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的用户 ID"})
+		return
+	}
+
+	adminID := ctx.MustGet("userID").(uint)
+	if err := c.service.ForcePasswordReset(ctx, adminID, uint(id)); err != nil {
+		respondUserAdminError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "已发送密码重置邮件"})
+}
+
+// ToAdminUserResponse 将用户转为管理员查看的响应结构
+func ToAdminUserResponse(u *models.User) *AdminUserResponse {
+	roles := []models.Role{}
+	if len(u.Roles) > 0 {
+		// 角色列格式错误时按无角色展示
+		_ = u.Roles.Unmarshal(&roles)
+	}
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+	return &AdminUserResponse{
+		ID:            u.ID,
+		Username:      u.Username,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified(),
+		Phone:         u.Phone,
+		Roles:         names,
+		IsActive:      u.IsActive,
+		Language:      u.Language,
+		CreatedAt:     u.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// respondUserAdminError 将用户管理的错误转为响应
+func respondUserAdminError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidRole):
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, models.ErrSelfModification), errors.Is(err, models.ErrAdminRequired):
+		ctx.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, models.ErrUserNotFound):
+		ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
@@ -49,14 +49,14 @@ func (c *VehicleController) BindVehicle(ctx *gin.Context) {
 }
 
 // @Summary 出租车位
-// @Description 用户将自己的车位发布出租，需指定车位ID、出租价格及出租天数。
+// @Description 业主将自己的车位发布到出租市场，需指定车位ID、月租价格及出租天数，发布后租客可搜索并申请预订。
 // @Tags parking
 // @Accept json
 // @Produce json
 // @Example {"spot_id": 1, "days": 30, "rate": 280}
 // @Param input body RentRequest true "出租车位请求体"
 // @Security BearerAuth
-// @Success 200 {object} LeaseResponse "发布成功返回租赁信息"
+// @Success 200 {object} ListingResponse "发布成功返回出租信息"
 // @Failure 400 {object} ErrorResponse "请求参数错误"
 // @Failure 401 {object} ErrorResponse "未授权访问"
 // @Failure 500 {object} ErrorResponse "服务器内部错误"
@@ -71,14 +71,14 @@ func (c *VehicleController) PublishForRent(ctx *gin.Context) {
 	userID := ctx.MustGet("userID").(uint)
 	log.Printf("Received request to publish spot for rent: userID=%d, spotID=%d, rate=%f, period=%d", userID, req.SpotID, req.Rate, req.Days)
 
-	lease, err := c.service.PublishSpotForRent(ctx, userID, req.SpotID, req.Rate, req.Days)
+	listing, err := c.service.PublishSpotForRent(ctx, userID, req.SpotID, req.Rate, req.Days)
 	if err != nil {
 		log.Printf("Error in PublishSpotForRent: %v", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, ToLeaseResponse(lease))
+	ctx.JSON(http.StatusOK, ToListingResponse(listing))
 }
 
 // DTOs
@@ -0,0 +1,387 @@
+// internal/controllers/webhook_controller.go
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"modules/internal/models"
+	"modules/internal/services"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type WebhookController struct {
+	service *services.WebhookService
+}
+
+func NewWebhookController(service *services.WebhookService) *WebhookController {
+	return &WebhookController{service: service}
+}
+
+// CreateWebhookRequest 新建 Webhook 订阅请求
+type CreateWebhookRequest struct {
+	URL string `json:"url" binding:"required"`
+	// 订阅的事件类型：parking.entry / parking.exit / lease.created / spot.status_changed，为空表示全部
+	Events      []models.WebhookEvent `json:"events"`
+	Description string                `json:"description"`
+	// 签名密钥，为空时自动生成
+	Secret string `json:"secret"`
+}
+
+// UpdateWebhookRequest 修改 Webhook 订阅请求，未提供的字段保持不变
+type UpdateWebhookRequest struct {
+	URL          *string                `json:"url"`
+	Events       *[]models.WebhookEvent `json:"events"`
+	Description  *string                `json:"description"`
+	Active       *bool                  `json:"active"`
+	RotateSecret bool                   `json:"rotate_secret"` // 为 true 时重新生成签名密钥
+}
+
+// WebhookResponse Webhook 订阅响应，密钥仅在创建和轮换时完整返回
+type WebhookResponse struct {
+	ID          uint                  `json:"id"`
+	URL         string                `json:"url"`
+	Secret      string                `json:"secret"`
+	Events      []models.WebhookEvent `json:"events"`
+	Description string                `json:"description"`
+	Active      bool                  `json:"active"`
+	CreatedBy   uint                  `json:"created_by"`
+	CreatedAt   string                `json:"created_at"`
+	UpdatedAt   string                `json:"updated_at"`
+}
+
+// WebhookDeliveryResponse 投递记录响应
+type WebhookDeliveryResponse struct {
+	ID             uint                      `json:"id"`
+	SubscriptionID uint                      `json:"subscription_id"`
+	EventID        string                    `json:"event_id"`
+	Event          string                    `json:"event"`
+	Status         string                    `json:"status"`
+	Attempts       int                       `json:"attempts"`
+	NextAttemptAt  string                    `json:"next_attempt_at,omitempty"`
+	LastStatusCode int                       `json:"last_status_code"`
+	LastError      string                    `json:"last_error,omitempty"`
+	Payload        string                    `json:"payload,omitempty"`
+	AttemptLogs    []*WebhookAttemptResponse `json:"attempt_logs,omitempty"`
+	CreatedAt      string                    `json:"created_at"`
+}
+
+// WebhookAttemptResponse 单次投递尝试响应
+type WebhookAttemptResponse struct {
+	Attempt    int    `json:"attempt"`
+	StatusCode int    `json:"status_code"`
+	Error      string `json:"error,omitempty"`
+	Response   string `json:"response,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// CreateWebhook 新建 Webhook 订阅
+// @Summary 新建 Webhook 订阅
+// @Description 事件以 JSON POST 推送，请求头 X-Webhook-Signature 为 sha256=HMAC-SHA256(secret, timestamp + "." + body)，时间戳见 X-Webhook-Timestamp。响应中的密钥只返回这一次
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param input body CreateWebhookRequest true "订阅信息"
+// @Security BearerAuth
+// @Success 201 {object} WebhookResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Router /admin/webhooks [post]
+func (c *WebhookController) CreateWebhook(ctx *gin.Context) {
+	var req CreateWebhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	adminID := ctx.MustGet("userID").(uint)
+	sub, err := c.service.CreateSubscription(ctx, adminID, req.URL, req.Events, req.Description, req.Secret)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, ToWebhookResponse(sub, true))
+}
+
+// ListWebhooks 查询 Webhook 订阅
+// @Summary 查询 Webhook 订阅
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} WebhookResponse
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /admin/webhooks [get]
+func (c *WebhookController) ListWebhooks(ctx *gin.Context) {
+	subs, err := c.service.ListSubscriptions(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := make([]*WebhookResponse, 0, len(subs))
+	for _, sub := range subs {
+		response = append(response, ToWebhookResponse(sub, false))
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetWebhook 查询 Webhook 订阅详情
+// @Summary 查询 Webhook 订阅详情
+// @Tags admin
+// @Produce json
+// @Param id path int true "订阅ID"
+// @Security BearerAuth
+// @Success 200 {object} WebhookResponse
+// @Failure 400 {object} ErrorResponse "无效的订阅 ID"
+// @Failure 404 {object} ErrorResponse "订阅不存在"
+// @Router /admin/webhooks/{id} [get]
+func (c *WebhookController) GetWebhook(ctx *gin.Context) {
+	id, ok := parseWebhookID(ctx, "无效的订阅 ID")
+	if !ok {
+		return
+	}
+
+	sub, err := c.service.GetSubscription(ctx, id)
+	if err != nil {
+		respondWebhookError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ToWebhookResponse(sub, false))
+}
+
+// UpdateWebhook 修改 Webhook 订阅
+// @Summary 修改 Webhook 订阅
+// @Description 可修改地址、事件、描述和启用状态；rotate_secret 为 true 时重新生成密钥并在响应中返回
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "订阅ID"
+// @Param input body UpdateWebhookRequest true "修改内容"
+// @Security BearerAuth
+// @Success 200 {object} WebhookResponse
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "订阅不存在"
+// @Router /admin/webhooks/{id} [put]
+func (c *WebhookController) UpdateWebhook(ctx *gin.Context) {
+	id, ok := parseWebhookID(ctx, "无效的订阅 ID")
+	if !ok {
+		return
+	}
+	var req UpdateWebhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	sub, err := c.service.UpdateSubscription(ctx, id, services.WebhookUpdate{
+		URL:          req.URL,
+		Events:       req.Events,
+		Description:  req.Description,
+		Active:       req.Active,
+		RotateSecret: req.RotateSecret,
+	})
+	if err != nil {
+		respondWebhookError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ToWebhookResponse(sub, req.RotateSecret))
+}
+
+// DeleteWebhook 删除 Webhook 订阅
+// @Summary 删除 Webhook 订阅
+// @Description 删除后未完成的投递不再重试
+// @Tags admin
+// @Param id path int true "订阅ID"
+// @Security BearerAuth
+// @Success 204
+// @Failure 400 {object} ErrorResponse "无效的订阅 ID"
+// @Failure 404 {object} ErrorResponse "订阅不存在"
+// @Router /admin/webhooks/{id} [delete]
+func (c *WebhookController) DeleteWebhook(ctx *gin.Context) {
+	id, ok := parseWebhookID(ctx, "无效的订阅 ID")
+	if !ok {
+		return
+	}
+
+	if err := c.service.DeleteSubscription(ctx, id); err != nil {
+		respondWebhookError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListDeliveries 查询订阅的投递记录
+// @Summary 查询 Webhook 投递记录
+// @Tags admin
+// @Produce json
+// @Param id path int true "订阅ID"
+// @Param status query string false "投递状态：pending / succeeded / failed"
+// @Param page query int false "页码，从 1 开始"
+// @Param page_size query int false "每页条数，默认 20，最大 100"
+// @Security BearerAuth
+// @Success 200 {object} PageResponse{items=[]WebhookDeliveryResponse}
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "订阅不存在"
+// @Router /admin/webhooks/{id}/deliveries [get]
+func (c *WebhookController) ListDeliveries(ctx *gin.Context) {
+	id, ok := parseWebhookID(ctx, "无效的订阅 ID")
+	if !ok {
+		return
+	}
+	page, pageSize, err := parsePagination(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	status := models.DeliveryStatus(ctx.Query("status"))
+	switch status {
+	case "", models.DeliveryPending, models.DeliverySucceeded, models.DeliveryFailed:
+	default:
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的投递状态"})
+		return
+	}
+
+	deliveries, total, err := c.service.ListDeliveries(ctx, id, status, page, pageSize)
+	if err != nil {
+		respondWebhookError(ctx, err)
+		return
+	}
+
+	items := make([]*WebhookDeliveryResponse, 0, len(deliveries))
+	for _, d := range deliveries {
+		items = append(items, ToWebhookDeliveryResponse(d, false))
+	}
+	ctx.JSON(http.StatusOK, PageResponse{Items: items, Total: total, Page: page, PageSize: pageSize})
+}
+
+// GetDelivery 查询投递详情
+// @Summary 查询 Webhook 投递详情
+// @Description 包含推送内容和每一次尝试的状态码、错误与耗时
+// @Tags admin
+// @Produce json
+// @Param id path int true "投递ID"
+// @Security BearerAuth
+// @Success 200 {object} WebhookDeliveryResponse
+// @Failure 400 {object} ErrorResponse "无效的投递 ID"
+// @Failure 404 {object} ErrorResponse "投递记录不存在"
+// @Router /admin/webhooks/deliveries/{id} [get]
+func (c *WebhookController) GetDelivery(ctx *gin.Context) {
+	id, ok := parseWebhookID(ctx, "无效的投递 ID")
+	if !ok {
+		return
+	}
+
+	d, err := c.service.GetDelivery(ctx, id)
+	if err != nil {
+		respondWebhookError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ToWebhookDeliveryResponse(d, true))
+}
+
+// ReplayDelivery 重新推送
+// @Summary 重新推送 Webhook 投递
+// @Description 立即以原内容重新推送一次，返回推送后的投递详情
+// @Tags admin
+// @Produce json
+// @Param id path int true "投递ID"
+// @Security BearerAuth
+// @Success 200 {object} WebhookDeliveryResponse
+// @Failure 400 {object} ErrorResponse "无效的投递 ID"
+// @Failure 404 {object} ErrorResponse "投递记录或订阅不存在"
+// @Router /admin/webhooks/deliveries/{id}/replay [post]
+func (c *WebhookController) ReplayDelivery(ctx *gin.Context) {
+	id, ok := parseWebhookID(ctx, "无效的投递 ID")
+	if !ok {
+		return
+	}
+
+	d, err := c.service.Replay(ctx, id)
+	if err != nil {
+		respondWebhookError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ToWebhookDeliveryResponse(d, true))
+}
+
+func parseWebhookID(ctx *gin.Context, message string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: message})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondWebhookError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrWebhookNotFound), errors.Is(err, models.ErrDeliveryNotFound):
+		ctx.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+	default:
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+}
+
+// ToWebhookResponse 将订阅转为响应结构，showSecret 为 false 时只保留密钥末 4 位
+func ToWebhookResponse(sub *models.WebhookSubscription, showSecret bool) *WebhookResponse {
+	secret := sub.Secret
+	if !showSecret {
+		secret = maskSecret(secret)
+	}
+	events := services.WebhookEventList(sub)
+	if events == nil {
+		events = []models.WebhookEvent{}
+	}
+	return &WebhookResponse{
+		ID:          sub.ID,
+		URL:         sub.URL,
+		Secret:      secret,
+		Events:      events,
+		Description: sub.Description,
+		Active:      sub.Active,
+		CreatedBy:   sub.CreatedBy,
+		CreatedAt:   sub.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   sub.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// ToWebhookDeliveryResponse 将投递记录转为响应结构，detail 为 true 时包含推送内容和尝试记录
+func ToWebhookDeliveryResponse(d *models.WebhookDelivery, detail bool) *WebhookDeliveryResponse {
+	resp := &WebhookDeliveryResponse{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		Event:          string(d.Event),
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt.Format(time.RFC3339),
+	}
+	if d.Status == models.DeliveryPending {
+		resp.NextAttemptAt = d.NextAttemptAt.Format(time.RFC3339)
+	}
+	if detail {
+		resp.Payload = d.Payload
+		resp.AttemptLogs = make([]*WebhookAttemptResponse, 0, len(d.AttemptLogs))
+		for _, a := range d.AttemptLogs {
+			resp.AttemptLogs = append(resp.AttemptLogs, &WebhookAttemptResponse{
+				Attempt:    a.Attempt,
+				StatusCode: a.StatusCode,
+				Error:      a.Error,
+				Response:   a.Response,
+				DurationMs: a.DurationMs,
+				CreatedAt:  a.CreatedAt.Format(time.RFC3339),
+			})
+		}
+	}
+	return resp
+}
+
+func maskSecret(secret string) string {
+	if len(secret) <= 4 {
+		return "****"
+	}
+	return "****" + secret[len(secret)-4:]
+}
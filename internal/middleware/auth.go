@@ -1,9 +1,11 @@
 package middleware
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"log"
 	"modules/config"
+	"modules/internal/models"
 	"modules/internal/services"
 	"net/http"
 	"strings"
@@ -29,10 +31,16 @@ func JWTAuthMiddleware(cfg *config.Config, authService *services.AuthService) gi
 			return
 		}
 
-		claims, err := authService.ValidateToken(tokenString)
+		claims, err := authService.Authenticate(c, tokenString)
 		if err != nil {
 			log.Printf("令牌验证失败: %v", err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的认证令牌"})
+			if errors.Is(err, models.ErrTokenRevoked) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "认证令牌已失效，请重新登录"})
+			} else if errors.Is(err, models.ErrAccountDisabled) || errors.Is(err, models.ErrTwoFactorMandatory) {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			} else {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的认证令牌"})
+			}
 			c.Abort()
 			return
 		}
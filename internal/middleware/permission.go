@@ -0,0 +1,45 @@
+// internal/middleware/permission.go
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"modules/internal/models"
+	"modules/internal/services"
+	"net/http"
+)
+
+// RequirePermission 要求用户的角色拥有全部指定权限，需在 JWTAuthMiddleware 之后使用
+func RequirePermission(permissionService *services.PermissionService, permissions ...models.Permission) gin.HandlerFunc {
+	return permissionCheck(permissionService, permissions, true)
+}
+
+// RequireAnyPermission 要求用户的角色拥有任意一个指定权限，需在 JWTAuthMiddleware 之后使用
+func RequireAnyPermission(permissionService *services.PermissionService, permissions ...models.Permission) gin.HandlerFunc {
+	return permissionCheck(permissionService, permissions, false)
+}
+
+func permissionCheck(permissionService *services.PermissionService, permissions []models.Permission, requireAll bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roles, ok := c.Get("roles")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "未授权访问，缺少角色信息"})
+			return
+		}
+		roleStrings, ok := roles.([]string)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "角色信息类型错误"})
+			return
+		}
+
+		allowed, err := permissionService.HasPermissions(c, roleStrings, permissions, requireAll)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !allowed {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "权限不足"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,26 @@
+// internal/middleware/verified_email.go
+package middleware
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"modules/internal/models"
+	"modules/internal/services"
+	"net/http"
+)
+
+// RequireVerifiedEmail 要求当前用户已完成邮箱验证，需放在 JWT 认证中间件之后
+func RequireVerifiedEmail(authService *services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+		if err := authService.CheckEmailVerified(c, userID); err != nil {
+			if errors.Is(err, models.ErrEmailNotVerified) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			} else {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		c.Next()
+	}
+}
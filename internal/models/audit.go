@@ -0,0 +1,39 @@
+// internal/models/audit.go
+package models
+
+import "time"
+
+// 审计事件目标类型
+const (
+	AuditTargetSpot = "spot"
+	AuditTargetUser = "user"
+	AuditTargetRole = "role"
+)
+
+// 审计事件动作
+const (
+	AuditSpotAutoRecovered     = "spot.auto_recovered"
+	AuditSpotMarkedFaulty      = "spot.marked_faulty"
+	AuditSpotRestored          = "spot.restored"
+	AuditRecoveryPolicyUpdated = "spot.recovery_policy_updated"
+	AuditUserTwoFactorReset    = "user.two_factor_reset"
+	AuditUserRolesUpdated      = "user.roles_updated"
+	AuditUserActivated         = "user.activated"
+	AuditUserDeactivated       = "user.deactivated"
+	AuditUserPasswordReset     = "user.password_reset_forced"
+	AuditRoleCreated           = "role.created"
+	AuditRoleUpdated           = "role.updated"
+	AuditRoleDeleted           = "role.deleted"
+)
+
+// AuditEvent 审计事件，记录系统自动处理及管理员的关键操作
+type AuditEvent struct {
+	ID uint `gorm:"primaryKey"`
+	// 操作人，系统自动处理时为 0
+	ActorID    uint      `gorm:"index"`
+	Action     string    `gorm:"size:64;index"`
+	TargetType string    `gorm:"size:32;index:idx_audit_target"`
+	TargetID   uint      `gorm:"index:idx_audit_target"`
+	Detail     string    `gorm:"type:text"`
+	CreatedAt  time.Time `gorm:"autoCreateTime;index"`
+}
@@ -6,4 +6,6 @@ var (
 	ErrParkingSpotNotFound   = errors.New("车位不存在")
 	ErrParkingNotBoundToUser = errors.New("车位未绑定给指定用户")
 	ErrParkingAlreadyBound   = errors.New("车位已被绑定")
+	ErrParkingNotCompleted   = errors.New("停车记录尚未出场结算")
+	ErrParkingHasUser        = errors.New("注册用户的停车费需由本人缴纳")
 )
@@ -2,14 +2,25 @@
 package models
 
 import (
+	"errors"
 	"time"
 )
 
 type LeaseStatus string
 
 const (
-	LeaseActive  LeaseStatus = "active"
-	LeaseExpired LeaseStatus = "expired"
+	LeasePending    LeaseStatus = "pending" // 待支付
+	LeaseActive     LeaseStatus = "active"
+	LeaseExpired    LeaseStatus = "expired"
+	LeaseTerminated LeaseStatus = "terminated" // 生效后提前终止
+	LeaseCancelled  LeaseStatus = "cancelled"  // 支付前取消
+)
+
+var (
+	ErrLeaseNotFound = errors.New("租赁订单不存在")
+	ErrLeaseConflict = errors.New("车位在该时段已被租用")
+	// 订单状态或支付状态已被其他请求修改
+	ErrLeaseStateChanged = errors.New("租赁订单状态已变更，请刷新后重试")
 )
 
 type LeaseOrder struct {
@@ -22,4 +33,36 @@ type LeaseOrder struct {
 	Status     LeaseStatus
 	AutoRenew  bool `gorm:"default:false"`
 	CreatedAt  time.Time
+	// 提前终止时间
+	TerminatedAt *time.Time
+	// 下单时的车位业主，用于续租时判断车位是否已转让
+	OwnerID uint
+	// 自动续租时对应的上一期订单
+	RenewedFromID *uint `gorm:"index"`
+}
+
+type LeaseRenewalStatus string
+
+const (
+	RenewalSucceeded LeaseRenewalStatus = "succeeded"
+	RenewalFailed    LeaseRenewalStatus = "failed"
+)
+
+// LeaseRenewal 自动续租执行记录，失败时记录原因
+type LeaseRenewal struct {
+	ID         uint               `gorm:"primaryKey"`
+	LeaseID    uint               `gorm:"not null;index"`
+	NewLeaseID *uint              // 续租生成的新订单
+	Status     LeaseRenewalStatus `gorm:"type:varchar(20)"`
+	Reason     string             `gorm:"size:255"`
+	CreatedAt  time.Time          `gorm:"autoCreateTime"`
+}
+
+// LeaseReminder 租赁到期提醒发送记录，同一订单的同一档提醒对每个收件人只发送一次
+type LeaseReminder struct {
+	ID          uint      `gorm:"primaryKey"`
+	LeaseID     uint      `gorm:"not null;uniqueIndex:idx_lease_reminder"`
+	OffsetDays  int       `gorm:"not null;uniqueIndex:idx_lease_reminder"`
+	RecipientID uint      `gorm:"not null;uniqueIndex:idx_lease_reminder"`
+	SentAt      time.Time `gorm:"autoCreateTime"`
 }
@@ -0,0 +1,65 @@
+// internal/models/ledger.go
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+type AccountType string
+
+const (
+	AccountAsset     AccountType = "asset"
+	AccountLiability AccountType = "liability"
+	AccountRevenue   AccountType = "revenue"
+)
+
+// JournalSource 分录来源
+type JournalSource string
+
+const (
+	JournalFromLease   JournalSource = "lease"
+	JournalFromParking JournalSource = "parking"
+	// 退款冲销，来源ID为退款申请ID
+	JournalFromRefund JournalSource = "refund"
+)
+
+var ErrUnbalancedEntry = errors.New("分录借贷不平衡")
+
+// LedgerAccount 记账科目：平台收款、平台佣金收入以及每个业主的应付款
+type LedgerAccount struct {
+	ID   uint        `gorm:"primaryKey"`
+	Code string      `gorm:"size:64;uniqueIndex"`
+	Name string      `gorm:"size:100"`
+	Type AccountType `gorm:"type:varchar(20)"`
+	// 业主应付款科目对应的业主
+	OwnerID   *uint     `gorm:"index"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// JournalEntry 记账凭证，每笔租赁或停车收入对应一张凭证；退款按比例冲销，金额为负数
+type JournalEntry struct {
+	ID         uint          `gorm:"primaryKey"`
+	SourceType JournalSource `gorm:"type:varchar(20);uniqueIndex:idx_journal_source"`
+	SourceID   uint          `gorm:"uniqueIndex:idx_journal_source"`
+	OwnerID    uint          `gorm:"not null;index"`
+	SpotID     uint
+	PaymentID  uint
+	// 总收入、平台佣金、业主分成
+	Gross       float64       `gorm:"type:decimal(10,2)"`
+	Commission  float64       `gorm:"type:decimal(10,2)"`
+	OwnerShare  float64       `gorm:"type:decimal(10,2)"`
+	Description string        `gorm:"size:255"`
+	OccurredAt  time.Time     `gorm:"not null;index"`
+	Lines       []JournalLine `gorm:"foreignKey:EntryID"`
+	CreatedAt   time.Time     `gorm:"autoCreateTime"`
+}
+
+// JournalLine 凭证分录行，一张凭证的借方合计必须等于贷方合计
+type JournalLine struct {
+	ID        uint    `gorm:"primaryKey"`
+	EntryID   uint    `gorm:"not null;index"`
+	AccountID uint    `gorm:"not null;index"`
+	Debit     float64 `gorm:"type:decimal(10,2)"`
+	Credit    float64 `gorm:"type:decimal(10,2)"`
+}
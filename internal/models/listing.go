@@ -0,0 +1,86 @@
+// internal/models/listing.go
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+type ListingStatus string
+
+const (
+	ListingOpen   ListingStatus = "open"
+	ListingClosed ListingStatus = "closed"
+)
+
+// PriceUnit 出租计价单位
+type PriceUnit string
+
+const (
+	PriceMonthly PriceUnit = "monthly"
+	PriceHourly  PriceUnit = "hourly"
+)
+
+type BookingStatus string
+
+const (
+	BookingPending   BookingStatus = "pending"
+	BookingAccepted  BookingStatus = "accepted"
+	BookingDeclined  BookingStatus = "declined"
+	BookingCancelled BookingStatus = "cancelled"
+)
+
+var (
+	ErrListingNotFound = errors.New("出租信息不存在")
+	ErrListingClosed   = errors.New("出租信息已下架")
+	ErrBookingNotFound = errors.New("预订申请不存在")
+	// 处理预订申请时申请已被其他请求处理或撤回
+	ErrBookingNotPending = errors.New("预订申请已被处理或撤回")
+)
+
+// SpotListing 业主发布的车位出租信息
+type SpotListing struct {
+	ID      uint `gorm:"primaryKey"`
+	SpotID  uint `gorm:"not null;index"`
+	OwnerID uint `gorm:"not null;index"`
+	// 发布时的车位类型，便于搜索
+	SpotType  ParkingType `gorm:"type:varchar(20)"`
+	Title     string      `gorm:"size:100"`
+	PriceUnit PriceUnit   `gorm:"type:varchar(20)"`
+	Price     float64     `gorm:"type:decimal(10,2)"`
+	// 出租条款
+	Terms     string          `gorm:"type:text"`
+	Status    ListingStatus   `gorm:"type:varchar(20);default:'open';index"`
+	Windows   []ListingWindow `gorm:"foreignKey:ListingID"`
+	CreatedAt time.Time       `gorm:"autoCreateTime"`
+	UpdatedAt time.Time       `gorm:"autoUpdateTime"`
+}
+
+// ListingWindow 出租信息的可租时段
+type ListingWindow struct {
+	ID        uint      `gorm:"primaryKey"`
+	ListingID uint      `gorm:"not null;index"`
+	StartTime time.Time `gorm:"not null"`
+	EndTime   time.Time `gorm:"not null"`
+}
+
+// BookingRequest 租客对出租信息的预订申请，业主接受后生成租赁订单
+type BookingRequest struct {
+	ID        uint `gorm:"primaryKey"`
+	ListingID uint `gorm:"not null;index"`
+	SpotID    uint `gorm:"not null;index"`
+	RenterID  uint `gorm:"not null;index"`
+	OwnerID   uint `gorm:"not null;index"`
+	StartTime time.Time
+	EndTime   time.Time
+	Amount    float64       `gorm:"type:decimal(10,2)"`
+	Message   string        `gorm:"size:255"`
+	Status    BookingStatus `gorm:"type:varchar(20);default:'pending';index"`
+	// 业主处理备注
+	DecisionNote string `gorm:"size:255"`
+	DecidedAt    *time.Time
+	// 接受后生成的租赁订单
+	LeaseID   *uint
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
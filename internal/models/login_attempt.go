@@ -0,0 +1,42 @@
+// internal/models/login_attempt.go
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// 登录失败计数的维度
+type LoginAttemptScope string
+
+const (
+	LoginScopeAccount LoginAttemptScope = "account" // 按用户名（小写）计数，用户名不存在时同样计数
+	LoginScopeIP      LoginAttemptScope = "ip"      // 按客户端 IP 计数
+)
+
+var (
+	// ErrInvalidCredentials 用户名不存在和密码错误统一返回该错误，避免泄露用户名是否存在
+	ErrInvalidCredentials = errors.New("用户名或密码错误")
+	ErrLoginLocked        = errors.New("登录失败次数过多，请稍后再试")
+	ErrAccountDisabled    = errors.New("账户已停用")
+	ErrNotAdmin           = errors.New("非管理员用户，无权访问")
+)
+
+// LoginAttempt 某个账户或 IP 的连续登录失败记录
+type LoginAttempt struct {
+	ID    uint              `gorm:"primaryKey"`
+	Scope LoginAttemptScope `gorm:"type:varchar(20);not null;uniqueIndex:idx_login_attempt_key"`
+	Key   string            `gorm:"size:100;not null;uniqueIndex:idx_login_attempt_key"`
+	// 连续失败次数，登录成功或超过重置时间后清零
+	Failures     int
+	LastFailedAt time.Time
+	// 锁定截止时间，为空表示未锁定
+	LockedUntil *time.Time
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+}
+
+// Locked now 时是否处于锁定状态
+func (a *LoginAttempt) Locked(now time.Time) bool {
+	return a.LockedUntil != nil && now.Before(*a.LockedUntil)
+}
@@ -0,0 +1,65 @@
+// internal/models/notification.go
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// NotificationEvent 通知事件类型
+type NotificationEvent string
+
+const (
+	NotifyLeaseCreated  NotificationEvent = "lease_created"
+	NotifyLeaseExpiring NotificationEvent = "lease_expiring"
+	NotifyExitReceipt   NotificationEvent = "exit_receipt"
+	NotifyPasswordReset NotificationEvent = "password_reset"
+	// NotifyEmailVerification 邮箱验证邮件
+	NotifyEmailVerification NotificationEvent = "email_verification"
+	// NotifyAdminAlert 系统自动处理结果，发给管理员
+	NotifyAdminAlert NotificationEvent = "admin_alert"
+)
+
+// NotificationEvents 全部可配置偏好的事件类型。
+// 密码重置和邮箱验证属于账户安全邮件，总是直接发送到邮箱，不可配置
+var NotificationEvents = []NotificationEvent{
+	NotifyLeaseCreated,
+	NotifyLeaseExpiring,
+	NotifyExitReceipt,
+	NotifyAdminAlert,
+}
+
+// NotificationChannel 站内信之外的投递渠道，站内信总会保存
+type NotificationChannel string
+
+const (
+	ChannelEmail NotificationChannel = "email"
+	ChannelSMS   NotificationChannel = "sms"
+	// ChannelInbox 仅保存站内信
+	ChannelInbox NotificationChannel = "inbox"
+)
+
+// DefaultNotificationChannel 用户未设置偏好时的投递渠道
+const DefaultNotificationChannel = ChannelEmail
+
+var ErrNotificationNotFound = errors.New("通知不存在")
+
+// Notification 站内信
+type Notification struct {
+	ID        uint              `gorm:"primaryKey"`
+	UserID    uint              `gorm:"not null;index"`
+	Event     NotificationEvent `gorm:"type:varchar(32)"`
+	Title     string            `gorm:"size:255"`
+	Body      string            `gorm:"type:text"`
+	ReadAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+}
+
+// NotificationPreference 用户对某类事件的投递渠道偏好
+type NotificationPreference struct {
+	ID        uint                `gorm:"primaryKey"`
+	UserID    uint                `gorm:"not null;uniqueIndex:idx_notification_pref"`
+	Event     NotificationEvent   `gorm:"type:varchar(32);not null;uniqueIndex:idx_notification_pref"`
+	Channel   NotificationChannel `gorm:"type:varchar(20);not null"`
+	UpdatedAt time.Time           `gorm:"autoUpdateTime"`
+}
@@ -0,0 +1,166 @@
+// internal/models/outbox.go
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxPending   OutboxStatus = "pending"   // 等待分发或重试
+	OutboxDelivered OutboxStatus = "delivered" // 所有处理器均已处理
+	OutboxFailed    OutboxStatus = "failed"    // 重试次数用尽，需要管理员介入
+)
+
+// 领域事件的聚合类型
+const (
+	AggregateParkingRecord = "parking_record"
+	AggregateLease         = "lease"
+	AggregateSpot          = "spot"
+)
+
+var ErrOutboxEventNotFound = errors.New("事件不存在")
+
+// OutboxEvent 发件箱事件，与业务状态变更写在同一事务中，由后台分发器至少投递一次
+type OutboxEvent struct {
+	ID      uint   `gorm:"primaryKey"`
+	EventID string `gorm:"size:64;uniqueIndex"`
+	// 事件类型，与 Webhook 事件类型一致
+	Event         WebhookEvent `gorm:"type:varchar(50);index"`
+	AggregateType string       `gorm:"size:32"`
+	AggregateID   uint
+	// 序列化后的 EventEnvelope
+	Payload  string       `gorm:"type:text"`
+	Status   OutboxStatus `gorm:"type:varchar(20);index:idx_outbox_due"`
+	Attempts int
+	// 下一次允许分发的时间，分发进行中时用作租约，防止重复分发
+	NextAttemptAt time.Time `gorm:"index:idx_outbox_due"`
+	LastError     string    `gorm:"type:text"`
+	DeliveredAt   *time.Time
+	// 已完成处理的处理器，重试时跳过
+	Deliveries []OutboxDelivery `gorm:"foreignKey:OutboxEventID"`
+	CreatedAt  time.Time        `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time        `gorm:"autoUpdateTime"`
+}
+
+// OutboxDelivery 记录某个处理器已成功处理某条事件
+type OutboxDelivery struct {
+	ID            uint      `gorm:"primaryKey"`
+	OutboxEventID uint      `gorm:"not null;uniqueIndex:idx_outbox_handler"`
+	Handler       string    `gorm:"size:50;not null;uniqueIndex:idx_outbox_handler"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+}
+
+// EventEnvelope 事件的 JSON 结构，Webhook 推送的也是这一结构
+type EventEnvelope struct {
+	ID         string       `json:"id"`
+	Event      WebhookEvent `json:"event"`
+	OccurredAt time.Time    `json:"occurred_at"`
+	Data       interface{}  `json:"data"`
+}
+
+// NewOutboxEvent 生成待写入发件箱的事件
+func NewOutboxEvent(event WebhookEvent, aggregateType string, aggregateID uint, data interface{}) (*OutboxEvent, error) {
+	now := time.Now()
+	envelope := EventEnvelope{
+		ID:         newEventID(),
+		Event:      event,
+		OccurredAt: now,
+		Data:       data,
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, err
+	}
+	return &OutboxEvent{
+		EventID:       envelope.ID,
+		Event:         event,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       string(payload),
+		Status:        OutboxPending,
+		NextAttemptAt: now,
+	}, nil
+}
+
+// DecodeData 将事件内容解析到 data
+func (e *OutboxEvent) DecodeData(data interface{}) error {
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(e.Payload), &envelope); err != nil {
+		return err
+	}
+	return json.Unmarshal(envelope.Data, data)
+}
+
+func newEventID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand 失败时退化为时间戳，仅影响标识的随机性
+		return "evt_" + strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return "evt_" + hex.EncodeToString(b)
+}
+
+// ParkingEventData 入场、出场事件内容
+type ParkingEventData struct {
+	RecordID  uint       `json:"record_id"`
+	SpotID    uint       `json:"spot_id"`
+	License   string     `json:"license"`
+	UserID    *uint      `json:"user_id,omitempty"`
+	EntryTime time.Time  `json:"entry_time"`
+	ExitTime  *time.Time `json:"exit_time,omitempty"`
+	TotalCost float64    `json:"total_cost,omitempty"`
+}
+
+// LeaseEventData 租赁事件内容
+type LeaseEventData struct {
+	LeaseID    uint        `json:"lease_id"`
+	UserID     uint        `json:"user_id"`
+	SpotID     uint        `json:"spot_id"`
+	OwnerID    uint        `json:"owner_id"`
+	StartDate  time.Time   `json:"start_date"`
+	EndDate    time.Time   `json:"end_date"`
+	TotalPrice float64     `json:"total_price"`
+	Status     LeaseStatus `json:"status"`
+}
+
+// SpotStatusEventData 车位状态变更事件内容
+type SpotStatusEventData struct {
+	SpotID uint   `json:"spot_id"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Notes  string `json:"notes,omitempty"`
+}
+
+func NewParkingEventData(record *ParkingRecord) ParkingEventData {
+	return ParkingEventData{
+		RecordID:  record.ID,
+		SpotID:    record.SpotID,
+		License:   record.License,
+		UserID:    record.UserID,
+		EntryTime: record.EntryTime,
+		ExitTime:  record.ExitTime,
+		TotalCost: record.TotalCost,
+	}
+}
+
+func NewLeaseEventData(lease *LeaseOrder) LeaseEventData {
+	return LeaseEventData{
+		LeaseID:    lease.ID,
+		UserID:     lease.UserID,
+		SpotID:     lease.SpotID,
+		OwnerID:    lease.OwnerID,
+		StartDate:  lease.StartDate,
+		EndDate:    lease.EndDate,
+		TotalPrice: lease.TotalPrice,
+		Status:     lease.Status,
+	}
+}
@@ -21,6 +21,9 @@ const (
 	Faulty   ParkingStatus = "faulty"
 )
 
+// SpotTimeLayout 车位表中字符串时间字段的格式，按字典序比较即按时间先后
+const SpotTimeLayout = "2006-01-02 15:04:05"
+
 // BindParkingRequest 管理员绑定车位给用户的请求结构体
 type BindParkingRequest struct {
 	UserID    uint `json:"user_id" binding:"required"`
@@ -80,6 +83,10 @@ type ParkingRecord struct {
 	IsCompleted bool `gorm:"default:false"`
 	// 车辆ID
 	VehicleID *uint // 添加关联车辆ID
+	// 计费所用的资费版本
+	TariffID *uint `gorm:"index"`
+	// 结算时的计费明细（tariff.Quote 的 JSON），计费说明以此为准，不受之后车位费率调整影响
+	FeeQuote JSONBytes `gorm:"type:json"`
 }
 
 type UnbindParkingRequest struct {
@@ -97,3 +104,16 @@ type ParkingBindUserResponse struct {
 type BindParkingResponse struct {
 	Message string `json:"message"`
 }
+
+// SpotRecoveryPolicy 故障车位自动恢复策略，未配置策略的车位不会自动恢复
+type SpotRecoveryPolicy struct {
+	ID     uint `gorm:"primaryKey"`
+	SpotID uint `gorm:"uniqueIndex;not null"`
+	// 是否允许自动恢复
+	AutoRecover bool `gorm:"default:false"`
+	// 故障持续多少小时后自动恢复
+	AfterHours int `gorm:"default:24"`
+	// 最后修改策略的管理员
+	UpdatedBy uint
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
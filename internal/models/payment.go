@@ -0,0 +1,81 @@
+// internal/models/payment.go
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+type PaymentStatus string
+
+const (
+	PaymentPending PaymentStatus = "pending"
+	// 已被某次支付请求占用，正在向网关扣款
+	PaymentProcessing PaymentStatus = "processing"
+	PaymentSucceeded  PaymentStatus = "succeeded"
+	PaymentFailed     PaymentStatus = "failed"
+	PaymentRefunded   PaymentStatus = "refunded"
+)
+
+// PaymentPurpose 支付用途，与 ReferenceID 一起定位被支付的业务单据
+type PaymentPurpose string
+
+const (
+	PaymentForLease   PaymentPurpose = "lease"
+	PaymentForParking PaymentPurpose = "parking"
+)
+
+var (
+	ErrPaymentNotFound   = errors.New("支付单不存在")
+	ErrPaymentNotPending = errors.New("支付单不是待支付状态")
+	ErrPaymentProcessing = errors.New("支付单正在处理中，请稍后查询支付结果")
+	ErrRefundNotFound    = errors.New("退款申请不存在")
+	ErrRefundNotPending  = errors.New("退款申请已处理")
+)
+
+// Payment 支付单，每次向支付网关发起的收款对应一条记录
+type Payment struct {
+	ID          uint           `gorm:"primaryKey"`
+	UserID      uint           `gorm:"index"`
+	Purpose     PaymentPurpose `gorm:"type:varchar(20);not null;index:idx_payment_reference"`
+	ReferenceID uint           `gorm:"not null;index:idx_payment_reference"`
+	Amount      float64        `gorm:"type:decimal(10,2)"`
+	Currency    string         `gorm:"type:varchar(3);default:'CNY'"`
+	Description string         `gorm:"size:255"`
+	// 支付网关名称及网关侧的支付意图ID
+	Provider      string        `gorm:"type:varchar(20)"`
+	ProviderRef   string        `gorm:"type:varchar(100);index"`
+	Status        PaymentStatus `gorm:"type:varchar(20);default:'pending';index"`
+	FailureReason string        `gorm:"size:255"`
+	// 已退款金额，部分退款时支付单仍标记为 refunded
+	RefundedAmount float64 `gorm:"type:decimal(10,2);default:0"`
+	PaidAt         *time.Time
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
+}
+
+type RefundStatus string
+
+const (
+	RefundPending  RefundStatus = "pending"
+	RefundApproved RefundStatus = "approved"
+	RefundRejected RefundStatus = "rejected"
+)
+
+// Refund 退款申请，由管理员审核通过后才会向支付网关发起退款
+type Refund struct {
+	ID          uint           `gorm:"primaryKey"`
+	PaymentID   uint           `gorm:"not null;index"`
+	UserID      uint           `gorm:"index"`
+	Purpose     PaymentPurpose `gorm:"type:varchar(20)"`
+	ReferenceID uint
+	Amount      float64 `gorm:"type:decimal(10,2)"`
+	// 扣除的违约金
+	Penalty    float64      `gorm:"type:decimal(10,2)"`
+	Reason     string       `gorm:"size:255"`
+	Status     RefundStatus `gorm:"type:varchar(20);default:'pending';index"`
+	ReviewedBy *uint
+	ReviewNote string `gorm:"size:255"`
+	ReviewedAt *time.Time
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
@@ -0,0 +1,119 @@
+// internal/models/permission.go
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Permission 接口权限，按角色授予
+type Permission string
+
+const (
+	PermSpotsRead          Permission = "spots.read"
+	PermSpotsWrite         Permission = "spots.write"
+	PermSpotsAssign        Permission = "spots.assign"
+	PermGatesOperate       Permission = "gates.operate"
+	PermMaintenanceManage  Permission = "maintenance.manage"
+	PermReportsRead        Permission = "reports.read"
+	PermFinanceRead        Permission = "finance.read"
+	PermRefundsApprove     Permission = "refunds.approve"
+	PermTariffsRead        Permission = "tariffs.read"
+	PermTariffsWrite       Permission = "tariffs.write"
+	PermUsersRead          Permission = "users.read"
+	PermUsersWrite         Permission = "users.write"
+	PermRolesManage        Permission = "roles.manage"
+	PermAuditRead          Permission = "audit.read"
+	PermIntegrationsManage Permission = "integrations.manage"
+	PermSigningKeysManage  Permission = "signing_keys.manage"
+	PermOwnerSpots         Permission = "owner.spots"
+	PermListingsManage     Permission = "listings.manage"
+	PermLeasesApprove      Permission = "leases.approve"
+	PermStatementsRead     Permission = "statements.read"
+)
+
+// PermissionInfo 权限说明
+type PermissionInfo struct {
+	Name        Permission
+	Description string
+}
+
+// Permissions 系统支持的全部权限
+var Permissions = []PermissionInfo{
+	{PermSpotsRead, "查看车位状态、恢复策略和绑定关系"},
+	{PermSpotsWrite, "修改车位状态和故障恢复策略"},
+	{PermSpotsAssign, "将车位绑定给用户或解除绑定"},
+	{PermGatesOperate, "出入口值守：登记车辆入场、出场结算，为非注册用户代收停车费，查询停车记录计费明细"},
+	{PermMaintenanceManage, "处理维修工单"},
+	{PermReportsRead, "查看运营统计"},
+	{PermFinanceRead, "查看收入报表"},
+	{PermRefundsApprove, "审核退款"},
+	{PermTariffsRead, "查看资费方案"},
+	{PermTariffsWrite, "创建和启用资费方案"},
+	{PermUsersRead, "查看用户信息"},
+	{PermUsersWrite, "修改用户角色和账户状态，解除锁定，重置密码和两步验证"},
+	{PermRolesManage, "管理角色及其权限"},
+	{PermAuditRead, "查看审计事件"},
+	{PermIntegrationsManage, "管理 Webhook 订阅和发件箱事件"},
+	{PermSigningKeysManage, "查看和轮换 JWT 签名密钥"},
+	{PermOwnerSpots, "业主购买和创建车位"},
+	{PermListingsManage, "业主发布出租信息，查看车位上的租赁和预订"},
+	{PermLeasesApprove, "业主接受或拒绝预订申请"},
+	{PermStatementsRead, "业主查看和导出对账单"},
+}
+
+// Valid 是否为系统支持的权限
+func (p Permission) Valid() bool {
+	for _, info := range Permissions {
+		if info.Name == p {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultRolePermissions 内置角色的初始权限，首次启动时写入数据库，之后可由管理员调整。
+// 管理员拥有全部权限，不在此列出
+var DefaultRolePermissions = map[Role][]Permission{
+	Owner:  {PermOwnerSpots, PermListingsManage, PermLeasesApprove, PermStatementsRead},
+	Renter: {},
+	Operator: {
+		PermSpotsRead, PermSpotsWrite, PermGatesOperate,
+		PermMaintenanceManage, PermReportsRead, PermTariffsRead,
+	},
+}
+
+// BuiltInRoleDescriptions 内置角色说明
+var BuiltInRoleDescriptions = map[Role]string{
+	Admin:    "管理员，拥有全部权限",
+	Owner:    "业主",
+	Renter:   "租户",
+	Operator: "运营人员，负责出入口和车位状态，不能查看财务数据",
+}
+
+var (
+	ErrRoleNotFound      = errors.New("角色不存在")
+	ErrRoleExists        = errors.New("角色已存在")
+	ErrBuiltInRole       = errors.New("内置角色不能删除，管理员角色的权限不能修改")
+	ErrRoleInUse         = errors.New("角色仍有用户使用，不能删除")
+	ErrOwnRole           = errors.New("不能修改自己拥有的角色的权限")
+	ErrInvalidPermission = errors.New("无效的权限")
+)
+
+// RoleDefinition 角色定义，用户通过 User.Roles 中的角色名获得其权限
+type RoleDefinition struct {
+	ID          uint   `gorm:"primaryKey"`
+	Name        Role   `gorm:"size:32;not null;uniqueIndex"`
+	Description string `gorm:"size:255"`
+	// 内置角色不能删除
+	BuiltIn   bool
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// RolePermission 角色拥有的权限
+type RolePermission struct {
+	ID         uint       `gorm:"primaryKey"`
+	Role       Role       `gorm:"size:32;not null;uniqueIndex:idx_role_permission"`
+	Permission Permission `gorm:"size:64;not null;uniqueIndex:idx_role_permission"`
+}
@@ -2,17 +2,54 @@
 package models
 
 import (
+	"errors"
 	"time"
 )
 
+// DailyReport 每日停车收入与车位使用快照，每个日期一行
 type DailyReport struct {
-	Date         time.Time `gorm:"type:date"`
+	Date         time.Time `gorm:"type:date;uniqueIndex"`
 	TotalIncome  float64   `gorm:"type:decimal(10,2)"`
 	TemporaryCnt int
 	ShortTermCnt int
 	PermanentCnt int
+	// 快照生成时间
+	UpdatedAt time.Time
 }
 
+// RevenueRow 某天某车位某类收入的汇总，用于生成收入报表
+type RevenueRow struct {
+	Date     time.Time
+	Source   string // parking 停车费 / lease 租金
+	SpotID   uint
+	OwnerID  uint
+	SpotType string
+	Amount   float64
+	Count    int
+}
+
+// 维修工单状态，resolved 之前均视为未关闭
+const (
+	MaintenancePending  = "pending"  // 已上报，待分诊
+	MaintenanceTriaged  = "triaged"  // 已分诊
+	MaintenanceAssigned = "assigned" // 已指派维修人员
+	MaintenanceResolved = "resolved"
+)
+
+// 维修工单优先级
+const (
+	MaintenanceLow    = "low"
+	MaintenanceNormal = "normal"
+	MaintenanceHigh   = "high"
+	MaintenanceUrgent = "urgent"
+)
+
+var (
+	ErrMaintenanceNotFound = errors.New("维修工单不存在")
+	// 报表的统计粒度、分组维度或日期范围无效
+	ErrInvalidReportQuery = errors.New("无效的报表查询参数")
+)
+
 type MaintenanceRecord struct {
 	ID          uint      `gorm:"primaryKey"`
 	SpotID      uint      `gorm:"not null"`
@@ -21,4 +58,23 @@ type MaintenanceRecord struct {
 	Status      string    `gorm:"type:varchar(20);default:'pending'"`
 	CreatedAt   time.Time `gorm:"autoCreateTime"`
 	ResolvedAt  *time.Time
+	Priority    string `gorm:"type:varchar(20);default:'normal'"`
+	// 指派的维修人员
+	AssigneeID *uint
+	// 处理结果说明
+	Resolution string               `gorm:"type:text"`
+	UpdatedAt  time.Time            `gorm:"autoUpdateTime"`
+	History    []MaintenanceHistory `gorm:"foreignKey:RecordID"`
+}
+
+// MaintenanceHistory 维修工单的每一次状态变更
+type MaintenanceHistory struct {
+	ID         uint   `gorm:"primaryKey"`
+	RecordID   uint   `gorm:"not null;index"`
+	FromStatus string `gorm:"type:varchar(20)"`
+	ToStatus   string `gorm:"type:varchar(20)"`
+	// 操作人，系统自动处理时为 0
+	ActorID   uint
+	Note      string    `gorm:"type:text"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
 }
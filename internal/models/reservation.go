@@ -0,0 +1,39 @@
+// internal/models/reservation.go
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+type ReservationStatus string
+
+const (
+	ReservationBooked    ReservationStatus = "booked"
+	ReservationFulfilled ReservationStatus = "fulfilled"
+	ReservationCancelled ReservationStatus = "cancelled"
+	ReservationExpired   ReservationStatus = "expired"
+)
+
+var (
+	ErrReservationNotFound = errors.New("预约不存在")
+	ErrReservationConflict = errors.New("所选时段没有可预约的车位")
+)
+
+// Reservation 车位预约，预约时即分配具体车位，同一车位的有效预约时段不可重叠
+type Reservation struct {
+	ID     uint `gorm:"primaryKey"`
+	UserID uint `gorm:"not null;index"`
+	// 分配的车位
+	SpotID   uint        `gorm:"not null;index"`
+	SpotType ParkingType `gorm:"type:varchar(20)"`
+	// 预约车辆车牌号，入场时据此匹配预约
+	License   string            `gorm:"type:varchar(20);not null;index"`
+	StartTime time.Time         `gorm:"not null"`
+	EndTime   time.Time         `gorm:"not null"`
+	Status    ReservationStatus `gorm:"type:varchar(20);default:'booked';index"`
+	// 履约后对应的停车记录
+	RecordID  *uint
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
@@ -0,0 +1,36 @@
+// internal/models/signing_key.go
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// 支持的 JWT 签名算法
+const (
+	SigningAlgRS256 = "RS256"
+	SigningAlgEdDSA = "EdDSA"
+)
+
+var ErrSigningKeyNotFound = errors.New("签名密钥不存在")
+
+// SigningKey JWT 签名密钥。RetiredAt 为空的最新密钥用于签发；
+// 轮换后旧密钥不再签发，但在 ExpiresAt 之前仍通过 JWKS 发布，用于验证它签发的令牌
+type SigningKey struct {
+	ID        uint   `gorm:"primaryKey"`
+	KID       string `gorm:"column:kid;size:64;not null;uniqueIndex"`
+	Algorithm string `gorm:"size:16;not null"`
+	// PKCS#8 私钥，使用 jwt.key_encryption_key 加密保存
+	PrivateKey string `gorm:"type:text;not null"`
+	// PKIX 公钥（PEM）
+	PublicKey string `gorm:"type:text;not null"`
+	RetiredAt *time.Time
+	// 为空表示仍在使用，过期后从 JWKS 中移除并删除
+	ExpiresAt *time.Time `gorm:"index"`
+	CreatedAt time.Time  `gorm:"autoCreateTime"`
+}
+
+// Active 是否用于签发新令牌
+func (k *SigningKey) Active() bool {
+	return k.RetiredAt == nil
+}
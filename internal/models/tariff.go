@@ -0,0 +1,34 @@
+// internal/models/tariff.go
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+type TariffStatus string
+
+const (
+	TariffDraft    TariffStatus = "draft"
+	TariffActive   TariffStatus = "active"
+	TariffArchived TariffStatus = "archived"
+)
+
+var (
+	ErrTariffNotFound = errors.New("资费不存在")
+	ErrTariffArchived = errors.New("资费已归档，无法重新启用")
+)
+
+// Tariff 资费版本，同名资费每次修改生成一个新版本，同一时间只有一个版本生效
+type Tariff struct {
+	ID          uint         `gorm:"primaryKey"`
+	Name        string       `gorm:"size:100;not null;uniqueIndex:idx_tariff_name_version"`
+	Version     int          `gorm:"not null;uniqueIndex:idx_tariff_name_version"`
+	Description string       `gorm:"type:text"`
+	Status      TariffStatus `gorm:"type:varchar(20);default:'draft';index"`
+	// 计费规则集（tariff.Schedule 的 JSON）
+	Rules       JSONBytes `gorm:"type:json"`
+	CreatedBy   uint
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	ActivatedAt *time.Time
+}
@@ -0,0 +1,70 @@
+// internal/models/token.go
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("刷新令牌无效或已过期")
+	ErrRefreshTokenReused  = errors.New("刷新令牌已被使用，该登录会话已失效，请重新登录")
+	ErrTokenRevoked        = errors.New("令牌已失效")
+	// 令牌缺少 jti，无法撤销，视为无效
+	ErrTokenMissingID = errors.New("令牌缺少标识")
+
+	ErrInvalidUserToken     = errors.New("链接无效或已过期")
+	ErrEmailNotVerified     = errors.New("邮箱尚未验证，请先完成邮箱验证")
+	ErrEmailAlreadyVerified = errors.New("邮箱已验证")
+	ErrWeakPassword         = errors.New("密码长度不能少于 8 位")
+)
+
+// MinPasswordLength 新密码的最短长度
+const MinPasswordLength = 8
+
+// RefreshToken 刷新令牌，仅保存 SHA-256 摘要。
+// 同一次登录后轮换出的令牌属于同一个 FamilyID，已使用的令牌再次出现时整族撤销
+type RefreshToken struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	FamilyID  string `gorm:"size:64;not null;index"`
+	TokenHash string `gorm:"size:64;not null;uniqueIndex"`
+	// 轮换前的上一个令牌
+	ParentID  *uint
+	ExpiresAt time.Time `gorm:"index"`
+	// 已轮换（使用）的时间
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// RevokedToken 已撤销的访问令牌，过期后可以清理
+type RevokedToken struct {
+	JTI       string `gorm:"primaryKey;size:64"`
+	UserID    uint
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// UserTokenPurpose 一次性令牌的用途
+type UserTokenPurpose string
+
+const (
+	TokenPasswordReset     UserTokenPurpose = "password_reset"
+	TokenEmailVerification UserTokenPurpose = "email_verification"
+	// 密码校验通过后、两步验证完成前的登录挑战
+	TokenLoginChallenge UserTokenPurpose = "login_challenge"
+)
+
+// UserToken 一次性令牌（邮件链接、登录挑战），仅保存 SHA-256 摘要
+type UserToken struct {
+	ID        uint             `gorm:"primaryKey"`
+	UserID    uint             `gorm:"not null;index"`
+	Purpose   UserTokenPurpose `gorm:"type:varchar(30);not null"`
+	TokenHash string           `gorm:"size:64;not null;uniqueIndex"`
+	// 令牌发往的邮箱，验证通过后写入用户信息
+	Email     string    `gorm:"size:100"`
+	ExpiresAt time.Time `gorm:"index"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
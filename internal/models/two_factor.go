@@ -0,0 +1,43 @@
+// internal/models/two_factor.go
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrTwoFactorNotEnabled     = errors.New("尚未启用两步验证")
+	ErrTwoFactorAlreadyEnabled = errors.New("已启用两步验证")
+	ErrTwoFactorSetupRequired  = errors.New("请先生成两步验证密钥")
+	ErrTwoFactorMandatory      = errors.New("管理员账户必须启用两步验证")
+	ErrInvalidOTP              = errors.New("验证码错误")
+	ErrInvalidChallenge        = errors.New("登录验证已失效，请重新登录")
+)
+
+// UserTwoFactor 用户的 TOTP 两步验证配置，EnabledAt 为空表示已生成密钥但尚未确认启用
+type UserTwoFactor struct {
+	ID     uint `gorm:"primaryKey"`
+	UserID uint `gorm:"not null;uniqueIndex"`
+	// 加密保存的 Base32 密钥
+	Secret    string `gorm:"size:255;not null"`
+	EnabledAt *time.Time
+	// 最近一次验证通过的时间步，同一步内的验证码不能重复使用
+	LastUsedStep int64
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime"`
+}
+
+// Enabled 是否已启用
+func (t *UserTwoFactor) Enabled() bool {
+	return t != nil && t.EnabledAt != nil
+}
+
+// RecoveryCode 两步验证的一次性恢复码，仅保存 SHA-256 摘要
+type RecoveryCode struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	CodeHash  string `gorm:"size:64;not null;index"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
@@ -15,11 +15,27 @@ const (
 	Admin  Role = "admin"
 	Owner  Role = "owner"
 	Renter Role = "renter"
+	// Operator 运营人员：值守出入口、维护车位状态，不能查看财务数据
+	Operator Role = "operator"
 )
 
 var (
 	ErrUserNotFound    = errors.New("用户不存在")
 	ErrParkingNotFound = errors.New("车位不存在")
+	ErrUserExists      = errors.New("用户名或邮箱已存在")
+	ErrInvalidEmail    = errors.New("邮箱格式不正确")
+	ErrInvalidRole     = errors.New("无效的角色")
+	// 管理员不能停用自己或移除自己的管理员角色，避免系统失去管理员
+	ErrSelfModification = errors.New("不能停用自己的账户或移除自己的管理员角色")
+	// 授予或移除管理员角色、修改自己的角色、管理管理员账户都需要操作人是管理员
+	ErrAdminRequired     = errors.New("该操作需要管理员角色")
+	ErrIncorrectPassword = errors.New("当前密码错误")
+	ErrEmailInUse        = errors.New("该邮箱已被其他账户使用")
+	ErrInvalidPhone      = errors.New("手机号格式不正确")
+	ErrInvalidLanguage   = errors.New("不支持的语言")
+	// 注销前需要结清的事项：进行中的租赁、停车、预约、预订申请，以及名下车位
+	ErrAccountHasObligations = errors.New("存在进行中的租赁、停车、预约或名下车位，暂不能注销账户")
+	ErrAdminAccountDeletion  = errors.New("管理员账户不能注销")
 )
 
 type JSONBytes []byte
@@ -28,10 +44,17 @@ func (r JSONBytes) Unmarshal(dst *[]Role) error {
 	return json.Unmarshal(r, dst)
 }
 
+// MarshalRoles 将角色列表序列化为 JSON 列
+func MarshalRoles(roles []Role) (JSONBytes, error) {
+	if roles == nil {
+		roles = []Role{}
+	}
+	return json.Marshal(roles)
+}
+
 type AdminUserInfoResponse struct {
 	ID           uint           `json:"id"`
 	Email        string         `json:"email"`
-	Password     string         `json:"password"`
 	ParkingSpots []*ParkingSpot `json:"parking_spots"`
 }
 
@@ -45,6 +68,17 @@ type User struct {
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
 	IsActive  bool      `gorm:"default:true"` // 新增用户活跃状态字段
+	// 通知语言：zh / en
+	Language string `gorm:"size:10;default:'zh'"`
+	// 邮箱验证时间，为空表示尚未验证
+	EmailVerifiedAt *time.Time
+	// 账户注销时间，注销后个人信息已匿名化，停车和租赁记录保留
+	ClosedAt *time.Time
+}
+
+// EmailVerified 邮箱是否已验证
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
 }
 
 type AdminLoginRequest struct {
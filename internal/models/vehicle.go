@@ -1,7 +1,12 @@
 // internal/models/vehicle.go
 package models
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+var ErrVehicleNotFound = errors.New("车辆不存在")
 
 type Vehicle struct {
 	ID           uint   `gorm:"primaryKey"`
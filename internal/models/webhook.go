@@ -0,0 +1,85 @@
+// internal/models/webhook.go
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// WebhookEvent 对外推送的事件类型
+type WebhookEvent string
+
+const (
+	WebhookParkingEntry      WebhookEvent = "parking.entry"
+	WebhookParkingExit       WebhookEvent = "parking.exit"
+	WebhookLeaseCreated      WebhookEvent = "lease.created"
+	WebhookSpotStatusChanged WebhookEvent = "spot.status_changed"
+)
+
+// WebhookEvents 全部可订阅的事件类型
+var WebhookEvents = []WebhookEvent{
+	WebhookParkingEntry,
+	WebhookParkingExit,
+	WebhookLeaseCreated,
+	WebhookSpotStatusChanged,
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending" // 等待首次投递或重试
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed" // 重试次数用尽
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("Webhook 订阅不存在")
+	ErrDeliveryNotFound = errors.New("投递记录不存在")
+)
+
+// WebhookSubscription Webhook 订阅
+type WebhookSubscription struct {
+	ID  uint   `gorm:"primaryKey"`
+	URL string `gorm:"size:500;not null"`
+	// 签名密钥，用于计算 HMAC-SHA256 签名
+	Secret string `gorm:"size:100;not null"`
+	// 订阅的事件类型，逗号分隔，为空表示订阅全部事件
+	Events      string `gorm:"size:255"`
+	Description string `gorm:"size:255"`
+	Active      bool   `gorm:"default:true"`
+	CreatedBy   uint
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+}
+
+// WebhookDelivery 一次事件对一个订阅的投递
+type WebhookDelivery struct {
+	ID             uint           `gorm:"primaryKey"`
+	SubscriptionID uint           `gorm:"not null;index"`
+	EventID        string         `gorm:"size:64;index"`
+	Event          WebhookEvent   `gorm:"type:varchar(50)"`
+	Payload        string         `gorm:"type:text"`
+	Status         DeliveryStatus `gorm:"type:varchar(20);index:idx_delivery_due"`
+	Attempts       int
+	// 下一次允许投递的时间，投递进行中时用作租约，防止重复投递
+	NextAttemptAt  time.Time `gorm:"index:idx_delivery_due"`
+	LastStatusCode int
+	LastError      string           `gorm:"type:text"`
+	AttemptLogs    []WebhookAttempt `gorm:"foreignKey:DeliveryID"`
+	CreatedAt      time.Time        `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time        `gorm:"autoUpdateTime"`
+}
+
+// WebhookAttempt 每一次投递尝试的记录
+type WebhookAttempt struct {
+	ID         uint `gorm:"primaryKey"`
+	DeliveryID uint `gorm:"not null;index"`
+	// 第几次尝试，从 1 开始
+	Attempt    int
+	StatusCode int
+	Error      string `gorm:"type:text"`
+	// 响应体前 1KB，便于排查
+	Response   string `gorm:"type:text"`
+	DurationMs int64
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
@@ -0,0 +1,71 @@
+// internal/repositories/audit_repo.go
+package repositories
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"modules/internal/models"
+	"time"
+)
+
+type AuditRepository interface {
+	CreateEvent(ctx context.Context, event *models.AuditEvent) error
+	ListEvents(ctx context.Context, filter AuditFilter) ([]*models.AuditEvent, int64, error)
+}
+
+type AuditFilter struct {
+	ActorID    *uint
+	Action     string
+	TargetType string
+	TargetID   uint
+	From       *time.Time
+	To         *time.Time
+	Offset     int
+	Limit      int
+}
+
+type auditRepo struct {
+	db *gorm.DB
+}
+
+func NewAuditRepo(db *gorm.DB) AuditRepository {
+	return &auditRepo{db: db}
+}
+
+func (r *auditRepo) CreateEvent(ctx context.Context, event *models.AuditEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+func (r *auditRepo) ListEvents(ctx context.Context, filter AuditFilter) ([]*models.AuditEvent, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.AuditEvent{})
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != 0 {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Limit > 0 {
+		query = query.Offset(filter.Offset).Limit(filter.Limit)
+	}
+	var events []*models.AuditEvent
+	err := query.Order("created_at DESC, id DESC").Find(&events).Error
+	return events, total, err
+}
@@ -3,24 +3,64 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"modules/internal/models"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type LeaseTx interface {
+	// LockSpot 锁定车位行直到事务结束，同一车位的下单依次检查时段冲突
+	LockSpot(ctx context.Context, spotID uint) error
+	// HasOverlappingLease 检查车位在指定时段内是否存在待支付或生效中的订单
+	HasOverlappingLease(ctx context.Context, spotID uint, start, end time.Time) (bool, error)
 	CreateLease(ctx context.Context, lease *models.LeaseOrder) error
+	// CloseLease 仅当订单仍处于 from 状态时写入 lease 的状态和终止时间，返回是否更新成功
+	CloseLease(ctx context.Context, lease *models.LeaseOrder, from models.LeaseStatus) (bool, error)
+	// CreateRefund 创建退款申请，与订单状态变更一同提交
+	CreateRefund(ctx context.Context, refund *models.Refund) error
+	// AddOutboxEvent 写入发件箱事件，与事务内的其他变更一同提交
+	AddOutboxEvent(ctx context.Context, event *models.OutboxEvent) error
+	// AcceptBooking 仅当预订申请仍待处理时标记为已接受并关联订单，返回是否更新成功
+	AcceptBooking(ctx context.Context, booking *models.BookingRequest) (bool, error)
 }
 
 type LeaseRepository interface {
 	CreateLease(ctx context.Context, lease *models.LeaseOrder) error
+	GetLeaseByID(ctx context.Context, id uint) (*models.LeaseOrder, error)
 	GetUserLeases(ctx context.Context, userID uint, status models.LeaseStatus) ([]*models.LeaseOrder, error)
+	// ListLeases 按条件分页查询租赁订单，同时返回总数
+	ListLeases(ctx context.Context, filter LeaseFilter) ([]*models.LeaseOrder, int64, error)
 	UpdateLeaseStatus(ctx context.Context, leaseID uint, status models.LeaseStatus) error
 	GetExpiringLeases(ctx context.Context, before time.Time) ([]*models.LeaseOrder, error)
+	// SetAutoRenew 仅修改待支付或生效中订单的自动续租设置，返回是否更新成功
+	SetAutoRenew(ctx context.Context, leaseID uint, autoRenew bool) (bool, error)
+	// GetStalePendingLeases 查询创建时间早于 createdBefore 仍未支付的订单
+	GetStalePendingLeases(ctx context.Context, createdBefore time.Time) ([]*models.LeaseOrder, error)
+	// CancelPendingLease 仅当订单仍待支付时标记为已取消，返回是否更新成功
+	CancelPendingLease(ctx context.Context, leaseID uint, at time.Time) (bool, error)
+	// GetLeasesEndingBetween 查询在 (from, to] 区间内到期的生效订单
+	GetLeasesEndingBetween(ctx context.Context, from, to time.Time) ([]*models.LeaseOrder, error)
+	// CreateReminder 登记到期提醒，已登记过时返回 false
+	CreateReminder(ctx context.Context, reminder *models.LeaseReminder) (bool, error)
+	GetAutoRenewLeases(ctx context.Context, userID uint, before time.Time) ([]*models.LeaseOrder, error)
+	HasOverlappingLease(ctx context.Context, spotID, excludeLeaseID uint, start, end time.Time) (bool, error)
+	CreateRenewal(ctx context.Context, renewal *models.LeaseRenewal) error
+	GetRenewals(ctx context.Context, leaseID uint) ([]*models.LeaseRenewal, error)
 	Transaction(ctx context.Context, fn func(tx LeaseTx) error) error
 }
 
+// LeaseFilter 租赁订单查询条件，OwnerID 按车位当前业主过滤
+type LeaseFilter struct {
+	UserID  uint
+	OwnerID uint
+	Status  models.LeaseStatus
+	Offset  int
+	Limit   int
+}
+
 type leaseRepo struct {
 	db *gorm.DB
 }
@@ -46,6 +86,15 @@ func (r *leaseRepo) CreateLease(ctx context.Context, lease *models.LeaseOrder) e
 	})
 }
 
+func (r *leaseRepo) GetLeaseByID(ctx context.Context, id uint) (*models.LeaseOrder, error) {
+	var lease models.LeaseOrder
+	err := r.db.WithContext(ctx).First(&lease, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrLeaseNotFound
+	}
+	return &lease, err
+}
+
 func (r *leaseRepo) GetUserLeases(ctx context.Context, userID uint, status models.LeaseStatus) ([]*models.LeaseOrder, error) {
 	var leases []*models.LeaseOrder
 	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
@@ -56,6 +105,35 @@ func (r *leaseRepo) GetUserLeases(ctx context.Context, userID uint, status model
 	return leases, err
 }
 
+func (r *leaseRepo) ListLeases(ctx context.Context, filter LeaseFilter) ([]*models.LeaseOrder, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.LeaseOrder{})
+	if filter.UserID != 0 {
+		query = query.Where("lease_orders.user_id = ?", filter.UserID)
+	}
+	if filter.OwnerID != 0 {
+		query = query.
+			Joins("JOIN parking_spots ON parking_spots.id = lease_orders.spot_id").
+			Where("parking_spots.owner_id = ?", filter.OwnerID)
+	}
+	if filter.Status != "" {
+		query = query.Where("lease_orders.status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var leases []*models.LeaseOrder
+	if filter.Limit > 0 {
+		query = query.Offset(filter.Offset).Limit(filter.Limit)
+	}
+	err := query.Select("lease_orders.*").
+		Order("lease_orders.created_at DESC").
+		Find(&leases).Error
+	return leases, total, err
+}
+
 func (r *leaseRepo) UpdateLeaseStatus(ctx context.Context, leaseID uint, status models.LeaseStatus) error {
 	return r.db.WithContext(ctx).
 		Model(&models.LeaseOrder{}).
@@ -64,6 +142,45 @@ func (r *leaseRepo) UpdateLeaseStatus(ctx context.Context, leaseID uint, status
 		Error
 }
 
+func (r *leaseRepo) SetAutoRenew(ctx context.Context, leaseID uint, autoRenew bool) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.LeaseOrder{}).
+		Where("id = ? AND status IN ?", leaseID, []models.LeaseStatus{models.LeasePending, models.LeaseActive}).
+		Update("auto_renew", autoRenew)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return true, nil
+	}
+	// 设置未变化时 MySQL 不计入受影响行数，需要再确认订单状态
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.LeaseOrder{}).
+		Where("id = ? AND status IN ?", leaseID, []models.LeaseStatus{models.LeasePending, models.LeaseActive}).
+		Count(&count).Error
+	return count == 1, err
+}
+
+func (r *leaseRepo) GetStalePendingLeases(ctx context.Context, createdBefore time.Time) ([]*models.LeaseOrder, error) {
+	var leases []*models.LeaseOrder
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND created_at < ?", models.LeasePending, createdBefore).
+		Find(&leases).Error
+	return leases, err
+}
+
+func (r *leaseRepo) CancelPendingLease(ctx context.Context, leaseID uint, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.LeaseOrder{}).
+		Where("id = ? AND status = ?", leaseID, models.LeasePending).
+		Updates(map[string]interface{}{
+			"status":        models.LeaseCancelled,
+			"terminated_at": at,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
 func (r *leaseRepo) GetExpiringLeases(ctx context.Context, before time.Time) ([]*models.LeaseOrder, error) {
 	var leases []*models.LeaseOrder
 	err := r.db.WithContext(ctx).
@@ -72,6 +189,57 @@ func (r *leaseRepo) GetExpiringLeases(ctx context.Context, before time.Time) ([]
 	return leases, err
 }
 
+func (r *leaseRepo) GetLeasesEndingBetween(ctx context.Context, from, to time.Time) ([]*models.LeaseOrder, error) {
+	var leases []*models.LeaseOrder
+	err := r.db.WithContext(ctx).
+		Where("end_date > ? AND end_date <= ? AND status = ?", from, to, models.LeaseActive).
+		Order("end_date ASC").
+		Find(&leases).Error
+	return leases, err
+}
+
+func (r *leaseRepo) CreateReminder(ctx context.Context, reminder *models.LeaseReminder) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(reminder)
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetAutoRenewLeases 查询用户在指定时间前到期、开启了自动续租的生效订单
+func (r *leaseRepo) GetAutoRenewLeases(ctx context.Context, userID uint, before time.Time) ([]*models.LeaseOrder, error) {
+	var leases []*models.LeaseOrder
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND status = ? AND auto_renew = ? AND end_date < ?",
+			userID, models.LeaseActive, true, before).
+		Order("end_date ASC").
+		Find(&leases).Error
+	return leases, err
+}
+
+// HasOverlappingLease 检查车位在指定时段内是否存在其他待支付或生效中的订单
+func (r *leaseRepo) HasOverlappingLease(ctx context.Context, spotID, excludeLeaseID uint, start, end time.Time) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.LeaseOrder{}).
+		Where("spot_id = ? AND id <> ? AND status IN ? AND start_date < ? AND end_date > ?",
+			spotID, excludeLeaseID, []models.LeaseStatus{models.LeasePending, models.LeaseActive}, end, start).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *leaseRepo) CreateRenewal(ctx context.Context, renewal *models.LeaseRenewal) error {
+	return r.db.WithContext(ctx).Create(renewal).Error
+}
+
+func (r *leaseRepo) GetRenewals(ctx context.Context, leaseID uint) ([]*models.LeaseRenewal, error) {
+	var renewals []*models.LeaseRenewal
+	err := r.db.WithContext(ctx).
+		Where("lease_id = ?", leaseID).
+		Order("created_at DESC").
+		Find(&renewals).Error
+	return renewals, err
+}
+
 // Transaction 实现事务方法
 func (r *leaseRepo) Transaction(ctx context.Context, fn func(tx LeaseTx) error) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
@@ -85,7 +253,61 @@ type leaseTxRepo struct {
 	db *gorm.DB
 }
 
+// LockSpot 实现 LeaseTx 接口的 LockSpot 方法
+func (t *leaseTxRepo) LockSpot(ctx context.Context, spotID uint) error {
+	var spot models.ParkingSpot
+	err := t.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		First(&spot, spotID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.ErrParkingSpotNotFound
+	}
+	return err
+}
+
+// HasOverlappingLease 实现 LeaseTx 接口的 HasOverlappingLease 方法
+func (t *leaseTxRepo) HasOverlappingLease(ctx context.Context, spotID uint, start, end time.Time) (bool, error) {
+	return (&leaseRepo{db: t.db}).HasOverlappingLease(ctx, spotID, 0, start, end)
+}
+
 // CreateLease 实现 LeaseTx 接口的 CreateLease 方法
 func (t *leaseTxRepo) CreateLease(ctx context.Context, lease *models.LeaseOrder) error {
 	return t.db.WithContext(ctx).Create(lease).Error
 }
+
+// CloseLease 实现 LeaseTx 接口的 CloseLease 方法
+func (t *leaseTxRepo) CloseLease(ctx context.Context, lease *models.LeaseOrder, from models.LeaseStatus) (bool, error) {
+	result := t.db.WithContext(ctx).
+		Model(&models.LeaseOrder{}).
+		Where("id = ? AND status = ?", lease.ID, from).
+		Updates(map[string]interface{}{
+			"status":        lease.Status,
+			"terminated_at": lease.TerminatedAt,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// CreateRefund 实现 LeaseTx 接口的 CreateRefund 方法
+func (t *leaseTxRepo) CreateRefund(ctx context.Context, refund *models.Refund) error {
+	return t.db.WithContext(ctx).Create(refund).Error
+}
+
+// AcceptBooking 实现 LeaseTx 接口的 AcceptBooking 方法
+func (t *leaseTxRepo) AcceptBooking(ctx context.Context, booking *models.BookingRequest) (bool, error) {
+	result := t.db.WithContext(ctx).
+		Model(&models.BookingRequest{}).
+		Where("id = ? AND status = ?", booking.ID, models.BookingPending).
+		Updates(map[string]interface{}{
+			"status":        models.BookingAccepted,
+			"decision_note": booking.DecisionNote,
+			"decided_at":    booking.DecidedAt,
+			"lease_id":      booking.LeaseID,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// AddOutboxEvent 实现 LeaseTx 接口的 AddOutboxEvent 方法
+func (t *leaseTxRepo) AddOutboxEvent(ctx context.Context, event *models.OutboxEvent) error {
+	return t.db.WithContext(ctx).Create(event).Error
+}
@@ -0,0 +1,120 @@
+// internal/repositories/ledger_repo.go
+package repositories
+
+import (
+	"context"
+	"errors"
+	"math"
+	"modules/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LedgerRepository interface {
+	// EnsureAccount 按科目编码获取科目，不存在时创建
+	EnsureAccount(ctx context.Context, account *models.LedgerAccount) (*models.LedgerAccount, error)
+	GetAccountByCode(ctx context.Context, code string) (*models.LedgerAccount, error)
+	// PostEntry 校验借贷平衡后保存凭证；同一来源已记账时直接返回 false
+	PostEntry(ctx context.Context, entry *models.JournalEntry) (bool, error)
+	ListOwnerEntries(ctx context.Context, ownerID uint, from, to time.Time) ([]*models.JournalEntry, error)
+	// GetIncomeEntryByPayment 查询支付单对应的收入凭证（不含退款冲销），不存在时返回 nil
+	GetIncomeEntryByPayment(ctx context.Context, paymentID uint) (*models.JournalEntry, error)
+	// AccountBalance 统计科目在指定时间前的贷方余额（贷方合计减借方合计）
+	AccountBalance(ctx context.Context, accountID uint, before time.Time) (float64, error)
+}
+
+type ledgerRepo struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepo(db *gorm.DB) LedgerRepository {
+	return &ledgerRepo{db: db}
+}
+
+func (r *ledgerRepo) EnsureAccount(ctx context.Context, account *models.LedgerAccount) (*models.LedgerAccount, error) {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(account).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.GetAccountByCode(ctx, account.Code)
+}
+
+func (r *ledgerRepo) GetAccountByCode(ctx context.Context, code string) (*models.LedgerAccount, error) {
+	var account models.LedgerAccount
+	err := r.db.WithContext(ctx).Where("code = ?", code).First(&account).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *ledgerRepo) PostEntry(ctx context.Context, entry *models.JournalEntry) (bool, error) {
+	var debit, credit float64
+	for _, line := range entry.Lines {
+		debit += line.Debit
+		credit += line.Credit
+	}
+	if math.Abs(debit-credit) > 0.005 {
+		return false, models.ErrUnbalancedEntry
+	}
+
+	posted := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.JournalEntry{}).
+			Where("source_type = ? AND source_id = ?", entry.SourceType, entry.SourceID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		posted = true
+		return nil
+	})
+	return posted, err
+}
+
+func (r *ledgerRepo) ListOwnerEntries(ctx context.Context, ownerID uint, from, to time.Time) ([]*models.JournalEntry, error) {
+	var entries []*models.JournalEntry
+	err := r.db.WithContext(ctx).
+		Where("owner_id = ? AND occurred_at >= ? AND occurred_at < ?", ownerID, from, to).
+		Order("occurred_at ASC").
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *ledgerRepo) GetIncomeEntryByPayment(ctx context.Context, paymentID uint) (*models.JournalEntry, error) {
+	var entry models.JournalEntry
+	err := r.db.WithContext(ctx).
+		Where("payment_id = ? AND source_type <> ?", paymentID, models.JournalFromRefund).
+		First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *ledgerRepo) AccountBalance(ctx context.Context, accountID uint, before time.Time) (float64, error) {
+	var balance float64
+	err := r.db.WithContext(ctx).
+		Model(&models.JournalLine{}).
+		Joins("JOIN journal_entries ON journal_entries.id = journal_lines.entry_id").
+		Where("journal_lines.account_id = ? AND journal_entries.occurred_at < ?", accountID, before).
+		Select("COALESCE(SUM(journal_lines.credit - journal_lines.debit), 0)").
+		Scan(&balance).Error
+	return balance, err
+}
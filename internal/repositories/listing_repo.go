@@ -0,0 +1,193 @@
+// internal/repositories/listing_repo.go
+package repositories
+
+import (
+	"context"
+	"errors"
+	"modules/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ListingRepository interface {
+	CreateListing(ctx context.Context, listing *models.SpotListing) error
+	GetListingByID(ctx context.Context, id uint) (*models.SpotListing, error)
+	// SearchListings 按条件分页查询出租信息，同时返回总数
+	SearchListings(ctx context.Context, filter ListingFilter) ([]*models.SpotListing, int64, error)
+	UpdateListingStatus(ctx context.Context, id uint, status models.ListingStatus) error
+	CreateBooking(ctx context.Context, booking *models.BookingRequest) error
+	GetBookingByID(ctx context.Context, id uint) (*models.BookingRequest, error)
+	ListBookings(ctx context.Context, filter BookingFilter) ([]*models.BookingRequest, error)
+	// TransitionBooking 仅当申请仍处于 from 状态时写入 booking 的状态和处理信息，返回是否更新成功
+	TransitionBooking(ctx context.Context, booking *models.BookingRequest, from models.BookingStatus) (bool, error)
+	// DeclineOverlappingBookings 拒绝同一车位上与指定时段重叠的其他待处理申请
+	DeclineOverlappingBookings(ctx context.Context, spotID, exceptID uint, start, end time.Time, note string) (int64, error)
+}
+
+// ListingFilter 出租信息查询条件；From/To 非空时要求某个可租时段完整覆盖该区间
+type ListingFilter struct {
+	OwnerID   uint
+	Status    models.ListingStatus
+	SpotType  models.ParkingType
+	PriceUnit models.PriceUnit
+	MinPrice  float64
+	MaxPrice  float64
+	From      *time.Time
+	To        *time.Time
+	Offset    int
+	Limit     int
+}
+
+type BookingFilter struct {
+	RenterID  uint
+	OwnerID   uint
+	ListingID uint
+	Status    models.BookingStatus
+}
+
+type listingRepo struct {
+	db *gorm.DB
+}
+
+func NewListingRepo(db *gorm.DB) ListingRepository {
+	return &listingRepo{db: db}
+}
+
+func (r *listingRepo) CreateListing(ctx context.Context, listing *models.SpotListing) error {
+	return r.db.WithContext(ctx).Create(listing).Error
+}
+
+func (r *listingRepo) GetListingByID(ctx context.Context, id uint) (*models.SpotListing, error) {
+	var listing models.SpotListing
+	err := r.db.WithContext(ctx).
+		Preload("Windows", func(db *gorm.DB) *gorm.DB { return db.Order("start_time ASC") }).
+		First(&listing, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrListingNotFound
+	}
+	return &listing, err
+}
+
+func (r *listingRepo) SearchListings(ctx context.Context, filter ListingFilter) ([]*models.SpotListing, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.SpotListing{})
+	if filter.OwnerID != 0 {
+		query = query.Where("owner_id = ?", filter.OwnerID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.SpotType != "" {
+		query = query.Where("spot_type = ?", filter.SpotType)
+	}
+	if filter.PriceUnit != "" {
+		query = query.Where("price_unit = ?", filter.PriceUnit)
+	}
+	if filter.MinPrice > 0 {
+		query = query.Where("price >= ?", filter.MinPrice)
+	}
+	if filter.MaxPrice > 0 {
+		query = query.Where("price <= ?", filter.MaxPrice)
+	}
+	if filter.From != nil || filter.To != nil {
+		windows := r.db.Model(&models.ListingWindow{}).Select("listing_id")
+		if filter.From != nil {
+			windows = windows.Where("start_time <= ?", *filter.From)
+		}
+		if filter.To != nil {
+			windows = windows.Where("end_time >= ?", *filter.To)
+		}
+		query = query.Where("id IN (?)", windows)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Limit > 0 {
+		query = query.Offset(filter.Offset).Limit(filter.Limit)
+	}
+	var listings []*models.SpotListing
+	err := query.
+		Preload("Windows", func(db *gorm.DB) *gorm.DB { return db.Order("start_time ASC") }).
+		Order("created_at DESC").
+		Find(&listings).Error
+	return listings, total, err
+}
+
+func (r *listingRepo) UpdateListingStatus(ctx context.Context, id uint, status models.ListingStatus) error {
+	return r.db.WithContext(ctx).
+		Model(&models.SpotListing{}).
+		Where("id = ?", id).
+		Update("status", status).
+		Error
+}
+
+func (r *listingRepo) CreateBooking(ctx context.Context, booking *models.BookingRequest) error {
+	return r.db.WithContext(ctx).Create(booking).Error
+}
+
+func (r *listingRepo) GetBookingByID(ctx context.Context, id uint) (*models.BookingRequest, error) {
+	var booking models.BookingRequest
+	err := r.db.WithContext(ctx).First(&booking, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrBookingNotFound
+	}
+	return &booking, err
+}
+
+func (r *listingRepo) ListBookings(ctx context.Context, filter BookingFilter) ([]*models.BookingRequest, error) {
+	query := r.db.WithContext(ctx)
+	if filter.RenterID != 0 {
+		query = query.Where("renter_id = ?", filter.RenterID)
+	}
+	if filter.OwnerID != 0 {
+		query = query.Where("owner_id = ?", filter.OwnerID)
+	}
+	if filter.ListingID != 0 {
+		query = query.Where("listing_id = ?", filter.ListingID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var bookings []*models.BookingRequest
+	err := query.Order("created_at DESC").Find(&bookings).Error
+	return bookings, err
+}
+
+func (r *listingRepo) TransitionBooking(
+	ctx context.Context,
+	booking *models.BookingRequest,
+	from models.BookingStatus,
+) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.BookingRequest{}).
+		Where("id = ? AND status = ?", booking.ID, from).
+		Updates(map[string]interface{}{
+			"status":        booking.Status,
+			"decision_note": booking.DecisionNote,
+			"decided_at":    booking.DecidedAt,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *listingRepo) DeclineOverlappingBookings(
+	ctx context.Context,
+	spotID, exceptID uint,
+	start, end time.Time,
+	note string,
+) (int64, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).
+		Model(&models.BookingRequest{}).
+		Where("spot_id = ? AND id <> ? AND status = ? AND start_time < ? AND end_time > ?",
+			spotID, exceptID, models.BookingPending, end, start).
+		Updates(map[string]interface{}{
+			"status":        models.BookingDeclined,
+			"decision_note": note,
+			"decided_at":    now,
+		})
+	return result.RowsAffected, result.Error
+}
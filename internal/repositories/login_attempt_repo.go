@@ -0,0 +1,93 @@
+// internal/repositories/login_attempt_repo.go
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"modules/internal/models"
+	"time"
+)
+
+type LoginAttemptRepository interface {
+	// GetAttempt 查询失败记录，不存在时返回 nil
+	GetAttempt(ctx context.Context, scope models.LoginAttemptScope, key string) (*models.LoginAttempt, error)
+	// RecordFailure 在行锁内由 apply 更新失败次数和锁定时间，不存在时先创建空记录
+	RecordFailure(
+		ctx context.Context,
+		scope models.LoginAttemptScope,
+		key string,
+		apply func(attempt *models.LoginAttempt),
+	) (*models.LoginAttempt, error)
+	// Reset 清除失败记录并解除锁定
+	Reset(ctx context.Context, scope models.LoginAttemptScope, key string) error
+	// DeleteStale 清理 before 之前最后失败且已解除锁定的记录
+	DeleteStale(ctx context.Context, before time.Time) error
+}
+
+type loginAttemptRepo struct {
+	db *gorm.DB
+}
+
+func NewLoginAttemptRepo(db *gorm.DB) LoginAttemptRepository {
+	return &loginAttemptRepo{db: db}
+}
+
+func (r *loginAttemptRepo) GetAttempt(
+	ctx context.Context,
+	scope models.LoginAttemptScope,
+	key string,
+) (*models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	err := r.db.WithContext(ctx).Where("scope = ? AND `key` = ?", scope, key).First(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+func (r *loginAttemptRepo) RecordFailure(
+	ctx context.Context,
+	scope models.LoginAttemptScope,
+	key string,
+	apply func(attempt *models.LoginAttempt),
+) (*models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 首次失败时插入空记录，并发插入时忽略冲突
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.LoginAttempt{Scope: scope, Key: key, LastFailedAt: time.Now()}).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("scope = ? AND `key` = ?", scope, key).
+			First(&attempt).Error; err != nil {
+			return err
+		}
+
+		apply(&attempt)
+		return tx.Model(&attempt).
+			Select("failures", "last_failed_at", "locked_until", "updated_at").
+			Updates(&attempt).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+func (r *loginAttemptRepo) Reset(ctx context.Context, scope models.LoginAttemptScope, key string) error {
+	return r.db.WithContext(ctx).
+		Where("scope = ? AND `key` = ?", scope, key).
+		Delete(&models.LoginAttempt{}).Error
+}
+
+func (r *loginAttemptRepo) DeleteStale(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).
+		Where("last_failed_at < ? AND (locked_until IS NULL OR locked_until < ?)", before, before).
+		Delete(&models.LoginAttempt{}).Error
+}
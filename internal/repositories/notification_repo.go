@@ -0,0 +1,94 @@
+// internal/repositories/notification_repo.go
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"modules/internal/models"
+	"time"
+)
+
+type NotificationRepository interface {
+	CreateNotification(ctx context.Context, n *models.Notification) error
+	ListNotifications(ctx context.Context, filter NotificationFilter) ([]*models.Notification, int64, error)
+	// MarkRead 将用户的通知标记为已读，通知不存在或不属于该用户时返回 ErrNotificationNotFound
+	MarkRead(ctx context.Context, userID, id uint, at time.Time) (*models.Notification, error)
+	GetPreferences(ctx context.Context, userID uint) ([]*models.NotificationPreference, error)
+	SavePreferences(ctx context.Context, prefs []*models.NotificationPreference) error
+}
+
+type NotificationFilter struct {
+	UserID     uint
+	UnreadOnly bool
+	Offset     int
+	Limit      int
+}
+
+type notificationRepo struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepo(db *gorm.DB) NotificationRepository {
+	return &notificationRepo{db: db}
+}
+
+func (r *notificationRepo) CreateNotification(ctx context.Context, n *models.Notification) error {
+	return r.db.WithContext(ctx).Create(n).Error
+}
+
+func (r *notificationRepo) ListNotifications(ctx context.Context, filter NotificationFilter) ([]*models.Notification, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Notification{}).Where("user_id = ?", filter.UserID)
+	if filter.UnreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Limit > 0 {
+		query = query.Offset(filter.Offset).Limit(filter.Limit)
+	}
+	var items []*models.Notification
+	err := query.Order("created_at DESC, id DESC").Find(&items).Error
+	return items, total, err
+}
+
+func (r *notificationRepo) MarkRead(ctx context.Context, userID, id uint, at time.Time) (*models.Notification, error) {
+	var n models.Notification
+	err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&n).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrNotificationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if n.ReadAt != nil {
+		return &n, nil
+	}
+
+	if err := r.db.WithContext(ctx).Model(&n).Update("read_at", at).Error; err != nil {
+		return nil, err
+	}
+	n.ReadAt = &at
+	return &n, nil
+}
+
+func (r *notificationRepo) GetPreferences(ctx context.Context, userID uint) ([]*models.NotificationPreference, error) {
+	var prefs []*models.NotificationPreference
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&prefs).Error
+	return prefs, err
+}
+
+func (r *notificationRepo) SavePreferences(ctx context.Context, prefs []*models.NotificationPreference) error {
+	if len(prefs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "event"}},
+		DoUpdates: clause.AssignmentColumns([]string{"channel", "updated_at"}),
+	}).Create(&prefs).Error
+}
@@ -0,0 +1,118 @@
+// internal/repositories/outbox_repo.go
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"modules/internal/models"
+	"time"
+)
+
+type OutboxRepository interface {
+	GetEvent(ctx context.Context, id uint) (*models.OutboxEvent, error)
+	ListEvents(ctx context.Context, filter OutboxFilter) ([]*models.OutboxEvent, int64, error)
+	// ListDueEvents 查询到了分发时间的待分发事件，包含已完成的处理器
+	ListDueEvents(ctx context.Context, now time.Time, limit int) ([]*models.OutboxEvent, error)
+	// ClaimEvent 以尝试次数作为版本号抢占事件，并把下一次分发时间推迟到 leaseUntil，成功抢占时返回 true
+	ClaimEvent(ctx context.Context, id uint, attempts int, now, leaseUntil time.Time) (bool, error)
+	// RecordDelivery 登记处理器已处理该事件，重复登记时忽略
+	RecordDelivery(ctx context.Context, eventID uint, handler string) error
+	// SaveEvent 更新事件的分发状态
+	SaveEvent(ctx context.Context, event *models.OutboxEvent) error
+}
+
+type OutboxFilter struct {
+	Status models.OutboxStatus
+	Event  models.WebhookEvent
+	Offset int
+	Limit  int
+}
+
+type outboxRepo struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepo(db *gorm.DB) OutboxRepository {
+	return &outboxRepo{db: db}
+}
+
+// addOutboxEvent 在调用方的事务中写入发件箱事件
+func addOutboxEvent(
+	tx *gorm.DB,
+	event models.WebhookEvent,
+	aggregateType string,
+	aggregateID uint,
+	data interface{},
+) error {
+	outboxEvent, err := models.NewOutboxEvent(event, aggregateType, aggregateID, data)
+	if err != nil {
+		return err
+	}
+	return tx.Create(outboxEvent).Error
+}
+
+func (r *outboxRepo) GetEvent(ctx context.Context, id uint) (*models.OutboxEvent, error) {
+	var event models.OutboxEvent
+	err := r.db.WithContext(ctx).Preload("Deliveries").First(&event, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrOutboxEventNotFound
+	}
+	return &event, err
+}
+
+func (r *outboxRepo) ListEvents(ctx context.Context, filter OutboxFilter) ([]*models.OutboxEvent, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.OutboxEvent{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Event != "" {
+		query = query.Where("event = ?", filter.Event)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Limit > 0 {
+		query = query.Offset(filter.Offset).Limit(filter.Limit)
+	}
+	var events []*models.OutboxEvent
+	err := query.Order("id DESC").Find(&events).Error
+	return events, total, err
+}
+
+func (r *outboxRepo) ListDueEvents(ctx context.Context, now time.Time, limit int) ([]*models.OutboxEvent, error) {
+	var events []*models.OutboxEvent
+	err := r.db.WithContext(ctx).
+		Preload("Deliveries").
+		Where("status = ? AND next_attempt_at <= ?", models.OutboxPending, now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+func (r *outboxRepo) ClaimEvent(ctx context.Context, id uint, attempts int, now, leaseUntil time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.OutboxEvent{}).
+		Where("id = ? AND attempts = ? AND status = ? AND next_attempt_at <= ?",
+			id, attempts, models.OutboxPending, now).
+		Update("next_attempt_at", leaseUntil)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *outboxRepo) RecordDelivery(ctx context.Context, eventID uint, handler string) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.OutboxDelivery{OutboxEventID: eventID, Handler: handler}).Error
+}
+
+func (r *outboxRepo) SaveEvent(ctx context.Context, event *models.OutboxEvent) error {
+	return r.db.WithContext(ctx).
+		Model(event).
+		Select("status", "attempts", "next_attempt_at", "last_error", "delivered_at", "updated_at").
+		Updates(event).Error
+}
@@ -23,16 +23,24 @@ type ParkingRepository interface {
 	ListSpots(ctx context.Context, filter SpotFilter) ([]*models.ParkingSpot, error)
 	CreateRecord(ctx context.Context, record *models.ParkingRecord) error
 	GetOngoingRecord(ctx context.Context, license string) (*models.ParkingRecord, error)
+	// HasOngoingRecordForSpot 检查车位上是否还有未结束的停车记录
+	HasOngoingRecordForSpot(ctx context.Context, spotID uint) (bool, error)
 	UpdateStatus(ctx context.Context, spotID uint, status models.ParkingStatus) error
 	UpdateSpotExpiry(ctx context.Context, spotID uint, expiresAt *time.Time) error
 	OccupySpot(ctx context.Context, spotID uint, license string, userID *uint) (*models.ParkingRecord, error)
-	ReleaseSpot(ctx context.Context, recordID uint) (*models.ParkingRecord, error)
+	// ReleaseSpot 结束停车记录并释放车位；settle 在同一事务中计算费用，可以为 nil
+	ReleaseSpot(ctx context.Context, recordID uint, settle func(record *models.ParkingRecord) error) (*models.ParkingRecord, error)
 	UpdateRecord(ctx context.Context, record *models.ParkingRecord) (*models.ParkingRecord, error)
 	GetParkingByID(ctx context.Context, parkingID uint) (*models.ParkingRecord, error)
 	UpdateParking(ctx context.Context, parking *models.ParkingRecord) error
 	GetParkingSpotByID(ctx context.Context, parkingID uint) (*models.ParkingSpot, error)
 	UnbindParkingFromUser(ctx context.Context, id uint, id2 uint) error
 	UpdateParkingSpot(ctx context.Context, parking *models.ParkingSpot) error
+	GetRecoveryPolicy(ctx context.Context, spotID uint) (*models.SpotRecoveryPolicy, error)
+	SaveRecoveryPolicy(ctx context.Context, policy *models.SpotRecoveryPolicy) error
+	// AddOutboxEvent 写入发件箱事件，在 Transaction 中调用时与业务变更一同提交
+	AddOutboxEvent(ctx context.Context, event *models.OutboxEvent) error
+	Transaction(ctx context.Context, fn func(repo ParkingRepository) error) error
 }
 
 type parkingRepo struct {
@@ -40,6 +48,7 @@ type parkingRepo struct {
 }
 
 func (r *parkingRepo) UpdateParkingSpot(ctx context.Context, spot *models.ParkingSpot) error {
+	spot.UpdatedAt = spotTimestamp()
 	return r.db.WithContext(ctx).Save(spot).Error
 }
 
@@ -64,16 +73,23 @@ func (r *parkingRepo) GetSpotByID(ctx context.Context, id uint) (*models.Parking
 }
 
 func (r *parkingRepo) UpdateSpot(ctx context.Context, spot *models.ParkingSpot) error {
+	spot.UpdatedAt = spotTimestamp()
 	return r.db.WithContext(ctx).Save(spot).Error
 }
 
 type SpotFilter struct {
-	Type      models.ParkingType
-	Status    models.ParkingStatus
-	OwnerID   uint
+	Type    models.ParkingType
+	Status  models.ParkingStatus
+	OwnerID uint
+	// 只查询在该时间之前最后更新的车位
 	UpdatedAt *time.Time
 }
 
+// spotTimestamp 车位表 updated_at 为字符串字段，GORM 不会自动维护，由仓库在每次变更时写入
+func spotTimestamp() string {
+	return time.Now().Format(models.SpotTimeLayout)
+}
+
 func (r *parkingRepo) OccupySpot(
 	ctx context.Context,
 	spotID uint,
@@ -90,7 +106,7 @@ func (r *parkingRepo) OccupySpot(
 			return err
 		}
 
-		if spot.Status != "Idle" {
+		if spot.Status != string(models.Idle) {
 			return errors.New("停车位不可用")
 		}
 
@@ -108,20 +124,26 @@ func (r *parkingRepo) OccupySpot(
 
 		// 更新车位状态
 		if err := tx.Model(&spot).Updates(map[string]interface{}{
-			"status":  models.Occupied,
-			"license": license,
+			"status":     models.Occupied,
+			"license":    license,
+			"updated_at": spotTimestamp(),
 		}).Error; err != nil {
 			return err
 		}
 
 		record = newRecord // 保存创建的记录
-		return nil
+		return addOutboxEvent(tx, models.WebhookParkingEntry, models.AggregateParkingRecord,
+			newRecord.ID, models.NewParkingEventData(newRecord))
 	})
 
 	return record, err // 返回记录和错误
 }
 
-func (r *parkingRepo) ReleaseSpot(ctx context.Context, recordID uint) (*models.ParkingRecord, error) {
+func (r *parkingRepo) ReleaseSpot(
+	ctx context.Context,
+	recordID uint,
+	settle func(record *models.ParkingRecord) error,
+) (*models.ParkingRecord, error) {
 	var record models.ParkingRecord
 	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// 使用正确的锁语法
@@ -138,18 +160,32 @@ func (r *parkingRepo) ReleaseSpot(ctx context.Context, recordID uint) (*models.P
 		exitTime := time.Now()
 		record.ExitTime = &exitTime
 		record.IsCompleted = true
+		if settle != nil {
+			if err := settle(&record); err != nil {
+				return err
+			}
+		}
 
-		// 更新车位状态
+		// 更新车位状态；停车期间被报修的车位保持故障状态
 		if err := tx.Model(&models.ParkingSpot{}).
 			Where("id = ?", record.SpotID).
+			Update("license", "").Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ParkingSpot{}).
+			Where("id = ? AND status = ?", record.SpotID, models.Occupied).
 			Updates(map[string]interface{}{
-				"status":  models.Idle,
-				"license": "",
+				"status":     models.Idle,
+				"updated_at": spotTimestamp(),
 			}).Error; err != nil {
 			return err
 		}
 
-		return tx.Save(&record).Error
+		if err := tx.Save(&record).Error; err != nil {
+			return err
+		}
+		return addOutboxEvent(tx, models.WebhookParkingExit, models.AggregateParkingRecord,
+			record.ID, models.NewParkingEventData(&record))
 	})
 
 	return &record, err
@@ -170,6 +206,9 @@ func (r *parkingRepo) ListSpots(ctx context.Context, filter SpotFilter) ([]*mode
 	if filter.OwnerID != 0 {
 		query = query.Where("owner_id = ?", filter.OwnerID)
 	}
+	if filter.UpdatedAt != nil {
+		query = query.Where("updated_at <> '' AND updated_at <= ?", filter.UpdatedAt.Format(models.SpotTimeLayout))
+	}
 
 	var spots []*models.ParkingSpot
 	err := query.Find(&spots).Error
@@ -188,6 +227,15 @@ func (r *parkingRepo) GetOngoingRecord(ctx context.Context, license string) (*mo
 	return &record, err
 }
 
+func (r *parkingRepo) HasOngoingRecordForSpot(ctx context.Context, spotID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.ParkingRecord{}).
+		Where("spot_id = ? AND is_completed = ?", spotID, false).
+		Count(&count).Error
+	return count > 0, err
+}
+
 func (r *parkingRepo) UpdateRecord(
 	ctx context.Context,
 	record *models.ParkingRecord,
@@ -202,10 +250,33 @@ func (r *parkingRepo) UpdateStatus(ctx context.Context, spotID uint, status mode
 	return r.db.WithContext(ctx).
 		Model(&models.ParkingSpot{}).
 		Where("id = ?", spotID).
-		Update("status", status).
+		Updates(map[string]interface{}{
+			"status":     status,
+			"updated_at": spotTimestamp(),
+		}).
 		Error
 }
 
+// GetRecoveryPolicy 查询车位自动恢复策略，未配置时返回 nil
+func (r *parkingRepo) GetRecoveryPolicy(ctx context.Context, spotID uint) (*models.SpotRecoveryPolicy, error) {
+	var policy models.SpotRecoveryPolicy
+	err := r.db.WithContext(ctx).Where("spot_id = ?", spotID).First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *parkingRepo) SaveRecoveryPolicy(ctx context.Context, policy *models.SpotRecoveryPolicy) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "spot_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"auto_recover", "after_hours", "updated_by", "updated_at"}),
+	}).Create(policy).Error
+}
+
 func (r *parkingRepo) UpdateSpotExpiry(
 	ctx context.Context,
 	spotID uint,
@@ -245,6 +316,10 @@ func (r *parkingRepo) UpdateSpotExpiry(
 	return nil
 }
 
+func (r *parkingRepo) AddOutboxEvent(ctx context.Context, event *models.OutboxEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
 func (r *parkingRepo) Transaction(
 	ctx context.Context,
 	fn func(repo ParkingRepository) error,
@@ -0,0 +1,140 @@
+// internal/repositories/payment_repo.go
+package repositories
+
+import (
+	"context"
+	"errors"
+	"modules/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type PaymentRepository interface {
+	CreatePayment(ctx context.Context, payment *models.Payment) error
+	GetPaymentByID(ctx context.Context, id uint) (*models.Payment, error)
+	GetLatestPayment(ctx context.Context, purpose models.PaymentPurpose, referenceID uint) (*models.Payment, error)
+	GetUserPayments(ctx context.Context, userID uint) ([]*models.Payment, error)
+	UpdatePayment(ctx context.Context, payment *models.Payment) error
+	// TransitionPayment 仅当支付单处于 from 状态时改为 to，返回是否更新成功
+	TransitionPayment(ctx context.Context, id uint, from, to models.PaymentStatus) (bool, error)
+	// 退款申请
+	CreateRefund(ctx context.Context, refund *models.Refund) error
+	GetRefundByID(ctx context.Context, id uint) (*models.Refund, error)
+	ListRefunds(ctx context.Context, status models.RefundStatus) ([]*models.Refund, error)
+	UpdateRefund(ctx context.Context, refund *models.Refund) error
+	// TransitionRefund 仅当退款申请处于 from 状态时改为 to，返回是否更新成功
+	TransitionRefund(ctx context.Context, id uint, from, to models.RefundStatus) (bool, error)
+	// AddRefundedAmount 累加支付单的已退款金额并标记为已退款
+	AddRefundedAmount(ctx context.Context, paymentID uint, amount float64) error
+	Transaction(ctx context.Context, fn func(repo PaymentRepository) error) error
+}
+
+type paymentRepo struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepo(db *gorm.DB) PaymentRepository {
+	return &paymentRepo{db: db}
+}
+
+func (r *paymentRepo) CreatePayment(ctx context.Context, payment *models.Payment) error {
+	return r.db.WithContext(ctx).Create(payment).Error
+}
+
+func (r *paymentRepo) GetPaymentByID(ctx context.Context, id uint) (*models.Payment, error) {
+	var payment models.Payment
+	err := r.db.WithContext(ctx).First(&payment, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrPaymentNotFound
+	}
+	return &payment, err
+}
+
+// GetLatestPayment 获取业务单据最近一次的支付单
+func (r *paymentRepo) GetLatestPayment(
+	ctx context.Context,
+	purpose models.PaymentPurpose,
+	referenceID uint,
+) (*models.Payment, error) {
+	var payment models.Payment
+	err := r.db.WithContext(ctx).
+		Where("purpose = ? AND reference_id = ?", purpose, referenceID).
+		Order("id DESC").
+		First(&payment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrPaymentNotFound
+	}
+	return &payment, err
+}
+
+func (r *paymentRepo) GetUserPayments(ctx context.Context, userID uint) ([]*models.Payment, error) {
+	var payments []*models.Payment
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&payments).Error
+	return payments, err
+}
+
+func (r *paymentRepo) UpdatePayment(ctx context.Context, payment *models.Payment) error {
+	return r.db.WithContext(ctx).Save(payment).Error
+}
+
+func (r *paymentRepo) TransitionPayment(ctx context.Context, id uint, from, to models.PaymentStatus) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.Payment{}).
+		Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *paymentRepo) CreateRefund(ctx context.Context, refund *models.Refund) error {
+	return r.db.WithContext(ctx).Create(refund).Error
+}
+
+func (r *paymentRepo) GetRefundByID(ctx context.Context, id uint) (*models.Refund, error) {
+	var refund models.Refund
+	err := r.db.WithContext(ctx).First(&refund, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrRefundNotFound
+	}
+	return &refund, err
+}
+
+func (r *paymentRepo) ListRefunds(ctx context.Context, status models.RefundStatus) ([]*models.Refund, error) {
+	var refunds []*models.Refund
+	query := r.db.WithContext(ctx)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at DESC").Find(&refunds).Error
+	return refunds, err
+}
+
+func (r *paymentRepo) UpdateRefund(ctx context.Context, refund *models.Refund) error {
+	return r.db.WithContext(ctx).Save(refund).Error
+}
+
+func (r *paymentRepo) TransitionRefund(ctx context.Context, id uint, from, to models.RefundStatus) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.Refund{}).
+		Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *paymentRepo) AddRefundedAmount(ctx context.Context, paymentID uint, amount float64) error {
+	return r.db.WithContext(ctx).
+		Model(&models.Payment{}).
+		Where("id = ?", paymentID).
+		Updates(map[string]interface{}{
+			"refunded_amount": gorm.Expr("refunded_amount + ?", amount),
+			"status":          models.PaymentRefunded,
+		}).Error
+}
+
+func (r *paymentRepo) Transaction(ctx context.Context, fn func(repo PaymentRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&paymentRepo{db: tx})
+	})
+}
@@ -3,20 +3,41 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"modules/internal/models"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ReportRepository interface {
+	// GetDailyReports 从停车记录实时汇总指定时段内每天的数据
 	GetDailyReports(ctx context.Context, start, end time.Time) ([]*models.DailyReport, error)
+	// UpsertDailyReports 按日期写入或覆盖日报快照
+	UpsertDailyReports(ctx context.Context, reports []*models.DailyReport) error
+	// ListDailyReports 查询已保存的日报快照，按日期升序
+	ListDailyReports(ctx context.Context, from, to time.Time) ([]*models.DailyReport, error)
+	// GetDailyRevenue 按支付日期、车位汇总已支付的停车费和租金，均扣除已退款部分
+	GetDailyRevenue(ctx context.Context, start, end time.Time) ([]*models.RevenueRow, error)
 	GetSpotUtilization(ctx context.Context) (map[models.ParkingType]float64, error)
 	GetUserActivities(ctx context.Context, userID uint) ([]*models.ParkingRecord, error)
 	// 维护记录
 	CreateMaintenance(ctx context.Context, record *models.MaintenanceRecord) error
 	ResolveMaintenance(ctx context.Context, id uint) error
 	GetMaintenanceRecords(ctx context.Context, status string) ([]*models.MaintenanceRecord, error)
+	GetMaintenanceByID(ctx context.Context, id uint) (*models.MaintenanceRecord, error)
+	ListMaintenance(ctx context.Context, filter MaintenanceFilter) ([]*models.MaintenanceRecord, error)
+	// SaveMaintenance 在同一事务中保存工单并追加一条状态变更记录
+	SaveMaintenance(ctx context.Context, record *models.MaintenanceRecord, history *models.MaintenanceHistory) error
+	// HasPendingMaintenance 检查车位是否还有未关闭的维修工单
+	HasPendingMaintenance(ctx context.Context, spotID uint) (bool, error)
+}
+
+type MaintenanceFilter struct {
+	SpotID     uint
+	ReportedBy uint
+	Status     string
 }
 
 type reportRepo struct {
@@ -38,8 +59,8 @@ func (r *reportRepo) GetDailyReports(ctx context.Context, start, end time.Time)
 			COUNT(CASE WHEN spots.type = 'short_term' THEN 1 END) AS short_term_cnt,
 			COUNT(CASE WHEN spots.type = 'permanent' THEN 1 END) AS permanent_cnt
 		FROM parking_records
-		JOIN parking_spots ON parking_spots.id = parking_records.spot_id
-		WHERE exit_time BETWEEN ? AND ?
+		JOIN parking_spots AS spots ON spots.id = parking_records.spot_id
+		WHERE exit_time >= ? AND exit_time < ?
 		GROUP BY DATE(exit_time)
 		ORDER BY date DESC
 	`, start, end).Scan(&reports).Error
@@ -47,6 +68,68 @@ func (r *reportRepo) GetDailyReports(ctx context.Context, start, end time.Time)
 	return reports, err
 }
 
+func (r *reportRepo) UpsertDailyReports(ctx context.Context, reports []*models.DailyReport) error {
+	if len(reports) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "date"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"total_income", "temporary_cnt", "short_term_cnt", "permanent_cnt", "updated_at",
+			}),
+		}).
+		Create(&reports).Error
+}
+
+func (r *reportRepo) ListDailyReports(ctx context.Context, from, to time.Time) ([]*models.DailyReport, error) {
+	var reports []*models.DailyReport
+	err := r.db.WithContext(ctx).
+		Where("date >= ? AND date <= ?", from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Order("date ASC").
+		Find(&reports).Error
+	return reports, err
+}
+
+func (r *reportRepo) GetDailyRevenue(ctx context.Context, start, end time.Time) ([]*models.RevenueRow, error) {
+	var rows []*models.RevenueRow
+
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			DATE(payments.paid_at) AS date,
+			'parking' AS source,
+			parking_records.spot_id AS spot_id,
+			COALESCE(spots.owner_id, 0) AS owner_id,
+			COALESCE(spots.type, '') AS spot_type,
+			COALESCE(SUM(payments.amount - payments.refunded_amount), 0) AS amount,
+			COUNT(*) AS count
+		FROM payments
+		JOIN parking_records ON parking_records.id = payments.reference_id
+		LEFT JOIN parking_spots AS spots ON spots.id = parking_records.spot_id
+		WHERE payments.purpose = ? AND payments.status IN ? AND payments.paid_at >= ? AND payments.paid_at < ?
+		GROUP BY DATE(payments.paid_at), parking_records.spot_id, spots.owner_id, spots.type
+		UNION ALL
+		SELECT
+			DATE(payments.paid_at) AS date,
+			'lease' AS source,
+			lease_orders.spot_id AS spot_id,
+			lease_orders.owner_id AS owner_id,
+			COALESCE(spots.type, '') AS spot_type,
+			COALESCE(SUM(payments.amount - payments.refunded_amount), 0) AS amount,
+			COUNT(*) AS count
+		FROM payments
+		JOIN lease_orders ON lease_orders.id = payments.reference_id
+		LEFT JOIN parking_spots AS spots ON spots.id = lease_orders.spot_id
+		WHERE payments.purpose = ? AND payments.status IN ? AND payments.paid_at >= ? AND payments.paid_at < ?
+		GROUP BY DATE(payments.paid_at), lease_orders.spot_id, lease_orders.owner_id, spots.type
+	`,
+		models.PaymentForParking, []models.PaymentStatus{models.PaymentSucceeded, models.PaymentRefunded}, start, end,
+		models.PaymentForLease, []models.PaymentStatus{models.PaymentSucceeded, models.PaymentRefunded}, start, end,
+	).Scan(&rows).Error
+
+	return rows, err
+}
+
 func (r *reportRepo) GetSpotUtilization(ctx context.Context) (map[models.ParkingType]float64, error) {
 	var stats []struct {
 		Type  models.ParkingType
@@ -76,11 +159,53 @@ func (r *reportRepo) HasPendingMaintenance(ctx context.Context, spotID uint) (bo
 	var count int64
 	err := r.db.WithContext(ctx).
 		Model(&models.MaintenanceRecord{}).
-		Where("spot_id = ? AND status = ?", spotID, "pending").
+		Where("spot_id = ? AND status <> ?", spotID, models.MaintenanceResolved).
 		Count(&count).Error
 	return count > 0, err
 }
 
+func (r *reportRepo) GetMaintenanceByID(ctx context.Context, id uint) (*models.MaintenanceRecord, error) {
+	var record models.MaintenanceRecord
+	err := r.db.WithContext(ctx).
+		Preload("History", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC, id ASC") }).
+		First(&record, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrMaintenanceNotFound
+	}
+	return &record, err
+}
+
+func (r *reportRepo) ListMaintenance(ctx context.Context, filter MaintenanceFilter) ([]*models.MaintenanceRecord, error) {
+	query := r.db.WithContext(ctx)
+	if filter.SpotID != 0 {
+		query = query.Where("spot_id = ?", filter.SpotID)
+	}
+	if filter.ReportedBy != 0 {
+		query = query.Where("reported_by = ?", filter.ReportedBy)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var records []*models.MaintenanceRecord
+	err := query.Order("created_at DESC").Find(&records).Error
+	return records, err
+}
+
+func (r *reportRepo) SaveMaintenance(
+	ctx context.Context,
+	record *models.MaintenanceRecord,
+	history *models.MaintenanceHistory,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("History").Save(record).Error; err != nil {
+			return err
+		}
+		history.RecordID = record.ID
+		return tx.Create(history).Error
+	})
+}
+
 func (r *reportRepo) CreateMaintenance(ctx context.Context, record *models.MaintenanceRecord) error {
 	return r.db.WithContext(ctx).Create(record).Error
 }
@@ -0,0 +1,154 @@
+// internal/repositories/reservation_repo.go
+package repositories
+
+import (
+	"context"
+	"errors"
+	"modules/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReservationRepository interface {
+	// CreateReservation 在候选车位中依次查找该时段无冲突的车位并创建预约
+	CreateReservation(ctx context.Context, reservation *models.Reservation, candidateSpotIDs []uint) error
+	GetReservationByID(ctx context.Context, id uint) (*models.Reservation, error)
+	GetUserReservations(ctx context.Context, userID uint, status models.ReservationStatus) ([]*models.Reservation, error)
+	FindBookedByLicense(ctx context.Context, license string, arriveBefore, leaveAfter time.Time) (*models.Reservation, error)
+	GetReservedSpotIDs(ctx context.Context, from, to time.Time) ([]uint, error)
+	UpdateReservationStatus(ctx context.Context, id uint, status models.ReservationStatus) error
+	FulfillReservation(ctx context.Context, id uint, recordID uint) error
+	ExpireNoShows(ctx context.Context, startedBefore time.Time) (int64, error)
+}
+
+type reservationRepo struct {
+	db *gorm.DB
+}
+
+func NewReservationRepo(db *gorm.DB) ReservationRepository {
+	return &reservationRepo{db: db}
+}
+
+func (r *reservationRepo) CreateReservation(
+	ctx context.Context,
+	reservation *models.Reservation,
+	candidateSpotIDs []uint,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, spotID := range candidateSpotIDs {
+			// 锁定车位行，串行化同一车位的并发预约
+			var spot models.ParkingSpot
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				First(&spot, spotID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					continue
+				}
+				return err
+			}
+			if spot.Status == string(models.Faulty) {
+				continue
+			}
+
+			var overlapping int64
+			if err := tx.Model(&models.Reservation{}).
+				Where("spot_id = ? AND status = ? AND start_time < ? AND end_time > ?",
+					spotID, models.ReservationBooked, reservation.EndTime, reservation.StartTime).
+				Count(&overlapping).Error; err != nil {
+				return err
+			}
+			if overlapping > 0 {
+				continue
+			}
+
+			reservation.SpotID = spot.ID
+			reservation.SpotType = models.ParkingType(spot.Type)
+			reservation.Status = models.ReservationBooked
+			return tx.Create(reservation).Error
+		}
+		return models.ErrReservationConflict
+	})
+}
+
+func (r *reservationRepo) GetReservationByID(ctx context.Context, id uint) (*models.Reservation, error) {
+	var reservation models.Reservation
+	err := r.db.WithContext(ctx).First(&reservation, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrReservationNotFound
+	}
+	return &reservation, err
+}
+
+func (r *reservationRepo) GetUserReservations(
+	ctx context.Context,
+	userID uint,
+	status models.ReservationStatus,
+) ([]*models.Reservation, error) {
+	var reservations []*models.Reservation
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("start_time DESC").Find(&reservations).Error
+	return reservations, err
+}
+
+// FindBookedByLicense 查找车牌在当前时刻可使用的预约，没有时返回 nil
+func (r *reservationRepo) FindBookedByLicense(
+	ctx context.Context,
+	license string,
+	arriveBefore, leaveAfter time.Time,
+) (*models.Reservation, error) {
+	var reservation models.Reservation
+	err := r.db.WithContext(ctx).
+		Where("license = ? AND status = ? AND start_time <= ? AND end_time > ?",
+			license, models.ReservationBooked, arriveBefore, leaveAfter).
+		Order("start_time ASC").
+		First(&reservation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// GetReservedSpotIDs 查询在指定时段内有有效预约的车位
+func (r *reservationRepo) GetReservedSpotIDs(ctx context.Context, from, to time.Time) ([]uint, error) {
+	var spotIDs []uint
+	err := r.db.WithContext(ctx).
+		Model(&models.Reservation{}).
+		Where("status = ? AND start_time < ? AND end_time > ?", models.ReservationBooked, to, from).
+		Distinct().
+		Pluck("spot_id", &spotIDs).Error
+	return spotIDs, err
+}
+
+func (r *reservationRepo) UpdateReservationStatus(ctx context.Context, id uint, status models.ReservationStatus) error {
+	return r.db.WithContext(ctx).
+		Model(&models.Reservation{}).
+		Where("id = ?", id).
+		Update("status", status).
+		Error
+}
+
+func (r *reservationRepo) FulfillReservation(ctx context.Context, id uint, recordID uint) error {
+	return r.db.WithContext(ctx).
+		Model(&models.Reservation{}).
+		Where("id = ? AND status = ?", id, models.ReservationBooked).
+		Updates(map[string]interface{}{
+			"status":    models.ReservationFulfilled,
+			"record_id": recordID,
+		}).Error
+}
+
+// ExpireNoShows 将开始时间早于指定时刻仍未入场的预约标记为过期
+func (r *reservationRepo) ExpireNoShows(ctx context.Context, startedBefore time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.Reservation{}).
+		Where("status = ? AND start_time < ?", models.ReservationBooked, startedBefore).
+		Update("status", models.ReservationExpired)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,114 @@
+// internal/repositories/role_repo.go
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"modules/internal/models"
+)
+
+type RoleRepository interface {
+	ListRoles(ctx context.Context) ([]*models.RoleDefinition, error)
+	// GetRole 按名称查询角色，不存在时返回 models.ErrRoleNotFound
+	GetRole(ctx context.Context, name models.Role) (*models.RoleDefinition, error)
+	// ListRolePermissions 查询全部角色的权限
+	ListRolePermissions(ctx context.Context) ([]*models.RolePermission, error)
+	// CreateRole 创建角色及其权限，同名角色已存在时返回 models.ErrRoleExists
+	CreateRole(ctx context.Context, role *models.RoleDefinition, permissions []models.Permission) error
+	// EnsureRole 角色不存在时创建并写入初始权限，已存在时不做修改
+	EnsureRole(ctx context.Context, role *models.RoleDefinition, permissions []models.Permission) error
+	// UpdateRole 更新角色说明并替换其全部权限
+	UpdateRole(ctx context.Context, role *models.RoleDefinition, permissions []models.Permission) error
+	// DeleteRole 删除角色及其权限
+	DeleteRole(ctx context.Context, role *models.RoleDefinition) error
+}
+
+type roleRepo struct {
+	db *gorm.DB
+}
+
+func NewRoleRepo(db *gorm.DB) RoleRepository {
+	return &roleRepo{db: db}
+}
+
+func (r *roleRepo) ListRoles(ctx context.Context) ([]*models.RoleDefinition, error) {
+	var roles []*models.RoleDefinition
+	err := r.db.WithContext(ctx).Order("id").Find(&roles).Error
+	return roles, err
+}
+
+func (r *roleRepo) GetRole(ctx context.Context, name models.Role) (*models.RoleDefinition, error) {
+	var role models.RoleDefinition
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&role).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *roleRepo) ListRolePermissions(ctx context.Context) ([]*models.RolePermission, error) {
+	var permissions []*models.RolePermission
+	err := r.db.WithContext(ctx).Order("role, permission").Find(&permissions).Error
+	return permissions, err
+}
+
+func (r *roleRepo) CreateRole(ctx context.Context, role *models.RoleDefinition, permissions []models.Permission) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(role)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrRoleExists
+		}
+		return replaceRolePermissions(tx, role.Name, permissions)
+	})
+}
+
+func (r *roleRepo) EnsureRole(ctx context.Context, role *models.RoleDefinition, permissions []models.Permission) error {
+	err := r.CreateRole(ctx, role, permissions)
+	if errors.Is(err, models.ErrRoleExists) {
+		return nil
+	}
+	return err
+}
+
+func (r *roleRepo) UpdateRole(ctx context.Context, role *models.RoleDefinition, permissions []models.Permission) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.RoleDefinition{}).
+			Where("id = ?", role.ID).
+			Update("description", role.Description).Error
+		if err != nil {
+			return err
+		}
+		return replaceRolePermissions(tx, role.Name, permissions)
+	})
+}
+
+func (r *roleRepo) DeleteRole(ctx context.Context, role *models.RoleDefinition) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role = ?", role.Name).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.RoleDefinition{}, role.ID).Error
+	})
+}
+
+func replaceRolePermissions(tx *gorm.DB, role models.Role, permissions []models.Permission) error {
+	if err := tx.Where("role = ?", role).Delete(&models.RolePermission{}).Error; err != nil {
+		return err
+	}
+	rows := make([]*models.RolePermission, 0, len(permissions))
+	for _, p := range permissions {
+		rows = append(rows, &models.RolePermission{Role: role, Permission: p})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Create(&rows).Error
+}
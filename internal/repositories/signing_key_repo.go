@@ -0,0 +1,80 @@
+// internal/repositories/signing_key_repo.go
+package repositories
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"modules/internal/models"
+	"time"
+)
+
+type SigningKeyRepository interface {
+	// ListValidKeys 查询 now 时仍可用于验证的密钥，最新的在前
+	ListValidKeys(ctx context.Context, now time.Time) ([]*models.SigningKey, error)
+	// RotateKey 停用当前签发密钥 currentKID 并保存 next，旧密钥保留到 expiresAt。
+	// currentKID 为空表示尚无签发密钥；当前签发密钥已被其他进程轮换时不做修改并返回 false
+	RotateKey(ctx context.Context, currentKID string, next *models.SigningKey, expiresAt time.Time) (bool, error)
+	// DeleteExpired 删除 before 之前过期的密钥
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+type signingKeyRepo struct {
+	db *gorm.DB
+}
+
+func NewSigningKeyRepo(db *gorm.DB) SigningKeyRepository {
+	return &signingKeyRepo{db: db}
+}
+
+func (r *signingKeyRepo) ListValidKeys(ctx context.Context, now time.Time) ([]*models.SigningKey, error) {
+	var keys []*models.SigningKey
+	err := r.db.WithContext(ctx).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Order("id DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+func (r *signingKeyRepo) RotateKey(
+	ctx context.Context,
+	currentKID string,
+	next *models.SigningKey,
+	expiresAt time.Time,
+) (bool, error) {
+	rotated := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		active := tx.Model(&models.SigningKey{}).Where("retired_at IS NULL")
+		if currentKID == "" {
+			var count int64
+			if err := active.Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return nil
+			}
+		} else {
+			result := active.Where("kid = ?", currentKID).Updates(map[string]interface{}{
+				"retired_at": time.Now(),
+				"expires_at": expiresAt,
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return nil
+			}
+		}
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		rotated = true
+		return nil
+	})
+	return rotated, err
+}
+
+func (r *signingKeyRepo) DeleteExpired(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).
+		Where("expires_at IS NOT NULL AND expires_at < ?", before).
+		Delete(&models.SigningKey{}).Error
+}
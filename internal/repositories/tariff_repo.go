@@ -0,0 +1,118 @@
+// internal/repositories/tariff_repo.go
+package repositories
+
+import (
+	"context"
+	"errors"
+	"modules/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TariffRepository interface {
+	CreateTariff(ctx context.Context, tariff *models.Tariff) error
+	GetTariffByID(ctx context.Context, id uint) (*models.Tariff, error)
+	GetActiveTariff(ctx context.Context) (*models.Tariff, error)
+	ListTariffs(ctx context.Context, name string) ([]*models.Tariff, error)
+	ActivateTariff(ctx context.Context, id uint) (*models.Tariff, error)
+}
+
+type tariffRepo struct {
+	db *gorm.DB
+}
+
+func NewTariffRepo(db *gorm.DB) TariffRepository {
+	return &tariffRepo{db: db}
+}
+
+// CreateTariff 创建新的资费版本，版本号在同名资费内自增
+func (r *tariffRepo) CreateTariff(ctx context.Context, tariff *models.Tariff) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.Model(&models.Tariff{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("name = ?", tariff.Name).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&latest).Error; err != nil {
+			return err
+		}
+		tariff.Version = latest + 1
+		tariff.Status = models.TariffDraft
+		return tx.Create(tariff).Error
+	})
+}
+
+func (r *tariffRepo) GetTariffByID(ctx context.Context, id uint) (*models.Tariff, error) {
+	var tariff models.Tariff
+	err := r.db.WithContext(ctx).First(&tariff, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrTariffNotFound
+	}
+	return &tariff, err
+}
+
+// GetActiveTariff 获取当前生效的资费，没有时返回 nil
+func (r *tariffRepo) GetActiveTariff(ctx context.Context) (*models.Tariff, error) {
+	var tariff models.Tariff
+	err := r.db.WithContext(ctx).
+		Where("status = ?", models.TariffActive).
+		Order("activated_at DESC").
+		First(&tariff).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tariff, nil
+}
+
+func (r *tariffRepo) ListTariffs(ctx context.Context, name string) ([]*models.Tariff, error) {
+	var tariffs []*models.Tariff
+	query := r.db.WithContext(ctx)
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+	err := query.Order("name ASC, version DESC").Find(&tariffs).Error
+	return tariffs, err
+}
+
+// ActivateTariff 启用指定资费版本，并将原先生效的版本归档
+func (r *tariffRepo) ActivateTariff(ctx context.Context, id uint) (*models.Tariff, error) {
+	var tariff models.Tariff
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&tariff, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return models.ErrTariffNotFound
+			}
+			return err
+		}
+		if tariff.Status == models.TariffArchived {
+			return models.ErrTariffArchived
+		}
+		if tariff.Status == models.TariffActive {
+			return nil
+		}
+
+		if err := tx.Model(&models.Tariff{}).
+			Where("status = ? AND id <> ?", models.TariffActive, id).
+			Update("status", models.TariffArchived).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		tariff.Status = models.TariffActive
+		tariff.ActivatedAt = &now
+		return tx.Model(&tariff).Updates(map[string]interface{}{
+			"status":       tariff.Status,
+			"activated_at": tariff.ActivatedAt,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &tariff, nil
+}
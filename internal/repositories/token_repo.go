@@ -0,0 +1,162 @@
+// internal/repositories/token_repo.go
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"modules/internal/models"
+	"time"
+)
+
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+	// RotateRefreshToken 在同一事务中将 current 标记为已使用并保存 next；current 已被使用或撤销时返回 false
+	RotateRefreshToken(ctx context.Context, current, next *models.RefreshToken) (bool, error)
+	// RevokeFamily 撤销同一族中全部未撤销的刷新令牌
+	RevokeFamily(ctx context.Context, familyID string) error
+	// RevokeAccessToken 将访问令牌加入撤销名单，重复撤销时忽略
+	RevokeAccessToken(ctx context.Context, token *models.RevokedToken) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	// RevokeUserRefreshTokens 撤销用户全部未撤销的刷新令牌
+	RevokeUserRefreshTokens(ctx context.Context, userID uint) error
+	// RevokeOtherRefreshTokens 撤销用户除 keepFamilyID 外全部未撤销的刷新令牌
+	RevokeOtherRefreshTokens(ctx context.Context, userID uint, keepFamilyID string) error
+
+	// CreateUserToken 保存一次性令牌，同时作废该用户同一用途尚未使用的旧令牌
+	CreateUserToken(ctx context.Context, token *models.UserToken) error
+	GetUserToken(ctx context.Context, purpose models.UserTokenPurpose, hash string) (*models.UserToken, error)
+	// ConsumeUserToken 标记一次性令牌已使用，令牌已被使用时返回 false
+	ConsumeUserToken(ctx context.Context, id uint) (bool, error)
+
+	// DeleteExpired 清理 before 之前过期的刷新令牌、撤销记录和一次性令牌
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+type tokenRepo struct {
+	db *gorm.DB
+}
+
+func NewTokenRepo(db *gorm.DB) TokenRepository {
+	return &tokenRepo{db: db}
+}
+
+func (r *tokenRepo) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *tokenRepo) GetRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrInvalidRefreshToken
+	}
+	return &token, err
+}
+
+func (r *tokenRepo) RotateRefreshToken(ctx context.Context, current, next *models.RefreshToken) (bool, error) {
+	rotated := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", current.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		current.UsedAt = &now
+		rotated = true
+		return nil
+	})
+	return rotated, err
+}
+
+func (r *tokenRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	return r.db.WithContext(ctx).
+		Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *tokenRepo) RevokeUserRefreshTokens(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).
+		Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *tokenRepo) RevokeOtherRefreshTokens(ctx context.Context, userID uint, keepFamilyID string) error {
+	return r.db.WithContext(ctx).
+		Model(&models.RefreshToken{}).
+		Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, keepFamilyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *tokenRepo) RevokeAccessToken(ctx context.Context, token *models.RevokedToken) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(token).Error
+}
+
+func (r *tokenRepo) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.RevokedToken{}).
+		Where("jti = ?", jti).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *tokenRepo) CreateUserToken(ctx context.Context, token *models.UserToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserID, token.Purpose).
+			Update("expires_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+func (r *tokenRepo) GetUserToken(
+	ctx context.Context,
+	purpose models.UserTokenPurpose,
+	hash string,
+) (*models.UserToken, error) {
+	var token models.UserToken
+	err := r.db.WithContext(ctx).
+		Where("purpose = ? AND token_hash = ?", purpose, hash).
+		First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrInvalidUserToken
+	}
+	return &token, err
+}
+
+func (r *tokenRepo) ConsumeUserToken(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.UserToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *tokenRepo) DeleteExpired(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at < ?", before).Delete(&models.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("expires_at < ?", before).Delete(&models.UserToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("expires_at < ?", before).Delete(&models.RevokedToken{}).Error
+	})
+}
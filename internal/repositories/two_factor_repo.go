@@ -0,0 +1,131 @@
+// internal/repositories/two_factor_repo.go
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"modules/internal/models"
+	"time"
+)
+
+type TwoFactorRepository interface {
+	// GetByUserID 查询用户的两步验证配置，不存在时返回 nil
+	GetByUserID(ctx context.Context, userID uint) (*models.UserTwoFactor, error)
+	// SavePending 保存尚未启用的密钥，覆盖之前未确认的密钥
+	SavePending(ctx context.Context, userID uint, secret string) error
+	// Enable 启用两步验证并替换恢复码
+	Enable(ctx context.Context, userID uint, step int64, codeHashes []string) error
+	// MarkStepUsed 记录验证通过的时间步，step 不大于已记录的值时返回 false，防止验证码重放
+	MarkStepUsed(ctx context.Context, userID uint, step int64) (bool, error)
+	// ReplaceRecoveryCodes 作废旧恢复码并保存新恢复码
+	ReplaceRecoveryCodes(ctx context.Context, userID uint, codeHashes []string) error
+	// ConsumeRecoveryCode 使用一个恢复码，不存在或已使用时返回 false
+	ConsumeRecoveryCode(ctx context.Context, userID uint, codeHash string) (bool, error)
+	// CountRecoveryCodes 未使用的恢复码数量
+	CountRecoveryCodes(ctx context.Context, userID uint) (int64, error)
+	// Delete 删除用户的两步验证配置和全部恢复码
+	Delete(ctx context.Context, userID uint) error
+}
+
+type twoFactorRepo struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorRepo(db *gorm.DB) TwoFactorRepository {
+	return &twoFactorRepo{db: db}
+}
+
+func (r *twoFactorRepo) GetByUserID(ctx context.Context, userID uint) (*models.UserTwoFactor, error) {
+	var tf models.UserTwoFactor
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&tf).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tf, nil
+}
+
+func (r *twoFactorRepo) SavePending(ctx context.Context, userID uint, secret string) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"secret", "enabled_at", "last_used_step", "updated_at"}),
+		}).
+		Create(&models.UserTwoFactor{UserID: userID, Secret: secret}).Error
+}
+
+func (r *twoFactorRepo) Enable(ctx context.Context, userID uint, step int64, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.UserTwoFactor{}).
+			Where("user_id = ? AND enabled_at IS NULL", userID).
+			Updates(map[string]interface{}{
+				"enabled_at":     time.Now(),
+				"last_used_step": step,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrTwoFactorAlreadyEnabled
+		}
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+func (r *twoFactorRepo) MarkStepUsed(ctx context.Context, userID uint, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.UserTwoFactor{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *twoFactorRepo) ReplaceRecoveryCodes(ctx context.Context, userID uint, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	codes := make([]*models.RecoveryCode, 0, len(codeHashes))
+	for _, h := range codeHashes {
+		codes = append(codes, &models.RecoveryCode{UserID: userID, CodeHash: h})
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Create(&codes).Error
+}
+
+func (r *twoFactorRepo) ConsumeRecoveryCode(ctx context.Context, userID uint, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *twoFactorRepo) CountRecoveryCodes(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *twoFactorRepo) Delete(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.UserTwoFactor{}).Error
+	})
+}
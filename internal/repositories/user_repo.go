@@ -5,8 +5,10 @@ import (
 	"errors"
 	"go.uber.org/zap"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"modules/internal/models"
 )
@@ -18,6 +20,34 @@ type UserRepository interface {
 	CreateUser(ctx context.Context, user *models.User) error
 	GetUserByID(ctx context.Context, userID uint) (*models.User, error)
 	CheckUserExists(ctx context.Context, username, email string) (bool, error)
+	// ListUsersByRole 查询拥有指定角色的启用用户
+	ListUsersByRole(ctx context.Context, role models.Role) ([]*models.User, error)
+	// GetUserByEmail 按邮箱查询用户，不区分大小写
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	UpdatePassword(ctx context.Context, userID uint, hashedPassword string) error
+	// SetVerifiedEmail 将用户邮箱更新为已验证的 email
+	SetVerifiedEmail(ctx context.Context, userID uint, email string, verifiedAt time.Time) error
+	// ListUsers 按条件分页查询用户，按注册时间倒序
+	ListUsers(ctx context.Context, filter UserFilter) ([]*models.User, int64, error)
+	UpdateRoles(ctx context.Context, userID uint, roles models.JSONBytes) error
+	SetActive(ctx context.Context, userID uint, active bool) error
+	// UpdateProfile 更新用户可自行修改的资料
+	UpdateProfile(ctx context.Context, userID uint, phone, language string) error
+	// EmailInUse 邮箱是否已被其他用户使用，不区分大小写
+	EmailInUse(ctx context.Context, email string, exceptUserID uint) (bool, error)
+	// AnonymizeUser 注销账户：以 anonymized 中的用户名、邮箱和密码覆盖个人信息并停用账户，
+	// 删除车辆、通知、两步验证和令牌等个人数据，停车、租赁和支付记录保留。
+	// 存在进行中的租赁、停车、预约、预订申请或名下车位时返回 models.ErrAccountHasObligations
+	AnonymizeUser(ctx context.Context, user *models.User, anonymized *models.User) error
+}
+
+type UserFilter struct {
+	// 按用户名、邮箱或手机号模糊匹配
+	Query  string
+	Role   models.Role
+	Active *bool
+	Offset int
+	Limit  int
 }
 
 type userRepo struct {
@@ -91,3 +121,169 @@ func (r *userRepo) CheckUserExists(ctx context.Context, username, email string)
 	}
 	return count > 0, nil
 }
+
+func (r *userRepo) ListUsersByRole(ctx context.Context, role models.Role) ([]*models.User, error) {
+	var users []*models.User
+	err := r.db.WithContext(ctx).
+		Where("JSON_CONTAINS(roles, JSON_QUOTE(?)) AND is_active = ?", string(role), true).
+		Find(&users).Error
+	return users, err
+}
+
+func (r *userRepo) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).
+		Where("LOWER(email) = ?", strings.ToLower(strings.TrimSpace(email))).
+		First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepo) UpdatePassword(ctx context.Context, userID uint, hashedPassword string) error {
+	return r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("password", hashedPassword).Error
+}
+
+func (r *userRepo) SetVerifiedEmail(ctx context.Context, userID uint, email string, verifiedAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"email":             email,
+			"email_verified_at": verifiedAt,
+		}).Error
+}
+
+func (r *userRepo) ListUsers(ctx context.Context, filter UserFilter) ([]*models.User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.User{})
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		like := "%" + strings.NewReplacer("%", "\\%", "_", "\\_").Replace(strings.ToLower(q)) + "%"
+		query = query.Where("LOWER(username) LIKE ? OR LOWER(email) LIKE ? OR phone LIKE ?", like, like, like)
+	}
+	if filter.Role != "" {
+		query = query.Where("JSON_CONTAINS(roles, JSON_QUOTE(?))", string(filter.Role))
+	}
+	if filter.Active != nil {
+		query = query.Where("is_active = ?", *filter.Active)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Limit > 0 {
+		query = query.Offset(filter.Offset).Limit(filter.Limit)
+	}
+	var users []*models.User
+	err := query.Order("created_at DESC, id DESC").Find(&users).Error
+	return users, total, err
+}
+
+func (r *userRepo) UpdateRoles(ctx context.Context, userID uint, roles models.JSONBytes) error {
+	return r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("roles", roles).Error
+}
+
+func (r *userRepo) SetActive(ctx context.Context, userID uint, active bool) error {
+	return r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("is_active", active).Error
+}
+
+func (r *userRepo) UpdateProfile(ctx context.Context, userID uint, phone, language string) error {
+	return r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"phone":    phone,
+			"language": language,
+		}).Error
+}
+
+func (r *userRepo) EmailInUse(ctx context.Context, email string, exceptUserID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("LOWER(email) = ? AND id <> ?", strings.ToLower(strings.TrimSpace(email)), exceptUserID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *userRepo) AnonymizeUser(ctx context.Context, user *models.User, anonymized *models.User) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 锁定用户行，避免注销过程中产生新的业务
+		var locked models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, user.ID).Error; err != nil {
+			return err
+		}
+
+		obligations := []*gorm.DB{
+			tx.Model(&models.LeaseOrder{}).
+				Where("(user_id = ? OR owner_id = ?) AND status IN ?", user.ID, user.ID,
+					[]models.LeaseStatus{models.LeasePending, models.LeaseActive}),
+			tx.Model(&models.ParkingRecord{}).Where("user_id = ? AND exit_time IS NULL", user.ID),
+			tx.Model(&models.Reservation{}).Where("user_id = ? AND status = ?", user.ID, models.ReservationBooked),
+			tx.Model(&models.BookingRequest{}).
+				Where("(renter_id = ? OR owner_id = ?) AND status = ?", user.ID, user.ID, models.BookingPending),
+			tx.Model(&models.ParkingSpot{}).Where("owner_id = ?", user.ID),
+		}
+		for _, query := range obligations {
+			var count int64
+			if err := query.Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return models.ErrAccountHasObligations
+			}
+		}
+
+		now := time.Now()
+		err := tx.Model(&models.User{}).
+			Where("id = ?", user.ID).
+			Updates(map[string]interface{}{
+				"username":          anonymized.Username,
+				"email":             anonymized.Email,
+				"password":          anonymized.Password,
+				"phone":             "",
+				"roles":             models.JSONBytes("[]"),
+				"is_active":         false,
+				"email_verified_at": nil,
+				"closed_at":         now,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		personal := []interface{}{
+			&models.Vehicle{},
+			&models.Notification{},
+			&models.NotificationPreference{},
+			&models.UserTwoFactor{},
+			&models.RecoveryCode{},
+			&models.UserToken{},
+		}
+		for _, model := range personal {
+			if err := tx.Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("scope = ? AND `key` = ?", models.LoginScopeAccount, strings.ToLower(user.Username)).
+			Delete(&models.LoginAttempt{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", user.ID).
+			Update("revoked_at", now).Error
+	})
+}
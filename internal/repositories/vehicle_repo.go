@@ -13,6 +13,7 @@ type VehicleRepository interface {
 	AddVehicle(ctx context.Context, vehicle *models.Vehicle) error
 	RemoveVehicle(ctx context.Context, userID, vehicleID uint) error
 	GetUserVehicles(ctx context.Context, userID uint) ([]*models.Vehicle, error)
+	// GetVehicleByLicense 按车牌查询登记的车辆，不存在时返回 models.ErrVehicleNotFound
 	GetVehicleByLicense(ctx context.Context, license string) (*models.Vehicle, error)
 }
 
@@ -92,7 +93,7 @@ func (r *vehicleRepo) GetVehicleByLicense(ctx context.Context, license string) (
 		First(&vehicle).Error
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, errors.New("车辆不存在")
+		return nil, models.ErrVehicleNotFound
 	}
 	return &vehicle, err
 }
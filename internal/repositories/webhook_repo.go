@@ -0,0 +1,163 @@
+// internal/repositories/webhook_repo.go
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"modules/internal/models"
+	"time"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context, activeOnly bool) ([]*models.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, id uint) error
+
+	CreateDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error
+	GetDelivery(ctx context.Context, id uint) (*models.WebhookDelivery, error)
+	// ListEventDeliveries 查询某个事件已生成的投递记录
+	ListEventDeliveries(ctx context.Context, eventID string) ([]*models.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]*models.WebhookDelivery, int64, error)
+	// ListDueDeliveries 查询到了重试时间的待投递记录
+	ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error)
+	// ClaimDelivery 以尝试次数作为版本号抢占投递，并把下一次投递时间推迟到 leaseUntil，成功抢占时返回 true
+	ClaimDelivery(ctx context.Context, id uint, attempts int, now, leaseUntil time.Time) (bool, error)
+	// SaveAttempt 在同一事务中记录一次尝试并更新投递状态
+	SaveAttempt(ctx context.Context, delivery *models.WebhookDelivery, attempt *models.WebhookAttempt) error
+}
+
+type DeliveryFilter struct {
+	SubscriptionID uint
+	Status         models.DeliveryStatus
+	Offset         int
+	Limit          int
+}
+
+type webhookRepo struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepo(db *gorm.DB) WebhookRepository {
+	return &webhookRepo{db: db}
+}
+
+func (r *webhookRepo) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Create(sub).Error
+}
+
+func (r *webhookRepo) GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	err := r.db.WithContext(ctx).First(&sub, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrWebhookNotFound
+	}
+	return &sub, err
+}
+
+func (r *webhookRepo) ListSubscriptions(ctx context.Context, activeOnly bool) ([]*models.WebhookSubscription, error) {
+	query := r.db.WithContext(ctx)
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+	var subs []*models.WebhookSubscription
+	err := query.Order("id ASC").Find(&subs).Error
+	return subs, err
+}
+
+func (r *webhookRepo) UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Save(sub).Error
+}
+
+func (r *webhookRepo) DeleteSubscription(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&models.WebhookSubscription{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (r *webhookRepo) CreateDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&deliveries).Error
+}
+
+func (r *webhookRepo) GetDelivery(ctx context.Context, id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Preload("AttemptLogs", func(db *gorm.DB) *gorm.DB { return db.Order("attempt ASC, id ASC") }).
+		First(&delivery, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.ErrDeliveryNotFound
+	}
+	return &delivery, err
+}
+
+func (r *webhookRepo) ListEventDeliveries(ctx context.Context, eventID string) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	err := r.db.WithContext(ctx).Where("event_id = ?", eventID).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *webhookRepo) ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]*models.WebhookDelivery, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.WebhookDelivery{})
+	if filter.SubscriptionID != 0 {
+		query = query.Where("subscription_id = ?", filter.SubscriptionID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Limit > 0 {
+		query = query.Offset(filter.Offset).Limit(filter.Limit)
+	}
+	var deliveries []*models.WebhookDelivery
+	err := query.Order("created_at DESC, id DESC").Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+func (r *webhookRepo) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *webhookRepo) ClaimDelivery(ctx context.Context, id uint, attempts int, now, leaseUntil time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.WebhookDelivery{}).
+		Where("id = ? AND attempts = ? AND status = ? AND next_attempt_at <= ?",
+			id, attempts, models.DeliveryPending, now).
+		Update("next_attempt_at", leaseUntil)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *webhookRepo) SaveAttempt(
+	ctx context.Context,
+	delivery *models.WebhookDelivery,
+	attempt *models.WebhookAttempt,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		attempt.DeliveryID = delivery.ID
+		if err := tx.Create(attempt).Error; err != nil {
+			return err
+		}
+		return tx.Model(delivery).Select("status", "attempts", "next_attempt_at", "last_status_code", "last_error", "updated_at").
+			Updates(delivery).Error
+	})
+}
@@ -13,15 +13,31 @@ import (
 )
 
 type RouterDependencies struct {
-	AuthService    *services.AuthService
-	AuthController *controllers.AuthController
-	ParkingService *controllers.ParkingController
-	AdminService   *controllers.AdminController
-	LeaseService   *controllers.LeaseController
-	ReportService  *controllers.ReportController
-	VehicleService *controllers.VehicleController
-	OwnerService   *controllers.OwnerController
-	Cfg            *config.Config
+	AuthService         *services.AuthService
+	PermissionService   *services.PermissionService
+	AuthController      *controllers.AuthController
+	AccountService      *controllers.AccountController
+	TwoFactorService    *controllers.TwoFactorController
+	ParkingService      *controllers.ParkingController
+	AdminService        *controllers.AdminController
+	LeaseService        *controllers.LeaseController
+	ReportService       *controllers.ReportController
+	VehicleService      *controllers.VehicleController
+	OwnerService        *controllers.OwnerController
+	TariffService       *controllers.TariffController
+	ReservationService  *controllers.ReservationController
+	PaymentService      *controllers.PaymentController
+	MarketplaceService  *controllers.MarketplaceController
+	LedgerService       *controllers.LedgerController
+	MaintenanceService  *controllers.MaintenanceController
+	AuditService        *controllers.AuditController
+	NotificationService *controllers.NotificationController
+	WebhookService      *controllers.WebhookController
+	OutboxService       *controllers.OutboxController
+	SigningKeyService   *controllers.SigningKeyController
+	UserAdminService    *controllers.UserAdminController
+	RoleService         *controllers.RoleController
+	Cfg                 *config.Config
 }
 
 // setupSwaggerRoutes 配置 Swagger 文档的访问路由
@@ -42,12 +58,55 @@ func setupPublicRoutes(router *gin.Engine, deps *RouterDependencies) {
 		public.POST("/auth/register", deps.AuthController.Register)
 		// 用户登录接口
 		public.POST("/auth/login", deps.AuthController.UserLogin)
+		// 两步验证登录接口
+		public.POST("/auth/login/2fa", deps.AuthController.VerifyLogin)
+		// 刷新令牌接口
+		public.POST("/auth/refresh", deps.AuthController.Refresh)
+		// 找回密码、重置密码接口
+		public.POST("/auth/password/forgot", deps.AccountService.ForgotPassword)
+		public.POST("/auth/password/reset", deps.AccountService.ResetPassword)
+		// 邮箱验证接口
+		public.GET("/auth/verify-email", deps.AccountService.VerifyEmail)
 		// 管理员登录接口
 		public.POST("/admin/login", deps.AdminService.AdminLogin)
+		// JWT 公钥集合，供其他服务验证访问令牌
+		public.GET("/.well-known/jwks.json", deps.SigningKeyService.JWKS)
 		// 其他无需认证的接口...
 	}
 }
 
+// setupProfileRoutes 配置当前用户资料和账户路由组
+func setupProfileRoutes(authGroup *gin.RouterGroup, deps *RouterDependencies) {
+	me := authGroup.Group("/me")
+	{
+		// 查询、修改个人资料接口
+		me.GET("", deps.AccountService.GetMe)
+		me.PUT("", deps.AccountService.UpdateMe)
+		// 修改密码接口
+		me.PUT("/password", deps.AccountService.ChangePassword)
+		// 更换邮箱接口，新邮箱验证后生效
+		me.POST("/email", deps.AccountService.ChangeEmail)
+		// 注销账户接口
+		me.DELETE("", deps.AccountService.DeleteMe)
+	}
+}
+
+// setupTwoFactorRoutes 配置两步验证相关路由组
+func setupTwoFactorRoutes(authGroup *gin.RouterGroup, deps *RouterDependencies) {
+	twoFactor := authGroup.Group("/auth/2fa")
+	{
+		// 查询两步验证状态接口
+		twoFactor.GET("", deps.TwoFactorService.GetStatus)
+		// 生成密钥、确认启用接口
+		twoFactor.POST("/setup", deps.TwoFactorService.Setup)
+		twoFactor.POST("/enable", deps.TwoFactorService.Enable)
+		// 关闭两步验证接口
+		twoFactor.POST("/disable", deps.TwoFactorService.Disable)
+		// 重新生成恢复码接口
+		twoFactor.POST("/recovery-codes", deps.TwoFactorService.RegenerateRecoveryCodes)
+	}
+}
+
 // setupVehicleRoutes 配置车辆相关路由组
 func setupVehicleRoutes(authGroup *gin.RouterGroup, deps *RouterDependencies) {
 	vehicles := authGroup.Group("/vehicles")
@@ -69,10 +128,13 @@ func setupParkingRoutes(authGroup *gin.RouterGroup, deps *RouterDependencies) {
 		parking.GET("/spots", deps.ParkingService.ListSpots)
 		// 获取用户停车位信息接口
 		parking.GET("/my-spots", deps.ParkingService.GetUserSpots)
-		// 车辆进入停车场接口
-		parking.POST("/entry", deps.ParkingService.Entry)
-		// 车辆离开停车场接口
-		parking.POST("/exit/:id", deps.ParkingService.Exit)
+		// 出入口值守：车辆入场、出场结算接口
+		parking.POST("/entry", middleware.RequirePermission(deps.PermissionService, models.PermGatesOperate), deps.ParkingService.Entry)
+		parking.POST("/exit/:id", middleware.RequirePermission(deps.PermissionService, models.PermGatesOperate), deps.ParkingService.Exit)
+		// 出场缴费接口
+		parking.POST("/exit/:id/pay", deps.ParkingService.PayExit)
+		// 出入口为非注册用户代收停车费接口
+		parking.POST("/exit/:id/gate-pay", middleware.RequirePermission(deps.PermissionService, models.PermGatesOperate), deps.ParkingService.PayGateExit)
 		// 发布车辆出租信息接口
 		parking.POST("/rent", deps.VehicleService.PublishForRent)
 	}
@@ -80,18 +142,121 @@ func setupParkingRoutes(authGroup *gin.RouterGroup, deps *RouterDependencies) {
 
 // setupLeaseRoutes 配置租赁相关路由组
 func setupLeaseRoutes(authGroup *gin.RouterGroup, deps *RouterDependencies) {
-	// 创建租赁记录接口
-	authGroup.POST("/lease", deps.LeaseService.CreateLease)
+	// 创建租赁记录接口，需要已验证邮箱
+	authGroup.POST("/lease", middleware.RequireVerifiedEmail(deps.AuthService), deps.LeaseService.CreateLease)
+	// 查询我的租赁订单接口
+	authGroup.GET("/lease", deps.LeaseService.ListLeases)
+	// 查询租赁订单详情接口
+	authGroup.GET("/lease/:id", deps.LeaseService.GetLease)
+	// 支付租赁订单接口
+	authGroup.POST("/lease/pay", deps.LeaseService.PayLease)
+	// 提前终止租赁接口
+	authGroup.POST("/lease/:id/terminate", deps.LeaseService.TerminateLease)
+	// 设置自动续租接口
+	authGroup.PUT("/lease/:id/auto-renew", deps.LeaseService.SetAutoRenew)
+	// 查询自动续租记录接口
+	authGroup.GET("/lease/:id/renewals", deps.LeaseService.ListRenewals)
+	// 查询即将自动续租的订单接口
+	authGroup.GET("/lease/renewals/upcoming", deps.LeaseService.ListUpcomingRenewals)
+}
+
+// setupPaymentRoutes 配置支付相关路由组
+func setupPaymentRoutes(authGroup *gin.RouterGroup, deps *RouterDependencies) {
+	payments := authGroup.Group("/payments")
+	{
+		// 查询自己的支付记录接口
+		payments.GET("", deps.PaymentService.ListPayments)
+		// 查询支付单接口
+		payments.GET("/:id", deps.PaymentService.GetPayment)
+	}
+}
+
+// setupMaintenanceRoutes 配置车位报修相关路由组
+func setupMaintenanceRoutes(authGroup *gin.RouterGroup, deps *RouterDependencies) {
+	maintenance := authGroup.Group("/maintenance")
+	{
+		// 上报车位故障接口
+		maintenance.POST("", deps.MaintenanceService.ReportProblem)
+		// 查询自己上报的工单接口
+		maintenance.GET("/mine", deps.MaintenanceService.ListMyTickets)
+	}
+}
+
+// setupNotificationRoutes 配置站内信与通知偏好相关路由组
+func setupNotificationRoutes(authGroup *gin.RouterGroup, deps *RouterDependencies) {
+	notifications := authGroup.Group("/notifications")
+	{
+		// 查询站内信接口
+		notifications.GET("", deps.NotificationService.ListNotifications)
+		// 标记站内信已读接口
+		notifications.POST("/:id/read", deps.NotificationService.MarkRead)
+		// 通知偏好接口
+		notifications.GET("/preferences", deps.NotificationService.GetPreferences)
+		notifications.PUT("/preferences", deps.NotificationService.UpdatePreferences)
+	}
+}
+
+// setupReservationRoutes 配置车位预约相关路由组
+func setupReservationRoutes(authGroup *gin.RouterGroup, deps *RouterDependencies) {
+	reservations := authGroup.Group("/reservations")
+	{
+		// 预约车位接口
+		reservations.POST("", deps.ReservationService.CreateReservation)
+		// 查询自己的预约接口
+		reservations.GET("", deps.ReservationService.ListReservations)
+		// 取消预约接口
+		reservations.POST("/:id/cancel", deps.ReservationService.CancelReservation)
+	}
+}
+
+// setupMarketplaceRoutes 配置车位出租市场相关路由组
+func setupMarketplaceRoutes(authGroup *gin.RouterGroup, deps *RouterDependencies) {
+	listings := authGroup.Group("/listings")
+	{
+		// 搜索出租信息接口
+		listings.GET("", deps.MarketplaceService.SearchListings)
+		// 查询出租信息详情接口
+		listings.GET("/:id", deps.MarketplaceService.GetListing)
+		// 申请预订接口，需要已验证邮箱
+		listings.POST("/:id/bookings", middleware.RequireVerifiedEmail(deps.AuthService), deps.MarketplaceService.RequestBooking)
+	}
+
+	bookings := authGroup.Group("/bookings")
+	{
+		// 查询自己的预订申请接口
+		bookings.GET("", deps.MarketplaceService.ListMyBookings)
+		// 撤回预订申请接口
+		bookings.POST("/:id/cancel", deps.MarketplaceService.CancelBooking)
+	}
 }
 
 // setupOwnerRoutes 配置业主相关路由组
 func setupOwnerRoutes(authGroup *gin.RouterGroup, deps *RouterDependencies) {
-	owner := authGroup.Group("/owner").Use(middleware.RoleCheck(models.Owner))
+	owner := authGroup.Group("/owner")
+	ps := deps.PermissionService
 	{
-		// 业主购买停车位接口
-		owner.POST("/purchase", deps.OwnerService.PurchaseSpot)
+		// 业主购买停车位接口，需要已验证邮箱
+		owner.POST("/purchase", middleware.RequirePermission(ps, models.PermOwnerSpots), middleware.RequireVerifiedEmail(deps.AuthService), deps.OwnerService.PurchaseSpot)
 		// 业主创建停车位接口
-		owner.POST("/spots", deps.ParkingService.CreateSpot)
+		owner.POST("/spots", middleware.RequirePermission(ps, models.PermOwnerSpots), deps.ParkingService.CreateSpot)
+		// 查询业主车位上的租赁订单接口
+		owner.GET("/leases", middleware.RequirePermission(ps, models.PermListingsManage), deps.LeaseService.ListOwnerLeases)
+		// 发布出租信息接口
+		owner.POST("/listings", middleware.RequirePermission(ps, models.PermListingsManage), deps.MarketplaceService.CreateListing)
+		// 查询自己发布的出租信息接口
+		owner.GET("/listings", middleware.RequirePermission(ps, models.PermListingsManage), deps.MarketplaceService.ListOwnerListings)
+		// 下架出租信息接口
+		owner.POST("/listings/:id/close", middleware.RequirePermission(ps, models.PermListingsManage), deps.MarketplaceService.CloseListing)
+		// 查询收到的预订申请接口
+		owner.GET("/bookings", middleware.RequirePermission(ps, models.PermListingsManage), deps.MarketplaceService.ListOwnerBookings)
+		// 接受预订申请接口
+		owner.POST("/bookings/:id/accept", middleware.RequirePermission(ps, models.PermLeasesApprove), deps.MarketplaceService.AcceptBooking)
+		// 拒绝预订申请接口
+		owner.POST("/bookings/:id/decline", middleware.RequirePermission(ps, models.PermLeasesApprove), deps.MarketplaceService.DeclineBooking)
+		// 查询月度对账单接口
+		owner.GET("/statements", middleware.RequirePermission(ps, models.PermStatementsRead), deps.LedgerService.GetOwnerStatement)
+		// 导出月度对账单接口
+		owner.GET("/statements/export", middleware.RequirePermission(ps, models.PermStatementsRead), deps.LedgerService.ExportOwnerStatement)
 	}
 }
 
@@ -100,9 +265,20 @@ func setupAuthRoutes(router *gin.Engine, deps *RouterDependencies) {
 	authGroup := router.Group("/")
 	applyAuthMiddleware(authGroup, deps)
 
+	// 退出登录接口
+	authGroup.POST("/auth/logout", deps.AuthController.Logout)
+	// 重新发送验证邮件接口
+	authGroup.POST("/auth/verify-email/resend", deps.AccountService.ResendVerification)
+	setupProfileRoutes(authGroup, deps)
+	setupTwoFactorRoutes(authGroup, deps)
 	setupVehicleRoutes(authGroup, deps)
 	setupParkingRoutes(authGroup, deps)
 	setupLeaseRoutes(authGroup, deps)
+	setupReservationRoutes(authGroup, deps)
+	setupMarketplaceRoutes(authGroup, deps)
+	setupPaymentRoutes(authGroup, deps)
+	setupMaintenanceRoutes(authGroup, deps)
+	setupNotificationRoutes(authGroup, deps)
 	setupOwnerRoutes(authGroup, deps)
 }
 
@@ -111,8 +287,10 @@ func setupReportRoutes(router *gin.Engine, deps *RouterDependencies) {
 	report := router.Group("/reports")
 	applyAuthMiddleware(report, deps)
 	{
-		// 获取每日报表信息接口
-		report.GET("/daily", deps.ReportService.GetDailyReport)
+		// 获取每日报表信息接口，包含收入数据，需要财务权限
+		report.GET("/daily", middleware.RequirePermission(deps.PermissionService, models.PermFinanceRead), deps.ReportService.GetDailyReport)
+		// 收入报表接口，需要财务权限
+		report.GET("/revenue", middleware.RequirePermission(deps.PermissionService, models.PermFinanceRead), deps.ReportService.GetRevenueReport)
 	}
 }
 
@@ -120,18 +298,74 @@ func setupReportRoutes(router *gin.Engine, deps *RouterDependencies) {
 func setupAdminRoutes(router *gin.Engine, deps *RouterDependencies) {
 	adminGroup := router.Group("/admin")
 	applyAuthMiddleware(adminGroup, deps)
-	adminGroup.Use(middleware.RoleCheck(models.Admin))
+	// 各接口按所需权限授权，管理员角色拥有全部权限
+	ps := deps.PermissionService
 	{
 		// 更新车位状态接口
-		adminGroup.PUT("/spots/:id/status", deps.AdminService.UpdateSpotStatus)
+		adminGroup.PUT("/spots/:id/status", middleware.RequirePermission(ps, models.PermSpotsWrite), deps.AdminService.UpdateSpotStatus)
+		// 故障车位自动恢复策略接口
+		adminGroup.GET("/spots/:id/recovery-policy", middleware.RequirePermission(ps, models.PermSpotsRead), deps.AdminService.GetRecoveryPolicy)
+		adminGroup.PUT("/spots/:id/recovery-policy", middleware.RequirePermission(ps, models.PermSpotsWrite), deps.AdminService.SetRecoveryPolicy)
+		// 审计事件查询接口
+		adminGroup.GET("/audit-events", middleware.RequirePermission(ps, models.PermAuditRead), deps.AuditService.ListEvents)
 		// 获取系统统计数据接口
-		adminGroup.GET("/stats", deps.AdminService.GetSystemStats)
-		adminGroup.POST("/bind-parking", deps.AdminService.BindParkingToUser)
+		adminGroup.GET("/stats", middleware.RequirePermission(ps, models.PermReportsRead), deps.AdminService.GetSystemStats)
+		adminGroup.POST("/bind-parking", middleware.RequirePermission(ps, models.PermSpotsAssign), deps.AdminService.BindParkingToUser)
 		// 解除车位与用户绑定接口
-		adminGroup.POST("/unbind-parking", deps.AdminService.UnbindParkingFromUser)
-		adminGroup.GET("/users/:username", deps.AdminService.GetUserInfo)
+		adminGroup.POST("/unbind-parking", middleware.RequirePermission(ps, models.PermSpotsAssign), deps.AdminService.UnbindParkingFromUser)
+		adminGroup.GET("/users/:username", middleware.RequirePermission(ps, models.PermUsersRead), deps.AdminService.GetUserInfo)
+		// 解除账户登录锁定接口
+		adminGroup.POST("/users/:id/unlock", middleware.RequirePermission(ps, models.PermUsersWrite), deps.AdminService.UnlockUser)
+		// 用户管理接口：列表搜索、角色分配、启用停用、强制重置密码
+		adminGroup.GET("/users", middleware.RequirePermission(ps, models.PermUsersRead), deps.UserAdminService.ListUsers)
+		adminGroup.PUT("/users/:id/roles", middleware.RequirePermission(ps, models.PermUsersWrite, models.PermRolesManage), deps.UserAdminService.UpdateRoles)
+		adminGroup.POST("/users/:id/activate", middleware.RequirePermission(ps, models.PermUsersWrite), deps.UserAdminService.ActivateUser)
+		adminGroup.POST("/users/:id/deactivate", middleware.RequirePermission(ps, models.PermUsersWrite), deps.UserAdminService.DeactivateUser)
+		adminGroup.POST("/users/:id/password-reset", middleware.RequirePermission(ps, models.PermUsersWrite), deps.UserAdminService.ForcePasswordReset)
+		// 重置用户两步验证接口
+		adminGroup.POST("/users/:id/2fa/reset", middleware.RequirePermission(ps, models.PermUsersWrite), deps.TwoFactorService.ResetUser)
 		// 查询车位绑定用户信息接口
-		adminGroup.GET("parking/:parkingID/bind-user", deps.AdminService.GetParkingBindUser)
+		adminGroup.GET("parking/:parkingID/bind-user", middleware.RequirePermission(ps, models.PermSpotsRead), deps.AdminService.GetParkingBindUser)
+		// 资费管理接口
+		adminGroup.POST("/tariffs", middleware.RequirePermission(ps, models.PermTariffsWrite), deps.TariffService.CreateTariff)
+		adminGroup.GET("/tariffs", middleware.RequirePermission(ps, models.PermTariffsRead), deps.TariffService.ListTariffs)
+		adminGroup.GET("/tariffs/:id", middleware.RequirePermission(ps, models.PermTariffsRead), deps.TariffService.GetTariff)
+		adminGroup.POST("/tariffs/:id/activate", middleware.RequirePermission(ps, models.PermTariffsWrite), deps.TariffService.ActivateTariff)
+		// 停车记录计费说明接口
+		adminGroup.GET("/parking-records/:id/fee", middleware.RequireAnyPermission(ps, models.PermGatesOperate, models.PermFinanceRead), deps.TariffService.ExplainRecordFee)
+		// 退款审核接口
+		adminGroup.GET("/refunds", middleware.RequireAnyPermission(ps, models.PermRefundsApprove, models.PermFinanceRead), deps.PaymentService.ListRefunds)
+		adminGroup.POST("/refunds/:id/approve", middleware.RequirePermission(ps, models.PermRefundsApprove), deps.PaymentService.ApproveRefund)
+		adminGroup.POST("/refunds/:id/reject", middleware.RequirePermission(ps, models.PermRefundsApprove), deps.PaymentService.RejectRefund)
+		// 维修工单管理接口
+		adminGroup.GET("/maintenance", middleware.RequirePermission(ps, models.PermMaintenanceManage), deps.MaintenanceService.ListTickets)
+		adminGroup.GET("/maintenance/:id", middleware.RequirePermission(ps, models.PermMaintenanceManage), deps.MaintenanceService.GetTicket)
+		adminGroup.POST("/maintenance/:id/triage", middleware.RequirePermission(ps, models.PermMaintenanceManage), deps.MaintenanceService.TriageTicket)
+		adminGroup.POST("/maintenance/:id/assign", middleware.RequirePermission(ps, models.PermMaintenanceManage), deps.MaintenanceService.AssignTicket)
+		adminGroup.POST("/maintenance/:id/resolve", middleware.RequirePermission(ps, models.PermMaintenanceManage), deps.MaintenanceService.ResolveTicket)
+		// Webhook 订阅管理接口
+		adminGroup.POST("/webhooks", middleware.RequirePermission(ps, models.PermIntegrationsManage), deps.WebhookService.CreateWebhook)
+		adminGroup.GET("/webhooks", middleware.RequirePermission(ps, models.PermIntegrationsManage), deps.WebhookService.ListWebhooks)
+		adminGroup.GET("/webhooks/:id", middleware.RequirePermission(ps, models.PermIntegrationsManage), deps.WebhookService.GetWebhook)
+		adminGroup.PUT("/webhooks/:id", middleware.RequirePermission(ps, models.PermIntegrationsManage), deps.WebhookService.UpdateWebhook)
+		adminGroup.DELETE("/webhooks/:id", middleware.RequirePermission(ps, models.PermIntegrationsManage), deps.WebhookService.DeleteWebhook)
+		// Webhook 投递记录与重放接口
+		adminGroup.GET("/webhooks/:id/deliveries", middleware.RequirePermission(ps, models.PermIntegrationsManage), deps.WebhookService.ListDeliveries)
+		adminGroup.GET("/webhooks/deliveries/:id", middleware.RequirePermission(ps, models.PermIntegrationsManage), deps.WebhookService.GetDelivery)
+		adminGroup.POST("/webhooks/deliveries/:id/replay", middleware.RequirePermission(ps, models.PermIntegrationsManage), deps.WebhookService.ReplayDelivery)
+		// 发件箱事件查询与重试接口
+		adminGroup.GET("/outbox", middleware.RequirePermission(ps, models.PermIntegrationsManage), deps.OutboxService.ListEvents)
+		adminGroup.GET("/outbox/:id", middleware.RequirePermission(ps, models.PermIntegrationsManage), deps.OutboxService.GetEvent)
+		adminGroup.POST("/outbox/:id/retry", middleware.RequirePermission(ps, models.PermIntegrationsManage), deps.OutboxService.RetryEvent)
+		// JWT 签名密钥查询与轮换接口
+		adminGroup.GET("/signing-keys", middleware.RequirePermission(ps, models.PermSigningKeysManage), deps.SigningKeyService.ListKeys)
+		adminGroup.POST("/signing-keys/rotate", middleware.RequirePermission(ps, models.PermSigningKeysManage), deps.SigningKeyService.RotateKey)
+		// 角色与权限管理接口
+		adminGroup.GET("/permissions", middleware.RequirePermission(ps, models.PermRolesManage), deps.RoleService.ListPermissions)
+		adminGroup.GET("/roles", middleware.RequirePermission(ps, models.PermRolesManage), deps.RoleService.ListRoles)
+		adminGroup.POST("/roles", middleware.RequirePermission(ps, models.PermRolesManage), deps.RoleService.CreateRole)
+		adminGroup.PUT("/roles/:name", middleware.RequirePermission(ps, models.PermRolesManage), deps.RoleService.UpdateRole)
+		adminGroup.DELETE("/roles/:name", middleware.RequirePermission(ps, models.PermRolesManage), deps.RoleService.DeleteRole)
 	}
 }
 
@@ -0,0 +1,378 @@
+// internal/services/account_service.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"modules/config"
+	"modules/internal/models"
+	"modules/internal/repositories"
+	"modules/internal/utils"
+	"modules/pkg/logger"
+	"modules/pkg/notifier"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 一次性令牌有效期默认值
+const (
+	defaultPasswordResetTTL     = time.Hour
+	defaultEmailVerificationTTL = 48 * time.Hour
+)
+
+// 手机号：可带国际区号前缀 +，6 到 15 位数字
+var phoneRegex = regexp.MustCompile(`^\+?[0-9]{6,15}$`)
+
+// AccountService 账户安全流程：邮箱验证和找回密码，令牌一次有效并通过邮件发送；
+// 以及用户自助修改资料、密码、邮箱和注销账户
+type AccountService struct {
+	userRepo      repositories.UserRepository
+	tokenRepo     repositories.TokenRepository
+	notifications *NotificationService
+	cfg           config.AccountConfig
+}
+
+func NewAccountService(
+	ur repositories.UserRepository,
+	tr repositories.TokenRepository,
+	ns *NotificationService,
+	cfg *config.Config,
+) *AccountService {
+	return &AccountService{
+		userRepo:      ur,
+		tokenRepo:     tr,
+		notifications: ns,
+		cfg:           cfg.Account,
+	}
+}
+
+// SendVerificationEmail 向 email 发送验证邮件，验证通过后该邮箱成为用户的已验证邮箱
+func (s *AccountService) SendVerificationEmail(ctx context.Context, user *models.User, email string) error {
+	ttl := parseTokenTTL(s.cfg.EmailVerificationTTL, defaultEmailVerificationTTL)
+	token, err := s.issueToken(ctx, user.ID, models.TokenEmailVerification, email, ttl)
+	if err != nil {
+		return err
+	}
+
+	return s.notifications.SendEmail(ctx, user, email, models.NotifyEmailVerification, notifier.EmailVerificationData{
+		Email:     email,
+		Token:     token,
+		VerifyURL: withToken(s.cfg.VerifyEmailURL, token),
+		ExpiresIn: notifier.FormatDuration(ttl, user.Language),
+	})
+}
+
+// ResendVerification 重新发送当前邮箱的验证邮件
+func (s *AccountService) ResendVerification(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+	if user == nil {
+		return models.ErrUserNotFound
+	}
+	if user.EmailVerified() {
+		return models.ErrEmailAlreadyVerified
+	}
+	return s.SendVerificationEmail(ctx, user, user.Email)
+}
+
+// VerifyEmail 使用验证令牌确认邮箱
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	userToken, err := s.consumeToken(ctx, models.TokenEmailVerification, token)
+	if err != nil {
+		return nil, err
+	}
+
+	// 发出验证邮件后该邮箱可能已被其他账户验证
+	inUse, err := s.userRepo.EmailInUse(ctx, userToken.Email, userToken.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("查询邮箱失败: %w", err)
+	}
+	if inUse {
+		return nil, models.ErrEmailInUse
+	}
+	if err := s.userRepo.SetVerifiedEmail(ctx, userToken.UserID, userToken.Email, time.Now()); err != nil {
+		return nil, fmt.Errorf("更新邮箱验证状态失败: %w", err)
+	}
+	logger.Log.Info("邮箱验证成功", zap.Uint("userID", userToken.UserID))
+	return s.userRepo.GetUserByID(ctx, userToken.UserID)
+}
+
+// ForgotPassword 向账户邮箱发送密码重置邮件。邮箱不存在时同样返回成功，避免泄露注册信息
+func (s *AccountService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			logger.Log.Info("找回密码的邮箱未注册")
+			return nil
+		}
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+
+	// 发送失败同样只记录日志，否则响应会暴露该邮箱已注册
+	if err := s.SendPasswordReset(ctx, user); err != nil {
+		logger.Log.Error("发送密码重置邮件失败", zap.Uint("userID", user.ID), zap.Error(err))
+	}
+	return nil
+}
+
+// SendPasswordReset 签发密码重置令牌并发送到账户邮箱
+func (s *AccountService) SendPasswordReset(ctx context.Context, user *models.User) error {
+	ttl := parseTokenTTL(s.cfg.PasswordResetTTL, defaultPasswordResetTTL)
+	token, err := s.issueToken(ctx, user.ID, models.TokenPasswordReset, user.Email, ttl)
+	if err != nil {
+		return err
+	}
+	return s.notifications.SendEmail(ctx, user, user.Email, models.NotifyPasswordReset, notifier.PasswordResetData{
+		Token:     token,
+		ResetURL:  withToken(s.cfg.PasswordResetURL, token),
+		ExpiresIn: notifier.FormatDuration(ttl, user.Language),
+	})
+}
+
+// ResetPassword 使用重置令牌设置新密码，并撤销该用户全部刷新令牌
+func (s *AccountService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+	userToken, err := s.consumeToken(ctx, models.TokenPasswordReset, token)
+	if err != nil {
+		return err
+	}
+
+	hashed, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("密码加密失败: %w", err)
+	}
+	if err := s.userRepo.UpdatePassword(ctx, userToken.UserID, hashed); err != nil {
+		return fmt.Errorf("更新密码失败: %w", err)
+	}
+	if err := s.tokenRepo.RevokeUserRefreshTokens(ctx, userToken.UserID); err != nil {
+		logger.Log.Error("重置密码后撤销刷新令牌失败", zap.Uint("userID", userToken.UserID), zap.Error(err))
+	}
+
+	// 能收到重置邮件说明邮箱属于用户本人
+	user, err := s.userRepo.GetUserByID(ctx, userToken.UserID)
+	if err == nil && user != nil && !user.EmailVerified() && user.Email == userToken.Email {
+		if err := s.userRepo.SetVerifiedEmail(ctx, user.ID, user.Email, time.Now()); err != nil {
+			logger.Log.Error("重置密码后更新邮箱验证状态失败", zap.Uint("userID", user.ID), zap.Error(err))
+		}
+	}
+
+	logger.Log.Info("密码已重置", zap.Uint("userID", userToken.UserID))
+	return nil
+}
+
+// GetProfile 查询当前用户资料
+func (s *AccountService) GetProfile(ctx context.Context, userID uint) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	if user == nil {
+		return nil, models.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// UpdateProfile 修改手机号和通知语言，phone 为空表示清除手机号
+func (s *AccountService) UpdateProfile(ctx context.Context, userID uint, phone, language string) (*models.User, error) {
+	phone = strings.TrimSpace(phone)
+	if phone != "" && !phoneRegex.MatchString(phone) {
+		return nil, models.ErrInvalidPhone
+	}
+	if language != notifier.LangZH && language != notifier.LangEN {
+		return nil, models.ErrInvalidLanguage
+	}
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UpdateProfile(ctx, userID, phone, language); err != nil {
+		return nil, fmt.Errorf("更新用户资料失败: %w", err)
+	}
+	user.Phone = phone
+	user.Language = language
+	return user, nil
+}
+
+// ChangePassword 校验当前密码后设置新密码，并撤销当前会话以外的全部刷新令牌
+func (s *AccountService) ChangePassword(
+	ctx context.Context,
+	userID uint,
+	sessionID string,
+	currentPassword string,
+	newPassword string,
+) error {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !utils.CheckPasswordHash(currentPassword, user.Password) {
+		return models.ErrIncorrectPassword
+	}
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+
+	hashed, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("密码加密失败: %w", err)
+	}
+	if err := s.userRepo.UpdatePassword(ctx, userID, hashed); err != nil {
+		return fmt.Errorf("更新密码失败: %w", err)
+	}
+	if err := s.tokenRepo.RevokeOtherRefreshTokens(ctx, userID, sessionID); err != nil {
+		logger.Log.Error("修改密码后撤销其他会话失败", zap.Uint("userID", userID), zap.Error(err))
+	}
+
+	logger.Log.Info("密码已修改", zap.Uint("userID", userID))
+	return nil
+}
+
+// ChangeEmail 校验当前密码后向新邮箱发送验证邮件，验证通过后账户邮箱才会更新
+func (s *AccountService) ChangeEmail(ctx context.Context, userID uint, password, newEmail string) error {
+	newEmail = strings.TrimSpace(newEmail)
+	if !emailRegex.MatchString(newEmail) {
+		return models.ErrInvalidEmail
+	}
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !utils.CheckPasswordHash(password, user.Password) {
+		return models.ErrIncorrectPassword
+	}
+	inUse, err := s.userRepo.EmailInUse(ctx, newEmail, userID)
+	if err != nil {
+		return fmt.Errorf("查询邮箱失败: %w", err)
+	}
+	if inUse {
+		return models.ErrEmailInUse
+	}
+
+	if err := s.SendVerificationEmail(ctx, user, newEmail); err != nil {
+		return err
+	}
+	logger.Log.Info("已发送更换邮箱的验证邮件", zap.Uint("userID", userID))
+	return nil
+}
+
+// DeleteAccount 校验当前密码后注销账户：匿名化个人信息并停用账户，撤销全部会话。
+// 停车、租赁和支付记录作为财务记录保留，关联到匿名化后的用户
+func (s *AccountService) DeleteAccount(ctx context.Context, userID uint, password string) error {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !utils.CheckPasswordHash(password, user.Password) {
+		return models.ErrIncorrectPassword
+	}
+	roles, err := userRoles(user)
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if role == models.Admin {
+			return models.ErrAdminAccountDeletion
+		}
+	}
+
+	// 随机密码使注销后的账户无法再登录
+	hashed, err := utils.HashPassword(randomToken(32))
+	if err != nil {
+		return fmt.Errorf("密码加密失败: %w", err)
+	}
+	anonymized := &models.User{
+		Username: fmt.Sprintf("deleted_%d", user.ID),
+		Email:    fmt.Sprintf("deleted-%d@deleted.invalid", user.ID),
+		Password: hashed,
+	}
+	if err := s.userRepo.AnonymizeUser(ctx, user, anonymized); err != nil {
+		if errors.Is(err, models.ErrAccountHasObligations) {
+			return err
+		}
+		return fmt.Errorf("注销账户失败: %w", err)
+	}
+
+	logger.Log.Info("账户已注销", zap.Uint("userID", userID))
+	return nil
+}
+
+// issueToken 生成一次性令牌并保存摘要，返回明文令牌
+func (s *AccountService) issueToken(
+	ctx context.Context,
+	userID uint,
+	purpose models.UserTokenPurpose,
+	email string,
+	ttl time.Duration,
+) (string, error) {
+	token := randomToken(32)
+	if err := s.tokenRepo.CreateUserToken(ctx, &models.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		Email:     email,
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return "", fmt.Errorf("保存令牌失败: %w", err)
+	}
+	return token, nil
+}
+
+// consumeToken 校验并作废一次性令牌
+func (s *AccountService) consumeToken(
+	ctx context.Context,
+	purpose models.UserTokenPurpose,
+	token string,
+) (*models.UserToken, error) {
+	if token == "" {
+		return nil, models.ErrInvalidUserToken
+	}
+	userToken, err := s.tokenRepo.GetUserToken(ctx, purpose, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if userToken.UsedAt != nil || time.Now().After(userToken.ExpiresAt) {
+		return nil, models.ErrInvalidUserToken
+	}
+	consumed, err := s.tokenRepo.ConsumeUserToken(ctx, userToken.ID)
+	if err != nil {
+		return nil, fmt.Errorf("更新令牌状态失败: %w", err)
+	}
+	if !consumed {
+		return nil, models.ErrInvalidUserToken
+	}
+	return userToken, nil
+}
+
+// validatePassword 校验新密码强度
+func validatePassword(password string) error {
+	if utf8.RuneCountInString(password) < models.MinPasswordLength {
+		return models.ErrWeakPassword
+	}
+	return nil
+}
+
+// withToken 将令牌以 token 参数附加到地址上，地址为空时返回空串
+func withToken(base, token string) string {
+	if base == "" {
+		return ""
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		logger.Log.Warn("解析邮件链接地址失败", zap.String("url", base), zap.Error(err))
+		return ""
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,85 @@
+// internal/services/audit_service.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"modules/internal/models"
+	"modules/internal/repositories"
+	"modules/pkg/logger"
+)
+
+// AuditService 记录审计事件，并将系统自动处理的结果通知管理员
+type AuditService struct {
+	auditRepo     repositories.AuditRepository
+	userRepo      repositories.UserRepository
+	notifications *NotificationService
+}
+
+func NewAuditService(
+	ar repositories.AuditRepository,
+	ur repositories.UserRepository,
+	ns *NotificationService,
+) *AuditService {
+	return &AuditService{
+		auditRepo:     ar,
+		userRepo:      ur,
+		notifications: ns,
+	}
+}
+
+// Record 写入审计事件。审计失败不影响业务流程，仅记录日志
+func (s *AuditService) Record(
+	ctx context.Context,
+	actorID uint,
+	action, targetType string,
+	targetID uint,
+	detail string,
+) {
+	event := &models.AuditEvent{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Detail:     detail,
+	}
+	if err := s.auditRepo.CreateEvent(ctx, event); err != nil {
+		logger.Log.Error("写入审计事件失败",
+			zap.String("action", action),
+			zap.Uint("targetID", targetID),
+			zap.Error(err))
+	}
+}
+
+// RecordSystem 记录系统自动处理的事件并通知全部管理员
+func (s *AuditService) RecordSystem(
+	ctx context.Context,
+	action, targetType string,
+	targetID uint,
+	subject, detail string,
+) {
+	s.Record(ctx, 0, action, targetType, targetID, detail)
+	s.NotifyAdmins(ctx, subject, detail)
+}
+
+// NotifyAdmins 向全部启用的管理员发送通知
+func (s *AuditService) NotifyAdmins(ctx context.Context, subject, message string) {
+	admins, err := s.userRepo.ListUsersByRole(ctx, models.Admin)
+	if err != nil {
+		logger.Log.Error("查询管理员失败", zap.Error(err))
+		return
+	}
+	for _, admin := range admins {
+		s.notifications.NotifyMessage(ctx, admin.ID, models.NotifyAdminAlert, subject, message)
+	}
+}
+
+// ListEvents 分页查询审计事件
+func (s *AuditService) ListEvents(ctx context.Context, filter repositories.AuditFilter) ([]*models.AuditEvent, int64, error) {
+	events, total, err := s.auditRepo.ListEvents(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询审计事件失败: %w", err)
+	}
+	return events, total, nil
+}
@@ -3,51 +3,107 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5" // 导入 JWT 包
-	"gorm.io/gorm"
+	"go.uber.org/zap"
 	"log"
 	"modules/config"
 	"modules/internal/models"
 	"modules/internal/repositories"
-	"modules/internal/utils"
+	"modules/pkg/logger"
 	"regexp"
 	"time"
 )
 
+// 令牌有效期默认值
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
 type AuthService struct {
-	userRepo repositories.UserRepository
-	Cfg      *config.Config
+	userRepo  repositories.UserRepository
+	tokenRepo repositories.TokenRepository
+	limiter   *loginLimiter
+	twoFactor *TwoFactorService
+	issuer    *TokenIssuer
+	Cfg       *config.Config
 }
 
-// Claims 定义 JWT 声明结构
+// Claims 定义 JWT 声明结构；ID（jti）用于撤销，SessionID 为签发时所属的刷新令牌族
 type Claims struct {
-	UserID   uint     `json:"user_id"`
-	Username string   `json:"username"`
-	Roles    []string `json:"roles"`
+	UserID    uint     `json:"user_id"`
+	Username  string   `json:"username"`
+	Roles     []string `json:"roles"`
+	SessionID string   `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func NewAuthService(userRepo repositories.UserRepository, cfg *config.Config) *AuthService {
+// LoginResult 登录结果：无需两步验证时直接签发令牌，否则返回登录挑战
+type LoginResult struct {
+	Tokens    *TokenPair
+	Challenge *LoginChallenge
+	// 登录过程中完成两步验证绑定时返回的恢复码，只展示这一次
+	RecoveryCodes []string
+}
+
+// LoginChallenge 密码校验通过后等待两步验证的挑战
+type LoginChallenge struct {
+	Token     string
+	ExpiresIn time.Duration
+	// 必须启用两步验证但尚未绑定时，返回新生成的密钥，用验证码确认后即完成绑定
+	Setup *TwoFactorSetup
+}
+
+// TokenPair 登录或刷新后返回的令牌
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	// 访问令牌有效期
+	ExpiresIn time.Duration
+}
+
+func NewAuthService(
+	userRepo repositories.UserRepository,
+	tokenRepo repositories.TokenRepository,
+	attemptRepo repositories.LoginAttemptRepository,
+	twoFactor *TwoFactorService,
+	issuer *TokenIssuer,
+	cfg *config.Config,
+) *AuthService {
 	return &AuthService{
-		userRepo: userRepo,
-		Cfg:      cfg,
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
+		limiter:   newLoginLimiter(attemptRepo, cfg.Lockout),
+		twoFactor: twoFactor,
+		issuer:    issuer,
+		Cfg:       cfg,
 	}
 }
 
+// dummyPasswordHash 用户不存在时用于校验的 bcrypt 摘要，不对应任何账户
+const dummyPasswordHash = "$2a$10$4W6lsWTvxxjODsDsTV1Ww.eJ1k/Kd7g02U3k4eks53TUqL75T6Z7i"
+
 // 邮箱正则表达式
 var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 
-// 用户注册
-func (s *AuthService) Register(ctx context.Context, username, password, email string) error {
+// Register 用户注册，新用户的邮箱需要验证后才能租赁、购买车位
+func (s *AuthService) Register(ctx context.Context, username, password, email string) (*models.User, error) {
+	if !emailRegex.MatchString(email) {
+		return nil, models.ErrInvalidEmail
+	}
+
 	// 检查用户是否已存在
 	exists, err := s.userRepo.CheckUserExists(ctx, username, email)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if exists {
-		return errors.New("用户名或邮箱已存在")
+		return nil, models.ErrUserExists
 	}
 
 	// 创建用户对象
@@ -59,84 +115,118 @@ func (s *AuthService) Register(ctx context.Context, username, password, email st
 
 	// 对密码进行哈希处理
 	if err := user.HashPassword(); err != nil {
-		return err
+		return nil, err
 	}
 
 	// 将用户信息保存到数据库
-	return s.userRepo.CreateUser(ctx, user)
-}
-
-// GenerateToken 生成 JWT 令牌
-func (s *AuthService) GenerateToken(userID uint, username string, roles []string) (string, error) {
-	expiresIn, err := time.ParseDuration(s.Cfg.JWT.ExpiresIn)
-	if err != nil {
-		return "", fmt.Errorf("解析令牌过期时间失败: %w", err)
+	if err := s.userRepo.CreateUser(ctx, user); err != nil {
+		return nil, err
 	}
-	return GenerateJWT(s.Cfg.JWT.Secret, userID, username, roles, expiresIn)
+	return user, nil
 }
 
-// GenerateJWT 生成 JWT 令牌
-func GenerateJWT(secret string, userID uint, username string, roles []string, expiresIn time.Duration) (string, error) {
+// generateAccessToken 签发访问令牌，sessionID 为所属的刷新令牌族
+func (s *AuthService) generateAccessToken(
+	ctx context.Context,
+	user *models.User,
+	roles []string,
+	sessionID string,
+	expiresIn time.Duration,
+) (string, error) {
 	now := time.Now().UTC()
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
-		Roles:    roles,
+		UserID:    user.ID,
+		Username:  user.Username,
+		Roles:     roles,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        randomToken(16),
 			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
 			NotBefore: jwt.NewNumericDate(now),
 			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return s.issuer.Sign(ctx, claims)
 }
 
-// ValidateToken 验证 JWT 令牌
-func (s *AuthService) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return []byte(s.Cfg.JWT.Secret), nil
-	})
+// ValidateToken 验证 JWT 令牌的签名、签发者和有效期
+func (s *AuthService) ValidateToken(ctx context.Context, tokenString string) (*Claims, error) {
+	return s.issuer.Parse(ctx, tokenString)
+}
 
+// Authenticate 验证访问令牌，并拒绝已撤销的令牌、已停用的账户和未启用必需两步验证的账户；返回的角色为用户当前的角色
+func (s *AuthService) Authenticate(ctx context.Context, tokenString string) (*Claims, error) {
+	claims, err := s.ValidateToken(ctx, tokenString)
 	if err != nil {
 		return nil, err
 	}
+	if claims.ID == "" {
+		return nil, models.ErrTokenMissingID
+	}
+	revoked, err := s.tokenRepo.IsAccessTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		return nil, fmt.Errorf("查询令牌状态失败: %w", err)
+	}
+	if revoked {
+		return nil, models.ErrTokenRevoked
+	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	user, err := s.userRepo.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	if user == nil || !user.IsActive {
+		return nil, models.ErrAccountDisabled
 	}
 
-	return nil, jwt.ErrTokenInvalidClaims
+	// 以数据库中的角色为准，管理员调整角色后无需等待访问令牌过期
+	roles, err := userRoles(user)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireTwoFactor(ctx, user, roles); err != nil {
+		return nil, err
+	}
+	claims.Roles = make([]string, len(roles))
+	for i, role := range roles {
+		claims.Roles[i] = string(role)
+	}
+	return claims, nil
 }
 
-// Login 用户/管理员通用登录方法
-func (s *AuthService) Login(ctx context.Context, username, password string, checkAdmin bool) (string, error) {
+// Login 用户/管理员通用登录方法。
+// 用户名不存在和密码错误统一返回 models.ErrInvalidCredentials，并计入账户和 IP 的失败次数；
+// 启用了两步验证或必须启用两步验证的账户返回登录挑战，由 VerifyLogin 完成登录
+func (s *AuthService) Login(ctx context.Context, username, password, ip string, checkAdmin bool) (*LoginResult, error) {
 	if s.userRepo == nil {
-		return "", errors.New("userRepo is not initialized")
+		return nil, errors.New("userRepo is not initialized")
+	}
+	if err := s.limiter.Check(ctx, username, ip); err != nil {
+		return nil, err
 	}
+
 	user, err := s.userRepo.GetUserByUsername(ctx, username)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return "", errors.New("用户不存在")
+		if errors.Is(err, models.ErrUserNotFound) {
+			// 用户不存在时同样执行一次密码校验，使响应时间与密码错误一致
+			_ = (&models.User{Password: dummyPasswordHash}).CheckPassword(password)
+			s.limiter.RecordFailure(ctx, username, ip)
+			return nil, models.ErrInvalidCredentials
 		}
-		return "", fmt.Errorf("查询用户失败: %w", err)
+		return nil, fmt.Errorf("查询用户失败: %w", err)
 	}
 
 	if err := user.CheckPassword(password); err != nil {
-		return "", errors.New("密码错误")
+		s.limiter.RecordFailure(ctx, username, ip)
+		return nil, models.ErrInvalidCredentials
+	}
+	if !user.IsActive {
+		return nil, models.ErrAccountDisabled
 	}
 
-	var roles []models.Role
-	if len(user.Roles) == 0 {
-		roles = []models.Role{}
-	} else {
-		if err := user.Roles.Unmarshal(&roles); err != nil {
-			return "", fmt.Errorf("反序列化用户角色失败: %w", err)
-		}
+	roles, err := userRoles(user)
+	if err != nil {
+		return nil, err
 	}
 
 	if checkAdmin {
@@ -148,7 +238,294 @@ func (s *AuthService) Login(ctx context.Context, username, password string, chec
 			}
 		}
 		if !isAdmin {
-			return "", errors.New("非管理员用户，无权访问")
+			return nil, models.ErrNotAdmin
+		}
+	}
+
+	tf, err := s.twoFactor.Get(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if tf.Enabled() || s.twoFactor.RequiredFor(roles) {
+		// 两步验证完成后才清除失败记录
+		return s.issueChallenge(ctx, user, tf)
+	}
+
+	s.resetFailures(ctx, user)
+	tokens, err := s.issueTokens(ctx, user, roles, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{Tokens: tokens}, nil
+}
+
+// AdminLogin 管理员登录方法，复用 Login 方法
+func (s *AuthService) AdminLogin(ctx context.Context, username, password, ip string) (*LoginResult, error) {
+	return s.Login(ctx, username, password, ip, true)
+}
+
+// VerifyLogin 使用登录挑战和验证码（或恢复码）完成登录。
+// 尚未绑定两步验证的账户用新密钥生成的验证码完成绑定，并返回恢复码。验证码错误计入登录失败次数
+func (s *AuthService) VerifyLogin(ctx context.Context, challengeToken, code, ip string) (*LoginResult, error) {
+	challenge, err := s.tokenRepo.GetUserToken(ctx, models.TokenLoginChallenge, hashToken(challengeToken))
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidUserToken) {
+			return nil, models.ErrInvalidChallenge
+		}
+		return nil, fmt.Errorf("查询登录挑战失败: %w", err)
+	}
+	if challenge.UsedAt != nil || time.Now().After(challenge.ExpiresAt) {
+		return nil, models.ErrInvalidChallenge
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, challenge.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	if user == nil || !user.IsActive {
+		return nil, models.ErrAccountDisabled
+	}
+	if err := s.limiter.Check(ctx, user.Username, ip); err != nil {
+		return nil, err
+	}
+
+	tf, err := s.twoFactor.Get(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	result := &LoginResult{}
+	if tf.Enabled() {
+		err = s.twoFactor.Verify(ctx, user.ID, code)
+	} else {
+		result.RecoveryCodes, err = s.twoFactor.Enable(ctx, user.ID, code)
+	}
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidOTP) {
+			s.limiter.RecordFailure(ctx, user.Username, ip)
+		}
+		return nil, err
+	}
+
+	consumed, err := s.tokenRepo.ConsumeUserToken(ctx, challenge.ID)
+	if err != nil {
+		return nil, fmt.Errorf("更新登录挑战失败: %w", err)
+	}
+	if !consumed {
+		return nil, models.ErrInvalidChallenge
+	}
+	s.resetFailures(ctx, user)
+
+	roles, err := userRoles(user)
+	if err != nil {
+		return nil, err
+	}
+	if result.Tokens, err = s.issueTokens(ctx, user, roles, nil); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// issueChallenge 签发登录挑战；尚未启用两步验证时同时生成待绑定的密钥
+func (s *AuthService) issueChallenge(
+	ctx context.Context,
+	user *models.User,
+	tf *models.UserTwoFactor,
+) (*LoginResult, error) {
+	challenge := &LoginChallenge{
+		Token:     randomToken(32),
+		ExpiresIn: parseTokenTTL(s.Cfg.TwoFactor.ChallengeTTL, defaultChallengeTTL),
+	}
+	if !tf.Enabled() {
+		setup, err := s.twoFactor.Setup(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		challenge.Setup = setup
+	}
+
+	if err := s.tokenRepo.CreateUserToken(ctx, &models.UserToken{
+		UserID:    user.ID,
+		Purpose:   models.TokenLoginChallenge,
+		TokenHash: hashToken(challenge.Token),
+		ExpiresAt: time.Now().Add(challenge.ExpiresIn),
+	}); err != nil {
+		return nil, fmt.Errorf("保存登录挑战失败: %w", err)
+	}
+	return &LoginResult{Challenge: challenge}, nil
+}
+
+// requireTwoFactor 角色要求两步验证而用户尚未启用时返回 models.ErrTwoFactorMandatory，
+// 例如登录后才被授予管理员角色的用户，需重新登录完成两步验证设置
+func (s *AuthService) requireTwoFactor(ctx context.Context, user *models.User, roles []models.Role) error {
+	if !s.twoFactor.RequiredFor(roles) {
+		return nil
+	}
+	tf, err := s.twoFactor.Get(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if !tf.Enabled() {
+		return models.ErrTwoFactorMandatory
+	}
+	return nil
+}
+
+// resetFailures 登录成功后清除账户的失败记录
+func (s *AuthService) resetFailures(ctx context.Context, user *models.User) {
+	if err := s.limiter.ResetAccount(ctx, user.Username); err != nil {
+		logger.Log.Error("清除登录失败记录失败", zap.Uint("userID", user.ID), zap.Error(err))
+	}
+}
+
+// UnlockUser 解除账户的登录锁定并清零失败次数
+func (s *AuthService) UnlockUser(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+	if user == nil {
+		return models.ErrUserNotFound
+	}
+	if err := s.limiter.ResetAccount(ctx, user.Username); err != nil {
+		return fmt.Errorf("解除登录锁定失败: %w", err)
+	}
+	logger.Log.Info("已解除账户登录锁定", zap.Uint("userID", userID))
+	return nil
+}
+
+// Refresh 用刷新令牌换取新的访问令牌和刷新令牌，旧刷新令牌随即失效。
+// 已使用过的刷新令牌再次出现说明令牌可能泄露，撤销整族令牌
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	current, err := s.tokenRepo.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if current.RevokedAt != nil {
+		return nil, models.ErrInvalidRefreshToken
+	}
+	if current.UsedAt != nil {
+		s.revokeReusedFamily(ctx, current)
+		return nil, models.ErrRefreshTokenReused
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return nil, models.ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, current.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	if user == nil {
+		return nil, models.ErrInvalidRefreshToken
+	}
+	if !user.IsActive {
+		return nil, models.ErrAccountDisabled
+	}
+	roles, err := userRoles(user)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireTwoFactor(ctx, user, roles); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, user, roles, current)
+}
+
+// Logout 撤销当前访问令牌及其所属的刷新令牌族；提供 refreshToken 时一并撤销该令牌所在的族
+func (s *AuthService) Logout(ctx context.Context, claims *Claims, refreshToken string) error {
+	revoked := &models.RevokedToken{
+		JTI:       claims.ID,
+		UserID:    claims.UserID,
+		ExpiresAt: time.Now().Add(defaultAccessTokenTTL),
+	}
+	if claims.ExpiresAt != nil {
+		revoked.ExpiresAt = claims.ExpiresAt.Time
+	}
+	if err := s.tokenRepo.RevokeAccessToken(ctx, revoked); err != nil {
+		return fmt.Errorf("撤销访问令牌失败: %w", err)
+	}
+
+	if claims.SessionID != "" {
+		if err := s.tokenRepo.RevokeFamily(ctx, claims.SessionID); err != nil {
+			return fmt.Errorf("撤销刷新令牌失败: %w", err)
+		}
+	}
+	if refreshToken != "" {
+		token, err := s.tokenRepo.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidRefreshToken) {
+				return nil
+			}
+			return fmt.Errorf("查询刷新令牌失败: %w", err)
+		}
+		if token.UserID == claims.UserID && token.FamilyID != claims.SessionID {
+			if err := s.tokenRepo.RevokeFamily(ctx, token.FamilyID); err != nil {
+				return fmt.Errorf("撤销刷新令牌失败: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// PurgeExpiredTokens 清理已过期的刷新令牌、撤销记录和过期的登录失败记录，由定时任务调用
+func (s *AuthService) PurgeExpiredTokens(ctx context.Context) error {
+	if err := s.tokenRepo.DeleteExpired(ctx, time.Now()); err != nil {
+		return fmt.Errorf("清理过期令牌失败: %w", err)
+	}
+	if err := s.limiter.Purge(ctx); err != nil {
+		return fmt.Errorf("清理登录失败记录失败: %w", err)
+	}
+	return nil
+}
+
+// CheckEmailVerified 用户邮箱未验证时返回 models.ErrEmailNotVerified
+func (s *AuthService) CheckEmailVerified(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+	if user == nil {
+		return models.ErrUserNotFound
+	}
+	if !user.EmailVerified() {
+		return models.ErrEmailNotVerified
+	}
+	return nil
+}
+
+// issueTokens 签发访问令牌和刷新令牌；parent 不为 nil 时轮换该刷新令牌，沿用其令牌族
+func (s *AuthService) issueTokens(
+	ctx context.Context,
+	user *models.User,
+	roles []models.Role,
+	parent *models.RefreshToken,
+) (*TokenPair, error) {
+	accessTTL := s.accessTokenTTL()
+	refreshTTL := parseTokenTTL(s.Cfg.JWT.RefreshExpiresIn, defaultRefreshTokenTTL)
+
+	refreshToken := randomToken(32)
+	next := &models.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(refreshTTL),
+	}
+	if parent == nil {
+		next.FamilyID = randomToken(16)
+		if err := s.tokenRepo.CreateRefreshToken(ctx, next); err != nil {
+			return nil, fmt.Errorf("保存刷新令牌失败: %w", err)
+		}
+	} else {
+		next.FamilyID = parent.FamilyID
+		next.ParentID = &parent.ID
+		rotated, err := s.tokenRepo.RotateRefreshToken(ctx, parent, next)
+		if err != nil {
+			return nil, fmt.Errorf("轮换刷新令牌失败: %w", err)
+		}
+		if !rotated {
+			// 并发使用同一刷新令牌，同样视为重复使用
+			s.revokeReusedFamily(ctx, parent)
+			return nil, models.ErrRefreshTokenReused
 		}
 	}
 
@@ -156,18 +533,67 @@ func (s *AuthService) Login(ctx context.Context, username, password string, chec
 	for i, role := range roles {
 		roleStrings[i] = string(role)
 	}
-
-	// 将 s.Cfg.JWT.ExpiresIn 转换为 time.Duration 类型
-	expiresIn, err := time.ParseDuration(s.Cfg.JWT.ExpiresIn)
+	accessToken, err := s.generateAccessToken(ctx, user, roleStrings, next.FamilyID, accessTTL)
 	if err != nil {
-		log.Printf("解析令牌过期时间失败，使用默认值 24h: %v", err)
-		expiresIn = 24 * time.Hour
+		return nil, fmt.Errorf("生成访问令牌失败: %w", err)
 	}
 
-	return utils.GenerateJWT(s.Cfg.JWT.Secret, user.ID, user.Username, roleStrings, expiresIn)
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    accessTTL,
+	}, nil
 }
 
-// AdminLogin 管理员登录方法，复用 Login 方法
-func (s *AuthService) AdminLogin(ctx context.Context, username, password string) (string, error) {
-	return s.Login(ctx, username, password, true)
+func (s *AuthService) revokeReusedFamily(ctx context.Context, token *models.RefreshToken) {
+	logger.Log.Warn("检测到刷新令牌重复使用，撤销整族令牌",
+		zap.Uint("userID", token.UserID),
+		zap.Uint("tokenID", token.ID),
+		zap.String("familyID", token.FamilyID))
+	if err := s.tokenRepo.RevokeFamily(ctx, token.FamilyID); err != nil {
+		logger.Log.Error("撤销刷新令牌族失败",
+			zap.String("familyID", token.FamilyID),
+			zap.Error(err))
+	}
+}
+
+func (s *AuthService) accessTokenTTL() time.Duration {
+	return parseTokenTTL(s.Cfg.JWT.ExpiresIn, defaultAccessTokenTTL)
+}
+
+func parseTokenTTL(value string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("解析令牌过期时间失败，使用默认值 %s: %v", fallback, err)
+		return fallback
+	}
+	return d
+}
+
+// userRoles 解析用户角色
+func userRoles(user *models.User) ([]models.Role, error) {
+	roles := []models.Role{}
+	if len(user.Roles) == 0 {
+		return roles, nil
+	}
+	if err := user.Roles.Unmarshal(&roles); err != nil {
+		return nil, fmt.Errorf("反序列化用户角色失败: %w", err)
+	}
+	return roles, nil
+}
+
+// hashToken 刷新令牌只保存 SHA-256 摘要
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomToken 生成 n 字节的随机十六进制串
+func randomToken(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand 不可用时无法安全签发令牌
+		panic(fmt.Sprintf("生成随机令牌失败: %v", err))
+	}
+	return hex.EncodeToString(b)
 }
@@ -3,21 +3,26 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"go.uber.org/zap"
+	"math"
+	"modules/config"
 	"modules/internal/models"
 	"modules/internal/repositories"
 	"modules/pkg/logger"
+	"modules/pkg/notifier"
+	"sort"
+	"strings"
 	"time"
 )
 
-// CreateLease 创建租赁订单
+// CreateLease 创建租赁订单，按车位的月租价格计价；车位在该时段已被租用时返回 ErrLeaseConflict
 func (s *LeaseService) CreateLease(
 	ctx context.Context,
 	userID uint,
 	spotID uint,
 	period int, // 租赁时长（月数）
-	rate float64, // 租赁费率
 ) (*models.LeaseOrder, error) {
 	// 参数校验
 	if period <= 0 {
@@ -30,8 +35,13 @@ func (s *LeaseService) CreateLease(
 		return nil, err
 	}
 
+	spot, err := s.parkingRepo.GetSpotByID(ctx, spotID)
+	if err != nil {
+		return nil, models.ErrParkingSpotNotFound
+	}
+	rate := spot.MonthlyRate
 	if rate <= 0 {
-		err := fmt.Errorf("租赁费率必须为正数")
+		err := fmt.Errorf("车位未设置月租价格，暂不能租用")
 		logger.Log.Error("创建租赁订单失败",
 			zap.Uint("userID", userID),
 			zap.Uint("spotID", spotID),
@@ -46,8 +56,8 @@ func (s *LeaseService) CreateLease(
 		zap.Int("period", period),
 		zap.Float64("rate", rate))
 
-	// 计算总价时使用传入的费率
-	totalPrice := rate * float64(period)
+	// 按车位的月租价格计算总价
+	totalPrice := math.Round(rate*float64(period)*100) / 100
 
 	startDate := time.Now()
 	endDate := startDate.AddDate(0, period, 0)
@@ -64,30 +74,18 @@ func (s *LeaseService) CreateLease(
 		return nil, err
 	}
 
+	// 订单在支付成功前处于待支付状态，车位到期时间在激活时更新
 	lease := &models.LeaseOrder{
 		UserID:     userID,
 		SpotID:     spotID,
+		OwnerID:    spot.OwnerID,
 		StartDate:  startDate,
 		EndDate:    endDate,
 		TotalPrice: totalPrice,
-		Status:     models.LeaseActive,
+		Status:     models.LeasePending,
 	}
 
-	// 使用事务创建租赁订单和更新车位信息
-	err := s.leaseRepo.Transaction(ctx, func(tx repositories.LeaseTx) error {
-		if err := tx.CreateLease(ctx, lease); err != nil {
-			return fmt.Errorf("创建租赁订单失败: %w", err)
-		}
-
-		// 更新车位到期时间
-		if err := s.parkingRepo.UpdateSpotExpiry(ctx, spotID, &endDate); err != nil {
-			return fmt.Errorf("更新车位到期时间失败: %w", err)
-		}
-
-		return nil
-	})
-
-	if err != nil {
+	if err := s.createPendingLease(ctx, lease, nil); err != nil {
 		logger.Log.Error("创建租赁订单失败",
 			zap.Uint("userID", userID),
 			zap.Uint("spotID", spotID),
@@ -102,23 +100,440 @@ func (s *LeaseService) CreateLease(
 	return lease, nil
 }
 
+// CreateBookedLease 接受预订申请并生成待支付的租赁订单，两者在同一事务中提交；
+// 申请已被处理或撤回时返回 ErrBookingNotPending
+func (s *LeaseService) CreateBookedLease(
+	ctx context.Context,
+	booking *models.BookingRequest,
+	note string,
+) (*models.LeaseOrder, error) {
+	lease := &models.LeaseOrder{
+		UserID:     booking.RenterID,
+		SpotID:     booking.SpotID,
+		OwnerID:    booking.OwnerID,
+		StartDate:  booking.StartTime,
+		EndDate:    booking.EndTime,
+		TotalPrice: booking.Amount,
+		Status:     models.LeasePending,
+	}
+
+	now := time.Now()
+	accepted := *booking
+	accepted.Status = models.BookingAccepted
+	accepted.DecisionNote = strings.TrimSpace(note)
+	accepted.DecidedAt = &now
+	err := s.createPendingLease(ctx, lease, func(tx repositories.LeaseTx) error {
+		accepted.LeaseID = &lease.ID
+		ok, err := tx.AcceptBooking(ctx, &accepted)
+		if err != nil {
+			return fmt.Errorf("更新预订申请失败: %w", err)
+		}
+		if !ok {
+			return models.ErrBookingNotPending
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	*booking = accepted
+
+	logger.Log.Info("预订租赁订单已创建",
+		zap.Uint("leaseID", lease.ID),
+		zap.Uint("bookingID", booking.ID),
+		zap.Uint("renterID", booking.RenterID),
+		zap.Uint("spotID", booking.SpotID))
+	return lease, nil
+}
+
+// createPendingLease 锁定车位并确认时段无冲突后保存待支付订单，再创建对应的支付单；
+// 时段已被其他订单占用时返回 ErrLeaseConflict。within 与订单在同一事务中执行，可以为 nil
+func (s *LeaseService) createPendingLease(
+	ctx context.Context,
+	lease *models.LeaseOrder,
+	within func(tx repositories.LeaseTx) error,
+) error {
+	err := s.leaseRepo.Transaction(ctx, func(tx repositories.LeaseTx) error {
+		if err := tx.LockSpot(ctx, lease.SpotID); err != nil {
+			if errors.Is(err, models.ErrParkingSpotNotFound) {
+				return err
+			}
+			return fmt.Errorf("锁定车位失败: %w", err)
+		}
+		overlapping, err := tx.HasOverlappingLease(ctx, lease.SpotID, lease.StartDate, lease.EndDate)
+		if err != nil {
+			return fmt.Errorf("检查车位占用失败: %w", err)
+		}
+		if overlapping {
+			return models.ErrLeaseConflict
+		}
+
+		if err := tx.CreateLease(ctx, lease); err != nil {
+			return fmt.Errorf("创建租赁订单失败: %w", err)
+		}
+		event, err := models.NewOutboxEvent(models.WebhookLeaseCreated, models.AggregateLease, lease.ID,
+			models.NewLeaseEventData(lease))
+		if err != nil {
+			return fmt.Errorf("生成租赁事件失败: %w", err)
+		}
+		if err := tx.AddOutboxEvent(ctx, event); err != nil {
+			return err
+		}
+		if within != nil {
+			return within(tx)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.paymentService.CreateIntent(ctx, lease.UserID, models.PaymentForLease, lease.ID, lease.TotalPrice, leasePaymentDescription(lease)); err != nil {
+		return fmt.Errorf("创建租赁支付单失败: %w", err)
+	}
+
+	return nil
+}
+
+// notifyLeaseCreated 发件箱处理器：通知承租人订单已创建
+func (s *LeaseService) notifyLeaseCreated(ctx context.Context, event *models.OutboxEvent) error {
+	var data models.LeaseEventData
+	if err := event.DecodeData(&data); err != nil {
+		return fmt.Errorf("解析租赁事件失败: %w", err)
+	}
+	s.notifications.Notify(ctx, data.UserID, models.NotifyLeaseCreated, notifier.LeaseCreatedData{
+		LeaseID:   data.LeaseID,
+		SpotID:    data.SpotID,
+		StartDate: data.StartDate.Local().Format("2006-01-02"),
+		EndDate:   data.EndDate.Local().Format("2006-01-02"),
+		Amount:    data.TotalPrice,
+	})
+	return nil
+}
+
+// 待支付订单默认保留时长
+const defaultPendingLeaseTTL = 30 * time.Minute
+
 type LeaseService struct {
-	leaseRepo   repositories.LeaseRepository
-	parkingRepo repositories.ParkingRepository
+	leaseRepo      repositories.LeaseRepository
+	parkingRepo    repositories.ParkingRepository
+	paymentService *PaymentService
+	notifications  *NotificationService
+	termination    config.LeaseTerminationConfig
+	// 到期提醒提前的天数，按从小到大排序
+	reminderDays []int
+	// 待支付订单超过该时长未支付自动取消
+	pendingTTL time.Duration
 }
 
 func NewLeaseService(
 	lr repositories.LeaseRepository,
 	pr repositories.ParkingRepository,
+	ps *PaymentService,
+	ns *NotificationService,
+	obs *OutboxService,
+	cfg *config.Config,
 ) *LeaseService {
-	return &LeaseService{
-		leaseRepo:   lr,
-		parkingRepo: pr,
+	s := &LeaseService{
+		leaseRepo:      lr,
+		parkingRepo:    pr,
+		paymentService: ps,
+		notifications:  ns,
+		termination:    cfg.Lease.Termination,
+		reminderDays:   normalizeReminderDays(cfg.Lease.ReminderDays),
+		pendingTTL:     parseDurationOr(cfg.Lease.PendingTTL, defaultPendingLeaseTTL, "lease.pending_ttl"),
+	}
+	ps.OnSucceeded(models.PaymentForLease, s.activatePaidLease)
+	obs.Subscribe("lease_created_notice", models.WebhookLeaseCreated, s.notifyLeaseCreated)
+	return s
+}
+
+// ListLeases 分页查询用户作为承租人的租赁订单
+func (s *LeaseService) ListLeases(
+	ctx context.Context,
+	userID uint,
+	status models.LeaseStatus,
+	page, pageSize int,
+) ([]*models.LeaseOrder, int64, error) {
+	return s.leaseRepo.ListLeases(ctx, repositories.LeaseFilter{
+		UserID: userID,
+		Status: status,
+		Offset: (page - 1) * pageSize,
+		Limit:  pageSize,
+	})
+}
+
+// ListOwnerLeases 分页查询业主名下车位上的全部租赁订单
+func (s *LeaseService) ListOwnerLeases(
+	ctx context.Context,
+	ownerID uint,
+	status models.LeaseStatus,
+	page, pageSize int,
+) ([]*models.LeaseOrder, int64, error) {
+	return s.leaseRepo.ListLeases(ctx, repositories.LeaseFilter{
+		OwnerID: ownerID,
+		Status:  status,
+		Offset:  (page - 1) * pageSize,
+		Limit:   pageSize,
+	})
+}
+
+// GetLease 查询租赁订单详情，仅承租人和车位业主可查看
+func (s *LeaseService) GetLease(ctx context.Context, userID, leaseID uint) (*models.LeaseOrder, error) {
+	lease, err := s.leaseRepo.GetLeaseByID(ctx, leaseID)
+	if err != nil {
+		return nil, err
+	}
+	if lease.UserID == userID {
+		return lease, nil
+	}
+
+	spot, err := s.parkingRepo.GetSpotByID(ctx, lease.SpotID)
+	if err != nil || spot.OwnerID != userID {
+		return nil, models.ErrLeaseNotFound
 	}
+	return lease, nil
 }
 
+// PayLease 支付租赁订单，支付成功后订单生效
+func (s *LeaseService) PayLease(ctx context.Context, userID, leaseID uint, amount float64) (*models.Payment, error) {
+	lease, err := s.leaseRepo.GetLeaseByID(ctx, leaseID)
+	if err != nil {
+		return nil, err
+	}
+	if lease.UserID != userID {
+		return nil, models.ErrLeaseNotFound
+	}
+	if lease.Status != models.LeasePending {
+		return nil, errors.New("租赁订单无需支付")
+	}
+	if math.Abs(amount-lease.TotalPrice) > 0.005 {
+		return nil, fmt.Errorf("支付金额与订单金额 %.2f 不一致", lease.TotalPrice)
+	}
+
+	return s.paymentService.PayReference(ctx, userID, models.PaymentForLease, lease.ID, lease.TotalPrice, leasePaymentDescription(lease))
+}
+
+// activatePaidLease 租赁支付成功回调：激活订单并更新车位到期时间
+func (s *LeaseService) activatePaidLease(ctx context.Context, payment *models.Payment) error {
+	lease, err := s.leaseRepo.GetLeaseByID(ctx, payment.ReferenceID)
+	if err != nil {
+		return err
+	}
+	if lease.Status != models.LeasePending {
+		return nil
+	}
+
+	if err := s.leaseRepo.UpdateLeaseStatus(ctx, lease.ID, models.LeaseActive); err != nil {
+		return fmt.Errorf("激活租赁订单失败: %w", err)
+	}
+	if err := s.parkingRepo.UpdateSpotExpiry(ctx, lease.SpotID, &lease.EndDate); err != nil {
+		return fmt.Errorf("更新车位到期时间失败: %w", err)
+	}
+
+	logger.Log.Info("租赁订单已激活",
+		zap.Uint("leaseID", lease.ID),
+		zap.Uint("paymentID", payment.ID))
+	return nil
+}
+
+// TerminateLease 终止租赁：待支付订单直接取消；生效中的订单提前终止，并按剩余租期生成待审核的退款申请
+func (s *LeaseService) TerminateLease(
+	ctx context.Context,
+	userID, leaseID uint,
+	reason string,
+) (*models.LeaseOrder, *models.Refund, error) {
+	lease, err := s.leaseRepo.GetLeaseByID(ctx, leaseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if lease.UserID != userID {
+		return nil, nil, models.ErrLeaseNotFound
+	}
+
+	now := time.Now()
+	switch lease.Status {
+	case models.LeasePending:
+		// 先作废待支付的支付单，取消后不会再被扣款
+		if err := s.voidLeasePayment(ctx, lease.ID); err != nil {
+			return nil, nil, err
+		}
+		cancelled, err := s.leaseRepo.CancelPendingLease(ctx, lease.ID, now)
+		if err != nil {
+			return nil, nil, fmt.Errorf("取消租赁订单失败: %w", err)
+		}
+		if !cancelled {
+			return nil, nil, models.ErrLeaseStateChanged
+		}
+		lease.Status = models.LeaseCancelled
+		lease.TerminatedAt = &now
+		logger.Log.Info("租赁订单已取消", zap.Uint("leaseID", lease.ID))
+		return lease, nil, nil
+	case models.LeaseActive:
+	default:
+		return nil, nil, fmt.Errorf("租赁订单当前状态为 %s，无法终止", lease.Status)
+	}
+
+	refundAmount, penalty := s.calculateTerminationRefund(lease, now)
+
+	var refund *models.Refund
+	if refundAmount > 0 {
+		payment, err := s.paymentService.GetLatestPayment(ctx, models.PaymentForLease, lease.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("查询租赁支付单失败: %w", err)
+		}
+		if refundAmount > payment.Amount-payment.RefundedAmount {
+			refundAmount = payment.Amount - payment.RefundedAmount
+		}
+		if reason == "" {
+			reason = "租赁提前终止"
+		}
+		if refund, err = s.paymentService.NewRefund(payment, refundAmount, penalty, reason); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// 终止订单与创建退款申请在同一事务中提交
+	lease.Status = models.LeaseTerminated
+	lease.TerminatedAt = &now
+	err = s.leaseRepo.Transaction(ctx, func(tx repositories.LeaseTx) error {
+		closed, err := tx.CloseLease(ctx, lease, models.LeaseActive)
+		if err != nil {
+			return fmt.Errorf("终止租赁订单失败: %w", err)
+		}
+		if !closed {
+			return models.ErrLeaseStateChanged
+		}
+		if refund == nil {
+			return nil
+		}
+		if err := tx.CreateRefund(ctx, refund); err != nil {
+			return fmt.Errorf("创建退款申请失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 清除车位到期时间
+	if err := s.parkingRepo.UpdateSpotExpiry(ctx, lease.SpotID, nil); err != nil {
+		logger.Log.Error("释放车位失败",
+			zap.Uint("spotID", lease.SpotID),
+			zap.Error(err))
+	}
+
+	logger.Log.Info("租赁订单已提前终止",
+		zap.Uint("leaseID", lease.ID),
+		zap.Float64("refund", refundAmount),
+		zap.Float64("penalty", penalty))
+	return lease, refund, nil
+}
+
+// calculateTerminationRefund 按剩余租期折算应退租金，并扣除违约金
+func (s *LeaseService) calculateTerminationRefund(lease *models.LeaseOrder, now time.Time) (refund, penalty float64) {
+	total := lease.EndDate.Sub(lease.StartDate)
+	if total <= 0 || !now.Before(lease.EndDate) {
+		return 0, 0
+	}
+
+	remaining := lease.EndDate.Sub(now)
+	if remaining > total {
+		remaining = total
+	}
+	prorated := lease.TotalPrice * float64(remaining) / float64(total)
+
+	freeWindow := time.Duration(s.termination.FreeCancelHours) * time.Hour
+	if now.Sub(lease.StartDate) > freeWindow {
+		penalty = math.Max(prorated*s.termination.PenaltyRate, s.termination.MinPenalty)
+		penalty = math.Min(penalty, prorated)
+	}
+
+	refund = math.Round((prorated-penalty)*100) / 100
+	penalty = math.Round(penalty*100) / 100
+	return refund, penalty
+}
+
+func leasePaymentDescription(lease *models.LeaseOrder) string {
+	return fmt.Sprintf("车位 %d 租赁 %s 至 %s", lease.SpotID,
+		lease.StartDate.Format("2006-01-02"), lease.EndDate.Format("2006-01-02"))
+}
+
+// CancelStalePendingLeases 取消超时未支付的订单，使其不再占用车位的租期。
+// 先作废订单的待支付支付单，正在扣款的订单留待支付结果；已支付的订单重新激活，不做取消
+func (s *LeaseService) CancelStalePendingLeases(ctx context.Context) error {
+	leases, err := s.leaseRepo.GetStalePendingLeases(ctx, time.Now().Add(-s.pendingTTL))
+	if err != nil {
+		return fmt.Errorf("查询超时未支付订单失败: %w", err)
+	}
+
+	for _, lease := range leases {
+		if err := s.voidLeasePayment(ctx, lease.ID); err != nil {
+			if errors.Is(err, models.ErrLeaseStateChanged) {
+				s.retryLeaseActivation(ctx, lease.ID)
+			} else {
+				logger.Log.Error("作废租赁支付单失败", zap.Uint("leaseID", lease.ID), zap.Error(err))
+			}
+			continue
+		}
+
+		cancelled, err := s.leaseRepo.CancelPendingLease(ctx, lease.ID, time.Now())
+		if err != nil {
+			logger.Log.Error("取消超时未支付订单失败", zap.Uint("leaseID", lease.ID), zap.Error(err))
+			continue
+		}
+		if cancelled {
+			logger.Log.Info("超时未支付的租赁订单已取消", zap.Uint("leaseID", lease.ID))
+		}
+	}
+	return nil
+}
+
+// retryLeaseActivation 已支付但激活失败的订单重新激活，这类订单不能取消
+func (s *LeaseService) retryLeaseActivation(ctx context.Context, leaseID uint) {
+	payment, err := s.paymentService.GetLatestPayment(ctx, models.PaymentForLease, leaseID)
+	if err != nil || payment.Status != models.PaymentSucceeded {
+		return
+	}
+	if err := s.activatePaidLease(ctx, payment); err != nil {
+		logger.Log.Error("重新激活已支付租赁订单失败",
+			zap.Uint("leaseID", leaseID),
+			zap.Uint("paymentID", payment.ID),
+			zap.Error(err))
+	}
+}
+
+// voidLeasePayment 作废订单待支付的支付单，之后该支付单不能再扣款。
+// 支付单正在扣款、已支付或已被其他请求占用时返回 ErrLeaseStateChanged，订单不能取消
+func (s *LeaseService) voidLeasePayment(ctx context.Context, leaseID uint) error {
+	payment, err := s.paymentService.GetLatestPayment(ctx, models.PaymentForLease, leaseID)
+	if errors.Is(err, models.ErrPaymentNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("查询租赁支付单失败: %w", err)
+	}
+
+	switch payment.Status {
+	case models.PaymentPending:
+		voided, err := s.paymentService.VoidPending(ctx, payment.ID)
+		if err != nil {
+			return err
+		}
+		if !voided {
+			return models.ErrLeaseStateChanged
+		}
+		return nil
+	case models.PaymentFailed:
+		return nil
+	default:
+		return models.ErrLeaseStateChanged
+	}
+}
+
+// CheckLeaseExpirations 处理已到结束时间的租赁：标记过期并释放车位，开启自动续租的订单随后续租
 func (s *LeaseService) CheckLeaseExpirations(ctx context.Context) error {
-	expiringLeases, err := s.leaseRepo.GetExpiringLeases(ctx, time.Now().Add(24*time.Hour))
+	expiringLeases, err := s.leaseRepo.GetExpiringLeases(ctx, time.Now())
 	if err != nil {
 		return fmt.Errorf("获取到期租赁失败: %w", err)
 	}
@@ -138,6 +553,268 @@ func (s *LeaseService) CheckLeaseExpirations(ctx context.Context) error {
 				zap.Uint("spotID", lease.SpotID),
 				zap.Error(err))
 		}
+
+		if lease.AutoRenew {
+			s.renewLease(ctx, lease)
+		}
+	}
+	return nil
+}
+
+// SendExpiryReminders 向即将到期订单的承租人和业主发送到期提醒。
+// 每个订单只发送当前剩余时间对应的最近一档提醒，例如剩余 2 天时只发送 3 天档，
+// 每档提醒对每个收件人只发送一次
+func (s *LeaseService) SendExpiryReminders(ctx context.Context) error {
+	if len(s.reminderDays) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	maxDays := s.reminderDays[len(s.reminderDays)-1]
+	leases, err := s.leaseRepo.GetLeasesEndingBetween(ctx, now, now.AddDate(0, 0, maxDays))
+	if err != nil {
+		return fmt.Errorf("查询即将到期租赁失败: %w", err)
+	}
+
+	for _, lease := range leases {
+		remaining := lease.EndDate.Sub(now)
+		offset := s.reminderOffset(remaining)
+		if offset == 0 {
+			continue
+		}
+
+		data := notifier.LeaseExpiringData{
+			LeaseID:  lease.ID,
+			SpotID:   lease.SpotID,
+			EndDate:  lease.EndDate.Format("2006-01-02 15:04"),
+			DaysLeft: int(math.Ceil(remaining.Hours() / 24)),
+		}
+		s.sendReminder(ctx, lease, offset, lease.UserID, data)
+
+		if lease.OwnerID != 0 && lease.OwnerID != lease.UserID {
+			data.IsOwner = true
+			s.sendReminder(ctx, lease, offset, lease.OwnerID, data)
+		}
 	}
 	return nil
 }
+
+// reminderOffset 返回剩余时间所在的最近一档提醒天数，不在任何一档内时返回 0
+func (s *LeaseService) reminderOffset(remaining time.Duration) int {
+	for _, days := range s.reminderDays {
+		if remaining <= time.Duration(days)*24*time.Hour {
+			return days
+		}
+	}
+	return 0
+}
+
+// sendReminder 先登记提醒再发送，已登记过的提醒不再重复发送
+func (s *LeaseService) sendReminder(
+	ctx context.Context,
+	lease *models.LeaseOrder,
+	offset int,
+	recipientID uint,
+	data notifier.LeaseExpiringData,
+) {
+	created, err := s.leaseRepo.CreateReminder(ctx, &models.LeaseReminder{
+		LeaseID:     lease.ID,
+		OffsetDays:  offset,
+		RecipientID: recipientID,
+	})
+	if err != nil {
+		logger.Log.Error("登记到期提醒失败",
+			zap.Uint("leaseID", lease.ID),
+			zap.Int("offsetDays", offset),
+			zap.Uint("recipientID", recipientID),
+			zap.Error(err))
+		return
+	}
+	if !created {
+		return
+	}
+
+	s.notifications.Notify(ctx, recipientID, models.NotifyLeaseExpiring, data)
+}
+
+// normalizeReminderDays 去掉非正数和重复项并按从小到大排序
+func normalizeReminderDays(days []int) []int {
+	seen := make(map[int]bool, len(days))
+	result := make([]int, 0, len(days))
+	for _, d := range days {
+		if d > 0 && !seen[d] {
+			seen[d] = true
+			result = append(result, d)
+		}
+	}
+	sort.Ints(result)
+	return result
+}
+
+// renewLease 按原租期和租金生成下一期订单并自动扣款，结果记录到续租记录中
+func (s *LeaseService) renewLease(ctx context.Context, lease *models.LeaseOrder) {
+	renewal := &models.LeaseRenewal{LeaseID: lease.ID}
+
+	next, reason, err := s.tryRenewLease(ctx, lease)
+	if next != nil {
+		renewal.NewLeaseID = &next.ID
+	}
+	switch {
+	case err != nil:
+		renewal.Status = models.RenewalFailed
+		renewal.Reason = err.Error()
+		logger.Log.Error("自动续租失败",
+			zap.Uint("leaseID", lease.ID),
+			zap.Error(err))
+	case reason != "":
+		renewal.Status = models.RenewalFailed
+		renewal.Reason = reason
+		logger.Log.Warn("自动续租失败",
+			zap.Uint("leaseID", lease.ID),
+			zap.String("reason", reason))
+	default:
+		renewal.Status = models.RenewalSucceeded
+		logger.Log.Info("自动续租成功",
+			zap.Uint("leaseID", lease.ID),
+			zap.Uint("newLeaseID", next.ID))
+	}
+
+	if err := s.leaseRepo.CreateRenewal(ctx, renewal); err != nil {
+		logger.Log.Error("保存续租记录失败",
+			zap.Uint("leaseID", lease.ID),
+			zap.Error(err))
+	}
+}
+
+// tryRenewLease 执行续租；业务原因导致无法续租时返回 reason，系统错误时返回 err
+func (s *LeaseService) tryRenewLease(ctx context.Context, lease *models.LeaseOrder) (*models.LeaseOrder, string, error) {
+	spot, err := s.parkingRepo.GetSpotByID(ctx, lease.SpotID)
+	if err != nil {
+		return nil, "车位不存在", nil
+	}
+	if spot.OwnerID != lease.OwnerID {
+		return nil, "车位已出售或转让", nil
+	}
+
+	start, end := nextLeasePeriod(lease)
+	overlapping, err := s.leaseRepo.HasOverlappingLease(ctx, lease.SpotID, lease.ID, start, end)
+	if err != nil {
+		return nil, "", fmt.Errorf("检查车位占用失败: %w", err)
+	}
+	if overlapping {
+		return nil, "车位续租时段已被他人租用", nil
+	}
+
+	renewedFrom := lease.ID
+	next := &models.LeaseOrder{
+		UserID:        lease.UserID,
+		SpotID:        lease.SpotID,
+		OwnerID:       spot.OwnerID,
+		StartDate:     start,
+		EndDate:       end,
+		TotalPrice:    lease.TotalPrice,
+		Status:        models.LeasePending,
+		AutoRenew:     true,
+		RenewedFromID: &renewedFrom,
+	}
+	if err := s.createPendingLease(ctx, next, nil); err != nil {
+		if errors.Is(err, models.ErrLeaseConflict) {
+			return nil, "车位续租时段已被他人租用", nil
+		}
+		return nil, "", err
+	}
+
+	payment, err := s.paymentService.PayReference(ctx, next.UserID, models.PaymentForLease, next.ID, next.TotalPrice, leasePaymentDescription(next))
+	if err == nil && payment.Status == models.PaymentSucceeded {
+		return next, "", nil
+	}
+
+	// 扣款失败时取消续租订单
+	reason := "自动扣款失败"
+	if err != nil {
+		reason = fmt.Sprintf("自动扣款失败: %v", err)
+	} else if payment.FailureReason != "" {
+		reason = "自动扣款失败: " + payment.FailureReason
+	}
+	if err := s.leaseRepo.UpdateLeaseStatus(ctx, next.ID, models.LeaseCancelled); err != nil {
+		logger.Log.Error("取消续租订单失败",
+			zap.Uint("leaseID", next.ID),
+			zap.Error(err))
+	}
+	return next, reason, nil
+}
+
+// nextLeasePeriod 计算续租时段：整月租期按月顺延，否则按原时长顺延
+func nextLeasePeriod(lease *models.LeaseOrder) (time.Time, time.Time) {
+	start := lease.EndDate
+	months := (lease.EndDate.Year()-lease.StartDate.Year())*12 + int(lease.EndDate.Month()-lease.StartDate.Month())
+	if months > 0 && lease.StartDate.AddDate(0, months, 0).Equal(lease.EndDate) {
+		return start, start.AddDate(0, months, 0)
+	}
+	return start, start.Add(lease.EndDate.Sub(lease.StartDate))
+}
+
+// SetAutoRenew 开启或关闭自动续租
+func (s *LeaseService) SetAutoRenew(ctx context.Context, userID, leaseID uint, autoRenew bool) (*models.LeaseOrder, error) {
+	lease, err := s.leaseRepo.GetLeaseByID(ctx, leaseID)
+	if err != nil {
+		return nil, err
+	}
+	if lease.UserID != userID {
+		return nil, models.ErrLeaseNotFound
+	}
+	if lease.Status != models.LeasePending && lease.Status != models.LeaseActive {
+		return nil, fmt.Errorf("租赁订单当前状态为 %s，无法设置自动续租", lease.Status)
+	}
+
+	// 只修改自动续租设置，不覆盖并发写入的订单状态
+	updated, err := s.leaseRepo.SetAutoRenew(ctx, lease.ID, autoRenew)
+	if err != nil {
+		return nil, fmt.Errorf("更新自动续租设置失败: %w", err)
+	}
+	if !updated {
+		return nil, models.ErrLeaseStateChanged
+	}
+	lease.AutoRenew = autoRenew
+	return lease, nil
+}
+
+// UpcomingRenewal 即将自动续租的订单及预计的下一期
+type UpcomingRenewal struct {
+	Lease     *models.LeaseOrder
+	NextStart time.Time
+	NextEnd   time.Time
+	Amount    float64
+}
+
+// ListUpcomingRenewals 查询未来 days 天内将自动续租的订单
+func (s *LeaseService) ListUpcomingRenewals(ctx context.Context, userID uint, days int) ([]*UpcomingRenewal, error) {
+	leases, err := s.leaseRepo.GetAutoRenewLeases(ctx, userID, time.Now().AddDate(0, 0, days))
+	if err != nil {
+		return nil, fmt.Errorf("查询自动续租订单失败: %w", err)
+	}
+
+	renewals := make([]*UpcomingRenewal, 0, len(leases))
+	for _, lease := range leases {
+		start, end := nextLeasePeriod(lease)
+		renewals = append(renewals, &UpcomingRenewal{
+			Lease:     lease,
+			NextStart: start,
+			NextEnd:   end,
+			Amount:    lease.TotalPrice,
+		})
+	}
+	return renewals, nil
+}
+
+// ListRenewals 查询订单的自动续租记录
+func (s *LeaseService) ListRenewals(ctx context.Context, userID, leaseID uint) ([]*models.LeaseRenewal, error) {
+	lease, err := s.leaseRepo.GetLeaseByID(ctx, leaseID)
+	if err != nil {
+		return nil, err
+	}
+	if lease.UserID != userID {
+		return nil, models.ErrLeaseNotFound
+	}
+	return s.leaseRepo.GetRenewals(ctx, lease.ID)
+}
@@ -0,0 +1,312 @@
+// internal/services/ledger_service.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"math"
+	"modules/config"
+	"modules/internal/models"
+	"modules/internal/repositories"
+	"modules/pkg/logger"
+	"time"
+)
+
+// 平台科目编码
+const (
+	platformCashAccount       = "platform:cash"
+	platformCommissionAccount = "platform:commission"
+)
+
+// LedgerService 业主收入分账：租赁或停车收入到账后按复式记账拆分平台佣金和业主分成，退款时按比例冲销
+type LedgerService struct {
+	ledgerRepo     repositories.LedgerRepository
+	leaseRepo      repositories.LeaseRepository
+	parkingRepo    repositories.ParkingRepository
+	commissionRate float64
+}
+
+func NewLedgerService(
+	lr repositories.LedgerRepository,
+	lsr repositories.LeaseRepository,
+	pr repositories.ParkingRepository,
+	ps *PaymentService,
+	cfg *config.Config,
+) *LedgerService {
+	s := &LedgerService{
+		ledgerRepo:     lr,
+		leaseRepo:      lsr,
+		parkingRepo:    pr,
+		commissionRate: cfg.Ledger.CommissionRate,
+	}
+	ps.OnSucceeded(models.PaymentForLease, s.recordLeasePayment)
+	ps.OnSucceeded(models.PaymentForParking, s.recordParkingPayment)
+	ps.OnRefunded(models.PaymentForLease, s.reverseRefund)
+	ps.OnRefunded(models.PaymentForParking, s.reverseRefund)
+	return s
+}
+
+// OwnerStatement 业主月度对账单
+type OwnerStatement struct {
+	OwnerID        uint
+	Month          string
+	PeriodStart    time.Time
+	PeriodEnd      time.Time
+	OpeningBalance float64
+	Gross          float64
+	Commission     float64
+	OwnerShare     float64
+	ClosingBalance float64
+	Entries        []*models.JournalEntry
+}
+
+// recordLeasePayment 租赁支付成功回调：租用业主车位的订单记入业主收入
+func (s *LedgerService) recordLeasePayment(ctx context.Context, payment *models.Payment) error {
+	lease, err := s.leaseRepo.GetLeaseByID(ctx, payment.ReferenceID)
+	if err != nil {
+		logger.Log.Error("分账时查询租赁订单失败",
+			zap.Uint("paymentID", payment.ID),
+			zap.Error(err))
+		return nil
+	}
+	if lease.OwnerID == 0 || lease.OwnerID == lease.UserID {
+		return nil
+	}
+
+	desc := fmt.Sprintf("车位 %d 租金 %s 至 %s", lease.SpotID,
+		lease.StartDate.Format("2006-01-02"), lease.EndDate.Format("2006-01-02"))
+	s.post(ctx, models.JournalFromLease, lease.ID, lease.OwnerID, lease.SpotID, payment, desc)
+	return nil
+}
+
+// recordParkingPayment 停车费支付成功回调：停在业主车位上的临时停车记入业主收入
+func (s *LedgerService) recordParkingPayment(ctx context.Context, payment *models.Payment) error {
+	record, err := s.parkingRepo.GetParkingByID(ctx, payment.ReferenceID)
+	if err != nil {
+		logger.Log.Error("分账时查询停车记录失败",
+			zap.Uint("paymentID", payment.ID),
+			zap.Error(err))
+		return nil
+	}
+	spot, err := s.parkingRepo.GetSpotByID(ctx, record.SpotID)
+	if err != nil {
+		logger.Log.Error("分账时查询车位失败",
+			zap.Uint("spotID", record.SpotID),
+			zap.Error(err))
+		return nil
+	}
+	if spot.OwnerID == 0 || (record.UserID != nil && *record.UserID == spot.OwnerID) {
+		return nil
+	}
+
+	desc := fmt.Sprintf("车位 %d 停车费 %s", spot.ID, record.License)
+	s.post(ctx, models.JournalFromParking, record.ID, spot.OwnerID, spot.ID, payment, desc)
+	return nil
+}
+
+// post 生成凭证：借记平台收款，贷记平台佣金收入和业主应付款。记账失败不影响支付结果，仅记录日志
+func (s *LedgerService) post(
+	ctx context.Context,
+	source models.JournalSource,
+	sourceID, ownerID, spotID uint,
+	payment *models.Payment,
+	description string,
+) {
+	if payment.Amount <= 0 {
+		return
+	}
+
+	gross := payment.Amount
+	commission := math.Round(gross*s.commissionRate*100) / 100
+	share := math.Round((gross-commission)*100) / 100
+
+	cash, err := s.ensureAccount(ctx, platformCashAccount, "平台收款", models.AccountAsset, nil)
+	if err != nil {
+		logger.Log.Error("获取记账科目失败", zap.Error(err))
+		return
+	}
+	revenue, err := s.ensureAccount(ctx, platformCommissionAccount, "平台佣金收入", models.AccountRevenue, nil)
+	if err != nil {
+		logger.Log.Error("获取记账科目失败", zap.Error(err))
+		return
+	}
+	payable, err := s.ownerAccount(ctx, ownerID)
+	if err != nil {
+		logger.Log.Error("获取记账科目失败", zap.Error(err))
+		return
+	}
+
+	occurredAt := time.Now()
+	if payment.PaidAt != nil {
+		occurredAt = *payment.PaidAt
+	}
+	entry := &models.JournalEntry{
+		SourceType:  source,
+		SourceID:    sourceID,
+		OwnerID:     ownerID,
+		SpotID:      spotID,
+		PaymentID:   payment.ID,
+		Gross:       gross,
+		Commission:  commission,
+		OwnerShare:  share,
+		Description: description,
+		OccurredAt:  occurredAt,
+		Lines: []models.JournalLine{
+			{AccountID: cash.ID, Debit: gross},
+			{AccountID: revenue.ID, Credit: commission},
+			{AccountID: payable.ID, Credit: share},
+		},
+	}
+	posted, err := s.ledgerRepo.PostEntry(ctx, entry)
+	if err != nil {
+		logger.Log.Error("记账失败",
+			zap.String("source", string(source)),
+			zap.Uint("sourceID", sourceID),
+			zap.Error(err))
+		return
+	}
+	if posted {
+		logger.Log.Info("业主收入已记账",
+			zap.Uint("entryID", entry.ID),
+			zap.Uint("ownerID", ownerID),
+			zap.Float64("gross", gross),
+			zap.Float64("ownerShare", share))
+	}
+}
+
+// reverseRefund 退款完成回调：按退款占原收入的比例冲减业主应付款和平台佣金，
+// 未分账的收入（例如平台自有车位）无需冲销。记账失败不影响退款结果，仅记录日志
+func (s *LedgerService) reverseRefund(ctx context.Context, payment *models.Payment, refund *models.Refund) error {
+	original, err := s.ledgerRepo.GetIncomeEntryByPayment(ctx, payment.ID)
+	if err != nil {
+		logger.Log.Error("冲销时查询收入凭证失败",
+			zap.Uint("paymentID", payment.ID),
+			zap.Error(err))
+		return nil
+	}
+	if original == nil || original.Gross <= 0 || refund.Amount <= 0 {
+		return nil
+	}
+
+	gross := math.Min(refund.Amount, original.Gross)
+	commission := math.Round(original.Commission*gross/original.Gross*100) / 100
+	share := math.Round((gross-commission)*100) / 100
+
+	cash, err := s.ensureAccount(ctx, platformCashAccount, "平台收款", models.AccountAsset, nil)
+	if err != nil {
+		logger.Log.Error("获取记账科目失败", zap.Error(err))
+		return nil
+	}
+	revenue, err := s.ensureAccount(ctx, platformCommissionAccount, "平台佣金收入", models.AccountRevenue, nil)
+	if err != nil {
+		logger.Log.Error("获取记账科目失败", zap.Error(err))
+		return nil
+	}
+	payable, err := s.ownerAccount(ctx, original.OwnerID)
+	if err != nil {
+		logger.Log.Error("获取记账科目失败", zap.Error(err))
+		return nil
+	}
+
+	occurredAt := time.Now()
+	if refund.ReviewedAt != nil {
+		occurredAt = *refund.ReviewedAt
+	}
+	entry := &models.JournalEntry{
+		SourceType:  models.JournalFromRefund,
+		SourceID:    refund.ID,
+		OwnerID:     original.OwnerID,
+		SpotID:      original.SpotID,
+		PaymentID:   payment.ID,
+		Gross:       -gross,
+		Commission:  -commission,
+		OwnerShare:  -share,
+		Description: "退款冲销：" + original.Description,
+		OccurredAt:  occurredAt,
+		Lines: []models.JournalLine{
+			{AccountID: payable.ID, Debit: share},
+			{AccountID: revenue.ID, Debit: commission},
+			{AccountID: cash.ID, Credit: gross},
+		},
+	}
+	posted, err := s.ledgerRepo.PostEntry(ctx, entry)
+	if err != nil {
+		logger.Log.Error("冲销记账失败",
+			zap.Uint("refundID", refund.ID),
+			zap.Error(err))
+		return nil
+	}
+	if posted {
+		logger.Log.Info("退款已冲销业主收入",
+			zap.Uint("entryID", entry.ID),
+			zap.Uint("ownerID", original.OwnerID),
+			zap.Float64("gross", gross),
+			zap.Float64("ownerShare", share))
+	}
+	return nil
+}
+
+func (s *LedgerService) ensureAccount(
+	ctx context.Context,
+	code, name string,
+	accountType models.AccountType,
+	ownerID *uint,
+) (*models.LedgerAccount, error) {
+	return s.ledgerRepo.EnsureAccount(ctx, &models.LedgerAccount{
+		Code:    code,
+		Name:    name,
+		Type:    accountType,
+		OwnerID: ownerID,
+	})
+}
+
+func (s *LedgerService) ownerAccount(ctx context.Context, ownerID uint) (*models.LedgerAccount, error) {
+	return s.ensureAccount(ctx, ownerAccountCode(ownerID), fmt.Sprintf("业主 %d 应付款", ownerID), models.AccountLiability, &ownerID)
+}
+
+func ownerAccountCode(ownerID uint) string {
+	return fmt.Sprintf("owner:%d:payable", ownerID)
+}
+
+// OwnerStatement 生成业主指定月份的对账单，month 取该月任意时刻
+func (s *LedgerService) OwnerStatement(ctx context.Context, ownerID uint, month time.Time) (*OwnerStatement, error) {
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	end := start.AddDate(0, 1, 0)
+
+	entries, err := s.ledgerRepo.ListOwnerEntries(ctx, ownerID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("查询业主收入明细失败: %w", err)
+	}
+
+	statement := &OwnerStatement{
+		OwnerID:     ownerID,
+		Month:       start.Format("2006-01"),
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Entries:     entries,
+	}
+	for _, e := range entries {
+		statement.Gross += e.Gross
+		statement.Commission += e.Commission
+		statement.OwnerShare += e.OwnerShare
+	}
+
+	account, err := s.ledgerRepo.GetAccountByCode(ctx, ownerAccountCode(ownerID))
+	if err != nil {
+		return nil, fmt.Errorf("查询业主科目失败: %w", err)
+	}
+	if account != nil {
+		if statement.OpeningBalance, err = s.ledgerRepo.AccountBalance(ctx, account.ID, start); err != nil {
+			return nil, fmt.Errorf("统计期初余额失败: %w", err)
+		}
+		if statement.ClosingBalance, err = s.ledgerRepo.AccountBalance(ctx, account.ID, end); err != nil {
+			return nil, fmt.Errorf("统计期末余额失败: %w", err)
+		}
+	}
+
+	statement.Gross = math.Round(statement.Gross*100) / 100
+	statement.Commission = math.Round(statement.Commission*100) / 100
+	statement.OwnerShare = math.Round(statement.OwnerShare*100) / 100
+	return statement, nil
+}
@@ -0,0 +1,155 @@
+// internal/services/login_limiter.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"modules/config"
+	"modules/internal/models"
+	"modules/internal/repositories"
+	"modules/pkg/logger"
+	"strings"
+	"time"
+)
+
+// 登录锁定默认配置
+const (
+	defaultLockoutAccountThreshold = 5
+	defaultLockoutIPThreshold      = 20
+	defaultLockoutBaseDuration     = time.Minute
+	defaultLockoutMaxDuration      = time.Hour
+	defaultLockoutResetAfter       = 24 * time.Hour
+	// 与 LoginAttempt.Key 的字段长度一致
+	maxLoginAttemptKeyLen = 100
+)
+
+// LoginLockedError 账户或 IP 处于锁定状态，RetryAfter 为剩余锁定时长
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return models.ErrLoginLocked.Error()
+}
+
+func (e *LoginLockedError) Unwrap() error {
+	return models.ErrLoginLocked
+}
+
+// loginLimiter 按账户和 IP 统计连续登录失败次数，达到阈值后渐进锁定
+type loginLimiter struct {
+	attemptRepo      repositories.LoginAttemptRepository
+	accountThreshold int
+	ipThreshold      int
+	baseDuration     time.Duration
+	maxDuration      time.Duration
+	resetAfter       time.Duration
+}
+
+func newLoginLimiter(ar repositories.LoginAttemptRepository, cfg config.LockoutConfig) *loginLimiter {
+	l := &loginLimiter{
+		attemptRepo:      ar,
+		accountThreshold: cfg.AccountThreshold,
+		ipThreshold:      cfg.IPThreshold,
+		baseDuration:     parseDurationOr(cfg.BaseDuration, defaultLockoutBaseDuration, "lockout.base_duration"),
+		maxDuration:      parseDurationOr(cfg.MaxDuration, defaultLockoutMaxDuration, "lockout.max_duration"),
+		resetAfter:       parseDurationOr(cfg.ResetAfter, defaultLockoutResetAfter, "lockout.reset_after"),
+	}
+	if l.accountThreshold <= 0 {
+		l.accountThreshold = defaultLockoutAccountThreshold
+	}
+	if l.ipThreshold <= 0 {
+		l.ipThreshold = defaultLockoutIPThreshold
+	}
+	return l
+}
+
+// Check 账户或 IP 处于锁定状态时返回 *LoginLockedError
+func (l *loginLimiter) Check(ctx context.Context, username, ip string) error {
+	now := time.Now()
+	var retryAfter time.Duration
+	for _, k := range l.keys(username, ip) {
+		attempt, err := l.attemptRepo.GetAttempt(ctx, k.scope, k.key)
+		if err != nil {
+			return fmt.Errorf("查询登录失败记录失败: %w", err)
+		}
+		if attempt != nil && attempt.Locked(now) {
+			if d := attempt.LockedUntil.Sub(now); d > retryAfter {
+				retryAfter = d
+			}
+		}
+	}
+	if retryAfter > 0 {
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// RecordFailure 记录一次失败登录，达到阈值后锁定账户或 IP。记录失败只写日志
+func (l *loginLimiter) RecordFailure(ctx context.Context, username, ip string) {
+	now := time.Now()
+	for _, k := range l.keys(username, ip) {
+		threshold := l.accountThreshold
+		if k.scope == models.LoginScopeIP {
+			threshold = l.ipThreshold
+		}
+		attempt, err := l.attemptRepo.RecordFailure(ctx, k.scope, k.key, func(a *models.LoginAttempt) {
+			if a.Failures > 0 && now.Sub(a.LastFailedAt) > l.resetAfter {
+				a.Failures = 0
+			}
+			a.Failures++
+			a.LastFailedAt = now
+			if a.Failures >= threshold {
+				until := now.Add(exponentialBackoff(l.baseDuration, a.Failures-threshold+1, l.maxDuration))
+				a.LockedUntil = &until
+			}
+		})
+		if err != nil {
+			logger.Log.Error("记录登录失败次数失败",
+				zap.String("scope", string(k.scope)),
+				zap.String("key", k.key),
+				zap.Error(err))
+			continue
+		}
+		if attempt.Locked(now) {
+			logger.Log.Warn("登录失败次数过多，已锁定",
+				zap.String("scope", string(k.scope)),
+				zap.String("key", k.key),
+				zap.Int("failures", attempt.Failures),
+				zap.Time("lockedUntil", *attempt.LockedUntil))
+		}
+	}
+}
+
+// ResetAccount 清除账户的失败记录。IP 的记录不随登录成功清除，避免攻击者用自己的账户重置计数
+func (l *loginLimiter) ResetAccount(ctx context.Context, username string) error {
+	return l.attemptRepo.Reset(ctx, models.LoginScopeAccount, accountKey(username))
+}
+
+// Purge 清理长时间没有失败且已解除锁定的记录
+func (l *loginLimiter) Purge(ctx context.Context) error {
+	return l.attemptRepo.DeleteStale(ctx, time.Now().Add(-l.resetAfter))
+}
+
+type loginAttemptKey struct {
+	scope models.LoginAttemptScope
+	key   string
+}
+
+func (l *loginLimiter) keys(username, ip string) []loginAttemptKey {
+	keys := []loginAttemptKey{{scope: models.LoginScopeAccount, key: accountKey(username)}}
+	if ip != "" {
+		keys = append(keys, loginAttemptKey{scope: models.LoginScopeIP, key: ip})
+	}
+	return keys
+}
+
+// accountKey 与用户名查询一致，不区分大小写；超长的输入截断到字段长度
+func accountKey(username string) string {
+	key := strings.ToLower(strings.TrimSpace(username))
+	if len(key) > maxLoginAttemptKeyLen {
+		key = key[:maxLoginAttemptKeyLen]
+	}
+	return key
+}
@@ -0,0 +1,271 @@
+// internal/services/maintenance_service.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"modules/internal/models"
+	"modules/internal/repositories"
+	"modules/pkg/logger"
+	"strings"
+	"time"
+)
+
+// MaintenanceService 车位维修工单：用户报修，管理员分诊、指派和关闭，工单状态与车位故障状态联动
+type MaintenanceService struct {
+	reportRepo   repositories.ReportRepository
+	parkingRepo  repositories.ParkingRepository
+	userRepo     repositories.UserRepository
+	auditService *AuditService
+}
+
+func NewMaintenanceService(
+	rr repositories.ReportRepository,
+	pr repositories.ParkingRepository,
+	ur repositories.UserRepository,
+	as *AuditService,
+) *MaintenanceService {
+	return &MaintenanceService{
+		reportRepo:   rr,
+		parkingRepo:  pr,
+		userRepo:     ur,
+		auditService: as,
+	}
+}
+
+// ReportProblem 上报车位故障，创建工单并将车位标记为故障
+func (s *MaintenanceService) ReportProblem(
+	ctx context.Context,
+	reporterID, spotID uint,
+	description string,
+) (*models.MaintenanceRecord, error) {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return nil, errors.New("故障描述不能为空")
+	}
+	spot, err := s.parkingRepo.GetSpotByID(ctx, spotID)
+	if err != nil {
+		return nil, models.ErrParkingSpotNotFound
+	}
+
+	record := &models.MaintenanceRecord{
+		SpotID:      spotID,
+		Description: description,
+		ReportedBy:  reporterID,
+		Status:      models.MaintenancePending,
+		Priority:    models.MaintenanceNormal,
+	}
+	history := &models.MaintenanceHistory{
+		ToStatus: models.MaintenancePending,
+		ActorID:  reporterID,
+		Note:     description,
+	}
+	if err := s.reportRepo.SaveMaintenance(ctx, record, history); err != nil {
+		return nil, fmt.Errorf("创建维修工单失败: %w", err)
+	}
+
+	if spot.Status != string(models.Faulty) {
+		if err := s.parkingRepo.UpdateStatus(ctx, spotID, models.Faulty); err != nil {
+			logger.Log.Error("报修后标记车位故障失败",
+				zap.Uint("spotID", spotID),
+				zap.Uint("recordID", record.ID),
+				zap.Error(err))
+		} else {
+			s.auditService.RecordSystem(ctx, models.AuditSpotMarkedFaulty, models.AuditTargetSpot, spotID,
+				fmt.Sprintf("车位 %d 已标记为故障", spotID),
+				fmt.Sprintf("用户 %d 上报维修工单 %d，车位 %d 已自动标记为故障：%s",
+					reporterID, record.ID, spotID, description))
+		}
+	}
+
+	logger.Log.Info("车位报修",
+		zap.Uint("recordID", record.ID),
+		zap.Uint("spotID", spotID),
+		zap.Uint("reportedBy", reporterID))
+	return record, nil
+}
+
+// ListTickets 管理员查询维修工单
+func (s *MaintenanceService) ListTickets(
+	ctx context.Context,
+	filter repositories.MaintenanceFilter,
+) ([]*models.MaintenanceRecord, error) {
+	records, err := s.reportRepo.ListMaintenance(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("查询维修工单失败: %w", err)
+	}
+	return records, nil
+}
+
+// ListMyTickets 查询用户自己上报的维修工单
+func (s *MaintenanceService) ListMyTickets(ctx context.Context, userID uint) ([]*models.MaintenanceRecord, error) {
+	return s.ListTickets(ctx, repositories.MaintenanceFilter{ReportedBy: userID})
+}
+
+// GetTicket 查询维修工单详情，包含状态变更历史
+func (s *MaintenanceService) GetTicket(ctx context.Context, id uint) (*models.MaintenanceRecord, error) {
+	return s.reportRepo.GetMaintenanceByID(ctx, id)
+}
+
+// Triage 分诊：确认故障并设置优先级
+func (s *MaintenanceService) Triage(
+	ctx context.Context,
+	adminID, id uint,
+	priority, note string,
+) (*models.MaintenanceRecord, error) {
+	if !validMaintenancePriority(priority) {
+		return nil, fmt.Errorf("无效的优先级: %s", priority)
+	}
+
+	record, err := s.reportRepo.GetMaintenanceByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status != models.MaintenancePending {
+		return nil, fmt.Errorf("工单当前状态为 %s，无法分诊", record.Status)
+	}
+
+	record.Priority = priority
+	if err := s.transition(ctx, record, models.MaintenanceTriaged, adminID, note); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Assign 将工单指派给维修人员，已指派的工单可以改派
+func (s *MaintenanceService) Assign(
+	ctx context.Context,
+	adminID, id, assigneeID uint,
+	note string,
+) (*models.MaintenanceRecord, error) {
+	assignee, err := s.userRepo.GetUserByID(ctx, assigneeID)
+	if err != nil {
+		return nil, fmt.Errorf("查询维修人员失败: %w", err)
+	}
+	if assignee == nil {
+		return nil, models.ErrUserNotFound
+	}
+
+	record, err := s.reportRepo.GetMaintenanceByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status == models.MaintenanceResolved {
+		return nil, errors.New("工单已关闭，无法指派")
+	}
+
+	record.AssigneeID = &assignee.ID
+	if note == "" {
+		note = fmt.Sprintf("指派给 %s", assignee.Username)
+	}
+	if err := s.transition(ctx, record, models.MaintenanceAssigned, adminID, note); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Resolve 关闭工单；车位上最后一张未关闭的工单处理完成后车位恢复空闲
+func (s *MaintenanceService) Resolve(
+	ctx context.Context,
+	adminID, id uint,
+	resolution string,
+) (*models.MaintenanceRecord, error) {
+	record, err := s.reportRepo.GetMaintenanceByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status == models.MaintenanceResolved {
+		return nil, errors.New("工单已关闭")
+	}
+
+	now := time.Now()
+	record.ResolvedAt = &now
+	record.Resolution = resolution
+	if err := s.transition(ctx, record, models.MaintenanceResolved, adminID, resolution); err != nil {
+		return nil, err
+	}
+
+	s.restoreSpot(ctx, record.SpotID)
+	return record, nil
+}
+
+// transition 变更工单状态并记录历史
+func (s *MaintenanceService) transition(
+	ctx context.Context,
+	record *models.MaintenanceRecord,
+	to string,
+	actorID uint,
+	note string,
+) error {
+	history := &models.MaintenanceHistory{
+		FromStatus: record.Status,
+		ToStatus:   to,
+		ActorID:    actorID,
+		Note:       note,
+	}
+	record.Status = to
+	if err := s.reportRepo.SaveMaintenance(ctx, record, history); err != nil {
+		return fmt.Errorf("更新维修工单失败: %w", err)
+	}
+	record.History = append(record.History, *history)
+
+	logger.Log.Info("维修工单状态变更",
+		zap.Uint("recordID", record.ID),
+		zap.String("from", history.FromStatus),
+		zap.String("to", to),
+		zap.Uint("actorID", actorID))
+	return nil
+}
+
+// restoreSpot 车位没有未关闭的工单且仍处于故障状态时恢复：车辆仍在位时恢复为占用，否则恢复为空闲
+func (s *MaintenanceService) restoreSpot(ctx context.Context, spotID uint) {
+	pending, err := s.reportRepo.HasPendingMaintenance(ctx, spotID)
+	if err != nil {
+		logger.Log.Error("查询车位未关闭工单失败", zap.Uint("spotID", spotID), zap.Error(err))
+		return
+	}
+	if pending {
+		return
+	}
+
+	spot, err := s.parkingRepo.GetSpotByID(ctx, spotID)
+	if err != nil {
+		logger.Log.Error("查询车位失败", zap.Uint("spotID", spotID), zap.Error(err))
+		return
+	}
+	if spot.Status != string(models.Faulty) {
+		return
+	}
+
+	occupied := spot.License != ""
+	if !occupied {
+		if occupied, err = s.parkingRepo.HasOngoingRecordForSpot(ctx, spotID); err != nil {
+			logger.Log.Error("查询车位停车记录失败", zap.Uint("spotID", spotID), zap.Error(err))
+			return
+		}
+	}
+	status, label := models.Idle, "空闲"
+	if occupied {
+		status, label = models.Occupied, "占用"
+	}
+
+	if err := s.parkingRepo.UpdateStatus(ctx, spotID, status); err != nil {
+		logger.Log.Error("维修完成后恢复车位状态失败", zap.Uint("spotID", spotID), zap.Error(err))
+		return
+	}
+	logger.Log.Info("维修完成，车位恢复"+label, zap.Uint("spotID", spotID))
+
+	s.auditService.RecordSystem(ctx, models.AuditSpotRestored, models.AuditTargetSpot, spotID,
+		fmt.Sprintf("车位 %d 维修完成", spotID),
+		fmt.Sprintf("车位 %d 的维修工单已全部关闭，系统已自动恢复为%s", spotID, label))
+}
+
+func validMaintenancePriority(priority string) bool {
+	switch priority {
+	case models.MaintenanceLow, models.MaintenanceNormal, models.MaintenanceHigh, models.MaintenanceUrgent:
+		return true
+	}
+	return false
+}
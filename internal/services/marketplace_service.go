@@ -0,0 +1,356 @@
+// internal/services/marketplace_service.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"math"
+	"modules/internal/models"
+	"modules/internal/repositories"
+	"modules/pkg/logger"
+	"strings"
+	"time"
+)
+
+// MarketplaceService 业主之间的车位出租市场：发布出租信息、预订申请与审批
+type MarketplaceService struct {
+	listingRepo  repositories.ListingRepository
+	parkingRepo  repositories.ParkingRepository
+	leaseService *LeaseService
+}
+
+func NewMarketplaceService(
+	lr repositories.ListingRepository,
+	pr repositories.ParkingRepository,
+	ls *LeaseService,
+) *MarketplaceService {
+	return &MarketplaceService{
+		listingRepo:  lr,
+		parkingRepo:  pr,
+		leaseService: ls,
+	}
+}
+
+// CreateListing 业主发布自己车位的出租信息
+func (s *MarketplaceService) CreateListing(
+	ctx context.Context,
+	ownerID, spotID uint,
+	title string,
+	unit models.PriceUnit,
+	price float64,
+	terms string,
+	windows []models.ListingWindow,
+) (*models.SpotListing, error) {
+	spot, err := s.parkingRepo.GetSpotByID(ctx, spotID)
+	if err != nil {
+		return nil, models.ErrParkingSpotNotFound
+	}
+	if spot.OwnerID == 0 {
+		return nil, errors.New("车位无业主，无法出租")
+	}
+	if spot.OwnerID != ownerID {
+		return nil, errors.New("无权操作该车位")
+	}
+	if unit != models.PriceMonthly && unit != models.PriceHourly {
+		return nil, fmt.Errorf("不支持的计价单位: %s", unit)
+	}
+	if price <= 0 {
+		return nil, errors.New("出租价格必须为正数")
+	}
+	if len(windows) == 0 {
+		return nil, errors.New("至少需要一个可租时段")
+	}
+	now := time.Now()
+	for _, w := range windows {
+		if !w.EndTime.After(w.StartTime) {
+			return nil, errors.New("可租时段的结束时间必须晚于开始时间")
+		}
+		if !w.EndTime.After(now) {
+			return nil, errors.New("可租时段已过期")
+		}
+	}
+
+	title = strings.TrimSpace(title)
+	if title == "" {
+		title = fmt.Sprintf("车位 %d 出租", spot.ID)
+	}
+
+	listing := &models.SpotListing{
+		SpotID:    spot.ID,
+		OwnerID:   ownerID,
+		SpotType:  models.ParkingType(spot.Type),
+		Title:     title,
+		PriceUnit: unit,
+		Price:     price,
+		Terms:     terms,
+		Status:    models.ListingOpen,
+		Windows:   windows,
+	}
+	if err := s.listingRepo.CreateListing(ctx, listing); err != nil {
+		return nil, fmt.Errorf("发布出租信息失败: %w", err)
+	}
+
+	logger.Log.Info("车位出租信息已发布",
+		zap.Uint("listingID", listing.ID),
+		zap.Uint("ownerID", ownerID),
+		zap.Uint("spotID", spot.ID))
+	return listing, nil
+}
+
+// SearchListings 分页搜索上架中的出租信息
+func (s *MarketplaceService) SearchListings(
+	ctx context.Context,
+	filter repositories.ListingFilter,
+	page, pageSize int,
+) ([]*models.SpotListing, int64, error) {
+	filter.Status = models.ListingOpen
+	filter.OwnerID = 0
+	filter.Offset = (page - 1) * pageSize
+	filter.Limit = pageSize
+	return s.listingRepo.SearchListings(ctx, filter)
+}
+
+// ListOwnerListings 查询业主发布的全部出租信息
+func (s *MarketplaceService) ListOwnerListings(ctx context.Context, ownerID uint) ([]*models.SpotListing, error) {
+	listings, _, err := s.listingRepo.SearchListings(ctx, repositories.ListingFilter{OwnerID: ownerID})
+	return listings, err
+}
+
+func (s *MarketplaceService) GetListing(ctx context.Context, listingID uint) (*models.SpotListing, error) {
+	return s.listingRepo.GetListingByID(ctx, listingID)
+}
+
+// CloseListing 业主下架出租信息，已提交的待处理申请一并拒绝
+func (s *MarketplaceService) CloseListing(ctx context.Context, ownerID, listingID uint) (*models.SpotListing, error) {
+	listing, err := s.listingRepo.GetListingByID(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+	if listing.OwnerID != ownerID {
+		return nil, models.ErrListingNotFound
+	}
+	if listing.Status == models.ListingClosed {
+		return listing, nil
+	}
+
+	if err := s.listingRepo.UpdateListingStatus(ctx, listing.ID, models.ListingClosed); err != nil {
+		return nil, fmt.Errorf("下架出租信息失败: %w", err)
+	}
+	listing.Status = models.ListingClosed
+
+	pending, err := s.listingRepo.ListBookings(ctx, repositories.BookingFilter{
+		ListingID: listing.ID,
+		Status:    models.BookingPending,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询待处理申请失败: %w", err)
+	}
+	for _, booking := range pending {
+		if err := s.decide(ctx, booking, models.BookingDeclined, "出租信息已下架"); err != nil {
+			logger.Log.Error("拒绝预订申请失败",
+				zap.Uint("bookingID", booking.ID),
+				zap.Error(err))
+		}
+	}
+	return listing, nil
+}
+
+// RequestBooking 租客在出租信息的可租时段内申请预订
+func (s *MarketplaceService) RequestBooking(
+	ctx context.Context,
+	renterID, listingID uint,
+	start, end time.Time,
+	message string,
+) (*models.BookingRequest, error) {
+	listing, err := s.listingRepo.GetListingByID(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+	if listing.Status != models.ListingOpen {
+		return nil, models.ErrListingClosed
+	}
+	if listing.OwnerID == renterID {
+		return nil, errors.New("不能预订自己发布的车位")
+	}
+	if !end.After(start) {
+		return nil, errors.New("结束时间必须晚于开始时间")
+	}
+	if start.Before(time.Now().Add(-time.Minute)) {
+		return nil, errors.New("开始时间不能早于当前时间")
+	}
+
+	covered := false
+	for _, w := range listing.Windows {
+		if !start.Before(w.StartTime) && !end.After(w.EndTime) {
+			covered = true
+			break
+		}
+	}
+	if !covered {
+		return nil, errors.New("申请时段不在可租时段内")
+	}
+
+	booking := &models.BookingRequest{
+		ListingID: listing.ID,
+		SpotID:    listing.SpotID,
+		RenterID:  renterID,
+		OwnerID:   listing.OwnerID,
+		StartTime: start,
+		EndTime:   end,
+		Amount:    bookingAmount(listing.PriceUnit, listing.Price, start, end),
+		Message:   strings.TrimSpace(message),
+		Status:    models.BookingPending,
+	}
+	if err := s.listingRepo.CreateBooking(ctx, booking); err != nil {
+		return nil, fmt.Errorf("提交预订申请失败: %w", err)
+	}
+
+	logger.Log.Info("收到车位预订申请",
+		zap.Uint("bookingID", booking.ID),
+		zap.Uint("listingID", listing.ID),
+		zap.Uint("renterID", renterID))
+	return booking, nil
+}
+
+// ListRenterBookings 查询租客提交的预订申请
+func (s *MarketplaceService) ListRenterBookings(
+	ctx context.Context,
+	renterID uint,
+	status models.BookingStatus,
+) ([]*models.BookingRequest, error) {
+	return s.listingRepo.ListBookings(ctx, repositories.BookingFilter{RenterID: renterID, Status: status})
+}
+
+// ListOwnerBookings 查询业主收到的预订申请
+func (s *MarketplaceService) ListOwnerBookings(
+	ctx context.Context,
+	ownerID uint,
+	status models.BookingStatus,
+) ([]*models.BookingRequest, error) {
+	return s.listingRepo.ListBookings(ctx, repositories.BookingFilter{OwnerID: ownerID, Status: status})
+}
+
+// AcceptBooking 业主接受预订申请，为租客生成待支付的租赁订单，并拒绝同一时段的其他申请
+func (s *MarketplaceService) AcceptBooking(
+	ctx context.Context,
+	ownerID, bookingID uint,
+	note string,
+) (*models.BookingRequest, *models.LeaseOrder, error) {
+	booking, err := s.ownerPendingBooking(ctx, ownerID, bookingID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	listing, err := s.listingRepo.GetListingByID(ctx, booking.ListingID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if listing.Status != models.ListingOpen {
+		return nil, nil, models.ErrListingClosed
+	}
+	spot, err := s.parkingRepo.GetSpotByID(ctx, booking.SpotID)
+	if err != nil {
+		return nil, nil, models.ErrParkingSpotNotFound
+	}
+	if spot.OwnerID != ownerID {
+		return nil, nil, errors.New("车位已不属于当前业主")
+	}
+
+	// 订单与申请状态在同一事务中提交，与租客撤回或并发接受互斥
+	lease, err := s.leaseService.CreateBookedLease(ctx, booking, note)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := s.listingRepo.DeclineOverlappingBookings(ctx, booking.SpotID, booking.ID,
+		booking.StartTime, booking.EndTime, "该时段已租给其他租客"); err != nil {
+		logger.Log.Error("拒绝冲突预订申请失败",
+			zap.Uint("bookingID", booking.ID),
+			zap.Error(err))
+	}
+
+	logger.Log.Info("预订申请已接受",
+		zap.Uint("bookingID", booking.ID),
+		zap.Uint("leaseID", lease.ID))
+	return booking, lease, nil
+}
+
+// DeclineBooking 业主拒绝预订申请
+func (s *MarketplaceService) DeclineBooking(ctx context.Context, ownerID, bookingID uint, note string) (*models.BookingRequest, error) {
+	booking, err := s.ownerPendingBooking(ctx, ownerID, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.decide(ctx, booking, models.BookingDeclined, note); err != nil {
+		return nil, err
+	}
+	return booking, nil
+}
+
+// CancelBooking 租客撤回尚未处理的预订申请
+func (s *MarketplaceService) CancelBooking(ctx context.Context, renterID, bookingID uint) (*models.BookingRequest, error) {
+	booking, err := s.listingRepo.GetBookingByID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.RenterID != renterID {
+		return nil, models.ErrBookingNotFound
+	}
+	if booking.Status != models.BookingPending {
+		return nil, fmt.Errorf("预订申请当前状态为 %s，无法撤回", booking.Status)
+	}
+
+	booking.Status = models.BookingCancelled
+	cancelled, err := s.listingRepo.TransitionBooking(ctx, booking, models.BookingPending)
+	if err != nil {
+		return nil, fmt.Errorf("撤回预订申请失败: %w", err)
+	}
+	if !cancelled {
+		return nil, models.ErrBookingNotPending
+	}
+	return booking, nil
+}
+
+func (s *MarketplaceService) ownerPendingBooking(ctx context.Context, ownerID, bookingID uint) (*models.BookingRequest, error) {
+	booking, err := s.listingRepo.GetBookingByID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.OwnerID != ownerID {
+		return nil, models.ErrBookingNotFound
+	}
+	if booking.Status != models.BookingPending {
+		return nil, fmt.Errorf("预订申请当前状态为 %s，无法处理", booking.Status)
+	}
+	return booking, nil
+}
+
+func (s *MarketplaceService) decide(ctx context.Context, booking *models.BookingRequest, status models.BookingStatus, note string) error {
+	now := time.Now()
+	booking.Status = status
+	booking.DecisionNote = strings.TrimSpace(note)
+	booking.DecidedAt = &now
+	updated, err := s.listingRepo.TransitionBooking(ctx, booking, models.BookingPending)
+	if err != nil {
+		return fmt.Errorf("更新预订申请失败: %w", err)
+	}
+	if !updated {
+		return models.ErrBookingNotPending
+	}
+	return nil
+}
+
+// bookingAmount 按计价单位计算租金：按月计价不足一月按一月计，按小时计价不足一小时按一小时计
+func bookingAmount(unit models.PriceUnit, price float64, start, end time.Time) float64 {
+	var units int
+	switch unit {
+	case models.PriceMonthly:
+		for units = 1; start.AddDate(0, units, 0).Before(end); units++ {
+		}
+	default:
+		units = int(math.Ceil(end.Sub(start).Hours()))
+	}
+	return math.Round(price*float64(units)*100) / 100
+}
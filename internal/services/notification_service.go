@@ -0,0 +1,246 @@
+// internal/services/notification_service.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"modules/internal/models"
+	"modules/internal/repositories"
+	"modules/pkg/logger"
+	"modules/pkg/notifier"
+	"time"
+)
+
+// NotificationService 通知分发：所有通知都会保存为站内信，并按用户偏好额外通过邮件或短信投递
+type NotificationService struct {
+	notificationRepo repositories.NotificationRepository
+	userRepo         repositories.UserRepository
+	email            notifier.Client
+	sms              notifier.SMSSender
+}
+
+func NewNotificationService(
+	nr repositories.NotificationRepository,
+	ur repositories.UserRepository,
+	email notifier.Client,
+	sms notifier.SMSSender,
+) *NotificationService {
+	return &NotificationService{
+		notificationRepo: nr,
+		userRepo:         ur,
+		email:            email,
+		sms:              sms,
+	}
+}
+
+// Notify 按用户语言渲染事件对应的模板并分发。通知失败不影响业务流程，仅记录日志
+func (s *NotificationService) Notify(ctx context.Context, userID uint, event models.NotificationEvent, data interface{}) {
+	user := s.recipient(ctx, userID, event)
+	if user == nil {
+		return
+	}
+	title, body, err := notifier.Render(notifier.TemplateName(event), user.Language, user.Username, data)
+	if err != nil {
+		logger.Log.Error("渲染通知失败",
+			zap.Uint("userID", userID),
+			zap.String("event", string(event)),
+			zap.Error(err))
+		return
+	}
+	s.deliver(ctx, user, event, title, body)
+}
+
+// NotifyMessage 分发已经写好标题和内容的通知
+func (s *NotificationService) NotifyMessage(
+	ctx context.Context,
+	userID uint,
+	event models.NotificationEvent,
+	title, body string,
+) {
+	user := s.recipient(ctx, userID, event)
+	if user == nil {
+		return
+	}
+	s.deliver(ctx, user, event, title, body)
+}
+
+// SendEmail 直接向指定邮箱发送模板邮件，不保存站内信也不考虑用户偏好，用于密码重置、邮箱验证等账户安全邮件
+func (s *NotificationService) SendEmail(
+	ctx context.Context,
+	user *models.User,
+	to string,
+	event models.NotificationEvent,
+	data interface{},
+) error {
+	if err := notifier.SendTemplate(s.email, to, user.Language, user.Username, notifier.TemplateName(event), data); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	logger.Log.Info("账户邮件已发送",
+		zap.Uint("userID", user.ID),
+		zap.String("event", string(event)))
+	return nil
+}
+
+func (s *NotificationService) recipient(ctx context.Context, userID uint, event models.NotificationEvent) *models.User {
+	if userID == 0 {
+		return nil
+	}
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Log.Error("发送通知时查询用户失败",
+			zap.Uint("userID", userID),
+			zap.String("event", string(event)),
+			zap.Error(err))
+		return nil
+	}
+	return user
+}
+
+// deliver 保存站内信，再按偏好投递到邮件或短信
+func (s *NotificationService) deliver(
+	ctx context.Context,
+	user *models.User,
+	event models.NotificationEvent,
+	title, body string,
+) {
+	if err := s.notificationRepo.CreateNotification(ctx, &models.Notification{
+		UserID: user.ID,
+		Event:  event,
+		Title:  title,
+		Body:   body,
+	}); err != nil {
+		logger.Log.Error("保存站内信失败",
+			zap.Uint("userID", user.ID),
+			zap.String("event", string(event)),
+			zap.Error(err))
+	}
+
+	channel, err := s.channelFor(ctx, user.ID, event)
+	if err != nil {
+		logger.Log.Error("查询通知偏好失败", zap.Uint("userID", user.ID), zap.Error(err))
+		channel = models.DefaultNotificationChannel
+	}
+
+	switch channel {
+	case models.ChannelEmail:
+		if user.Email == "" {
+			return
+		}
+		err = s.email.SendNotification(user.Email, title, body)
+	case models.ChannelSMS:
+		if user.Phone == "" {
+			logger.Log.Warn("用户未绑定手机号，短信通知仅保存为站内信",
+				zap.Uint("userID", user.ID),
+				zap.String("event", string(event)))
+			return
+		}
+		err = s.sms.SendSMS(user.Phone, fmt.Sprintf("%s\n%s", title, body))
+	default:
+		return
+	}
+	if err != nil {
+		logger.Log.Error("发送通知失败",
+			zap.Uint("userID", user.ID),
+			zap.String("event", string(event)),
+			zap.String("channel", string(channel)),
+			zap.Error(err))
+		return
+	}
+	logger.Log.Info("通知已发送",
+		zap.Uint("userID", user.ID),
+		zap.String("event", string(event)),
+		zap.String("channel", string(channel)))
+}
+
+func (s *NotificationService) channelFor(ctx context.Context, userID uint, event models.NotificationEvent) (models.NotificationChannel, error) {
+	prefs, err := s.notificationRepo.GetPreferences(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	for _, p := range prefs {
+		if p.Event == event {
+			return p.Channel, nil
+		}
+	}
+	return models.DefaultNotificationChannel, nil
+}
+
+// ListNotifications 分页查询用户的站内信
+func (s *NotificationService) ListNotifications(
+	ctx context.Context,
+	userID uint,
+	unreadOnly bool,
+	page, pageSize int,
+) ([]*models.Notification, int64, error) {
+	items, total, err := s.notificationRepo.ListNotifications(ctx, repositories.NotificationFilter{
+		UserID:     userID,
+		UnreadOnly: unreadOnly,
+		Offset:     (page - 1) * pageSize,
+		Limit:      pageSize,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询站内信失败: %w", err)
+	}
+	return items, total, nil
+}
+
+// MarkRead 将站内信标记为已读
+func (s *NotificationService) MarkRead(ctx context.Context, userID, id uint) (*models.Notification, error) {
+	return s.notificationRepo.MarkRead(ctx, userID, id, time.Now())
+}
+
+// GetPreferences 返回用户对每类事件的投递渠道，未设置的事件使用默认渠道
+func (s *NotificationService) GetPreferences(ctx context.Context, userID uint) (map[models.NotificationEvent]models.NotificationChannel, error) {
+	prefs, err := s.notificationRepo.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询通知偏好失败: %w", err)
+	}
+
+	result := make(map[models.NotificationEvent]models.NotificationChannel, len(models.NotificationEvents))
+	for _, event := range models.NotificationEvents {
+		result[event] = models.DefaultNotificationChannel
+	}
+	for _, p := range prefs {
+		result[p.Event] = p.Channel
+	}
+	return result, nil
+}
+
+// UpdatePreferences 更新用户的通知偏好，未提及的事件保持不变
+func (s *NotificationService) UpdatePreferences(
+	ctx context.Context,
+	userID uint,
+	changes map[models.NotificationEvent]models.NotificationChannel,
+) (map[models.NotificationEvent]models.NotificationChannel, error) {
+	prefs := make([]*models.NotificationPreference, 0, len(changes))
+	for event, channel := range changes {
+		if !validNotificationEvent(event) {
+			return nil, fmt.Errorf("不支持的通知类型: %s", event)
+		}
+		switch channel {
+		case models.ChannelEmail, models.ChannelSMS, models.ChannelInbox:
+		default:
+			return nil, fmt.Errorf("不支持的通知渠道: %s", channel)
+		}
+		prefs = append(prefs, &models.NotificationPreference{UserID: userID, Event: event, Channel: channel})
+	}
+	if len(prefs) == 0 {
+		return nil, errors.New("未指定任何通知偏好")
+	}
+
+	if err := s.notificationRepo.SavePreferences(ctx, prefs); err != nil {
+		return nil, fmt.Errorf("保存通知偏好失败: %w", err)
+	}
+	return s.GetPreferences(ctx, userID)
+}
+
+func validNotificationEvent(event models.NotificationEvent) bool {
+	for _, e := range models.NotificationEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+// internal/services/outbox_service.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"modules/config"
+	"modules/internal/models"
+	"modules/internal/repositories"
+	"modules/pkg/logger"
+	"strings"
+	"time"
+)
+
+// 发件箱分发默认配置
+const (
+	defaultOutboxPollInterval = 5 * time.Second
+	defaultOutboxMaxAttempts  = 10
+	defaultOutboxBackoffBase  = 10 * time.Second
+	// 重试间隔上限
+	maxOutboxBackoff = time.Hour
+	// 每次扫描最多分发的事件数
+	outboxDispatchBatch = 100
+	// 分发进行中的租约时长，超过后其他进程可以接手
+	outboxClaimDuration = 2 * time.Minute
+)
+
+// OutboxHandler 发件箱事件的处理器。返回错误时事件会按退避策略重试，已成功的处理器不再重复执行
+type OutboxHandler func(ctx context.Context, event *models.OutboxEvent) error
+
+type outboxSubscriber struct {
+	name    string
+	event   models.WebhookEvent // 为空表示处理全部事件
+	handler OutboxHandler
+}
+
+// OutboxService 分发业务事务中写入发件箱的领域事件，保证每个处理器至少执行一次
+type OutboxService struct {
+	outboxRepo   repositories.OutboxRepository
+	subscribers  []outboxSubscriber
+	pollInterval time.Duration
+	maxAttempts  int
+	backoffBase  time.Duration
+}
+
+func NewOutboxService(or repositories.OutboxRepository, cfg *config.Config) *OutboxService {
+	maxAttempts := cfg.Outbox.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultOutboxMaxAttempts
+	}
+	return &OutboxService{
+		outboxRepo:   or,
+		pollInterval: parseDurationOr(cfg.Outbox.PollInterval, defaultOutboxPollInterval, "outbox.poll_interval"),
+		maxAttempts:  maxAttempts,
+		backoffBase:  parseDurationOr(cfg.Outbox.BackoffBase, defaultOutboxBackoffBase, "outbox.backoff_base"),
+	}
+}
+
+// Subscribe 注册事件处理器，name 用于记录处理进度，必须唯一且保持稳定；event 为空表示处理全部事件
+func (s *OutboxService) Subscribe(name string, event models.WebhookEvent, handler OutboxHandler) {
+	s.subscribers = append(s.subscribers, outboxSubscriber{name: name, event: event, handler: handler})
+}
+
+// PollInterval 分发器的扫描间隔
+func (s *OutboxService) PollInterval() time.Duration {
+	return s.pollInterval
+}
+
+// Dispatch 分发到期的事件，由定时任务调用
+func (s *OutboxService) Dispatch(ctx context.Context) error {
+	now := time.Now()
+	due, err := s.outboxRepo.ListDueEvents(ctx, now, outboxDispatchBatch)
+	if err != nil {
+		return fmt.Errorf("查询待分发事件失败: %w", err)
+	}
+
+	for _, event := range due {
+		claimed, err := s.outboxRepo.ClaimEvent(ctx, event.ID, event.Attempts, now, now.Add(outboxClaimDuration))
+		if err != nil {
+			logger.Log.Error("抢占发件箱事件失败", zap.Uint("eventID", event.ID), zap.Error(err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		s.dispatch(ctx, event)
+	}
+	return nil
+}
+
+// dispatch 依次执行尚未处理过该事件的处理器，并根据结果更新事件状态
+func (s *OutboxService) dispatch(ctx context.Context, event *models.OutboxEvent) {
+	handled := make(map[string]bool, len(event.Deliveries))
+	for _, d := range event.Deliveries {
+		handled[d.Handler] = true
+	}
+
+	event.Attempts++
+	var failures []string
+	for _, sub := range s.subscribers {
+		if handled[sub.name] || (sub.event != "" && sub.event != event.Event) {
+			continue
+		}
+		if err := sub.handler(ctx, event); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", sub.name, err))
+			continue
+		}
+		if err := s.outboxRepo.RecordDelivery(ctx, event.ID, sub.name); err != nil {
+			// 未能登记时下次重试会再次执行该处理器，符合至少一次的语义
+			logger.Log.Error("登记事件处理进度失败",
+				zap.Uint("eventID", event.ID),
+				zap.String("handler", sub.name),
+				zap.Error(err))
+		}
+	}
+
+	now := time.Now()
+	switch {
+	case len(failures) == 0:
+		event.Status = models.OutboxDelivered
+		event.DeliveredAt = &now
+		event.LastError = ""
+	case event.Attempts >= s.maxAttempts:
+		event.Status = models.OutboxFailed
+		event.LastError = strings.Join(failures, "; ")
+	default:
+		event.Status = models.OutboxPending
+		event.NextAttemptAt = now.Add(exponentialBackoff(s.backoffBase, event.Attempts, maxOutboxBackoff))
+		event.LastError = strings.Join(failures, "; ")
+	}
+	if err := s.outboxRepo.SaveEvent(ctx, event); err != nil {
+		logger.Log.Error("更新发件箱事件状态失败", zap.Uint("eventID", event.ID), zap.Error(err))
+		return
+	}
+
+	if len(failures) > 0 {
+		logger.Log.Warn("发件箱事件分发失败",
+			zap.Uint("eventID", event.ID),
+			zap.String("event", string(event.Event)),
+			zap.Int("attempt", event.Attempts),
+			zap.String("status", string(event.Status)),
+			zap.String("error", event.LastError))
+	}
+}
+
+// Retry 立即重新分发一条未完成的事件，只执行尚未成功的处理器
+func (s *OutboxService) Retry(ctx context.Context, id uint) (*models.OutboxEvent, error) {
+	event, err := s.outboxRepo.GetEvent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if event.Status == models.OutboxDelivered {
+		return nil, fmt.Errorf("事件已分发完成")
+	}
+
+	s.dispatch(ctx, event)
+	return s.outboxRepo.GetEvent(ctx, id)
+}
+
+// ListEvents 分页查询发件箱事件
+func (s *OutboxService) ListEvents(
+	ctx context.Context,
+	status models.OutboxStatus,
+	event models.WebhookEvent,
+	page, pageSize int,
+) ([]*models.OutboxEvent, int64, error) {
+	events, total, err := s.outboxRepo.ListEvents(ctx, repositories.OutboxFilter{
+		Status: status,
+		Event:  event,
+		Offset: (page - 1) * pageSize,
+		Limit:  pageSize,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询发件箱事件失败: %w", err)
+	}
+	return events, total, nil
+}
+
+// GetEvent 查询事件详情，包含已完成的处理器
+func (s *OutboxService) GetEvent(ctx context.Context, id uint) (*models.OutboxEvent, error) {
+	return s.outboxRepo.GetEvent(ctx, id)
+}
+
+// exponentialBackoff 第 n 次失败后的重试间隔：base * 2^(n-1)，不超过 max
+func exponentialBackoff(base time.Duration, attempts int, max time.Duration) time.Duration {
+	d := base
+	for i := 1; i < attempts && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
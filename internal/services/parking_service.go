@@ -5,68 +5,129 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/goccy/go-json"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 	"modules/internal/models"
 	"modules/internal/repositories"
+	"modules/internal/tariff"
 	"modules/pkg/logger"
+	"modules/pkg/notifier"
 	"time"
 )
 
 type ParkingService struct {
-	parkingRepo repositories.ParkingRepository
-	userRepo    repositories.UserRepository
-	Notes       string `gorm:"type:text"`
+	parkingRepo        repositories.ParkingRepository
+	userRepo           repositories.UserRepository
+	vehicleRepo        repositories.VehicleRepository
+	tariffService      *TariffService
+	reservationService *ReservationService
+	paymentService     *PaymentService
+	reportRepo         repositories.ReportRepository
+	auditService       *AuditService
+	notifications      *NotificationService
+	Notes              string `gorm:"type:text"`
 }
 
+// 未配置自动恢复策略时展示的默认故障时长
+const defaultRecoveryHours = 24
+
 func NewParkingService(
 	pr repositories.ParkingRepository,
 	ur repositories.UserRepository,
+	vr repositories.VehicleRepository,
+	ts *TariffService,
+	rs *ReservationService,
+	ps *PaymentService,
+	rr repositories.ReportRepository,
+	as *AuditService,
+	ns *NotificationService,
+	obs *OutboxService,
 ) *ParkingService {
-	return &ParkingService{
-		parkingRepo: pr,
-		userRepo:    ur,
+	s := &ParkingService{
+		parkingRepo:        pr,
+		userRepo:           ur,
+		vehicleRepo:        vr,
+		tariffService:      ts,
+		reservationService: rs,
+		paymentService:     ps,
+		reportRepo:         rr,
+		auditService:       as,
+		notifications:      ns,
 	}
+	obs.Subscribe("exit_receipt", models.WebhookParkingExit, s.sendExitReceipt)
+	return s
 }
 
-// 计算停车费用
-func (s *ParkingService) CalculateFee(record *models.ParkingRecord, spot *models.ParkingSpot) float64 {
+// CalculateFee 按当前生效资费计算停车费用明细，同时返回计费所用的资费版本（未配置资费时为 nil）
+func (s *ParkingService) CalculateFee(
+	ctx context.Context,
+	record *models.ParkingRecord,
+	spot *models.ParkingSpot,
+) (*tariff.Quote, *models.Tariff, error) {
+	free := &tariff.Quote{
+		SpotType: models.ParkingType(spot.Type),
+		BaseRate: spot.HourlyRate,
+		Days:     []tariff.DayCharge{},
+	}
 	if record.ExitTime == nil {
-		return 0
+		return free, nil, nil
 	}
+	covered := *free
+	covered.LeaseCovered = true
 
-	duration := record.ExitTime.Sub(record.EntryTime).Hours()
-
-	// 将 spot.Type 与 string 类型的枚举值进行比较
-	switch string(spot.Type) {
-	case string(models.ShortTerm):
-		// 当 ExpiresAt 不为空字符串时进行解析
-		if spot.ExpiresAt != "" {
-			// 假设 ExpiresAt 格式为 RFC3339，可根据实际情况调整
-			expiresAt, err := time.Parse(time.RFC3339, spot.ExpiresAt)
-			if err == nil && time.Now().After(expiresAt) {
-				return duration * spot.HourlyRate
-			}
+	// 短租车位仅在租期结束后才按资费收费
+	if models.ParkingType(spot.Type) == models.ShortTerm {
+		if spot.ExpiresAt == "" {
+			return &covered, nil, nil
+		}
+		// 假设 ExpiresAt 格式为 RFC3339，可根据实际情况调整
+		expiresAt, err := time.Parse(time.RFC3339, spot.ExpiresAt)
+		if err != nil || !time.Now().After(expiresAt) {
+			return &covered, nil, nil
 		}
-		return 0
-	case string(models.Temporary):
-		return duration * spot.HourlyRate
-	default:
-		return 0
 	}
+
+	return s.tariffService.Quote(ctx, spot, record.EntryTime, *record.ExitTime)
 }
 
 // 处理车辆入场
-func (s *ParkingService) ProcessEntry(ctx context.Context, license string, userID *uint) (*models.ParkingRecord, error) {
+func (s *ParkingService) ProcessEntry(ctx context.Context, license string) (*models.ParkingRecord, error) {
 	// 检查是否有进行中的记录
 	existing, err := s.parkingRepo.GetOngoingRecord(ctx, license)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("查询进行中记录失败: %w", err)
 	}
-	if existing != nil {
+	if err == nil && existing != nil {
 		return nil, errors.New("该车辆已有进行中的停车记录")
 	}
 
+	// 优先使用该车牌的有效预约
+	reservation, err := s.reservationService.FindForEntry(ctx, license)
+	if err != nil {
+		return nil, fmt.Errorf("查询车位预约失败: %w", err)
+	}
+	userID, err := s.entryUser(ctx, license, reservation)
+	if err != nil {
+		return nil, err
+	}
+	if reservation != nil {
+		record, err := s.parkingRepo.OccupySpot(ctx, reservation.SpotID, license, userID)
+		if err == nil {
+			if err := s.reservationService.MarkFulfilled(ctx, reservation.ID, record.ID); err != nil {
+				logger.Log.Error("更新预约履约状态失败",
+					zap.Uint("reservationID", reservation.ID),
+					zap.Error(err))
+			}
+			return record, nil
+		}
+		// 预约车位暂不可用时退回自动分配
+		logger.Log.Warn("预约车位不可用，改为自动分配",
+			zap.Uint("reservationID", reservation.ID),
+			zap.Uint("spotID", reservation.SpotID),
+			zap.Error(err))
+	}
+
 	// 自动分配临时车位
 	spot, err := s.findAvailableSpot(ctx, models.Temporary)
 	if err != nil {
@@ -78,30 +139,146 @@ func (s *ParkingService) ProcessEntry(ctx context.Context, license string, userI
 	if err != nil {
 		return nil, fmt.Errorf("占用车位失败: %w", err)
 	}
+	if reservation != nil {
+		if err := s.reservationService.MarkFulfilled(ctx, reservation.ID, record.ID); err != nil {
+			logger.Log.Error("更新预约履约状态失败",
+				zap.Uint("reservationID", reservation.ID),
+				zap.Error(err))
+		}
+	}
 	return record, nil
 }
 
-// 处理车辆出场
+// 处理车辆出场，费用在释放车位的同一事务中结算
 func (s *ParkingService) ProcessExit(ctx context.Context, recordID uint) (*models.ParkingRecord, error) {
-	// 修正：接收 ReleaseSpot 的两个返回值
-	record, err := s.parkingRepo.ReleaseSpot(ctx, recordID)
+	record, err := s.parkingRepo.ReleaseSpot(ctx, recordID, func(record *models.ParkingRecord) error {
+		// 获取关联车位信息
+		spot, err := s.parkingRepo.GetSpotByID(ctx, record.SpotID)
+		if err != nil {
+			return fmt.Errorf("获取车位信息失败: %w", err)
+		}
+
+		// 计算费用，记录计费所用的资费版本和计费明细
+		quote, used, err := s.CalculateFee(ctx, record, spot)
+		if err != nil {
+			return fmt.Errorf("计算停车费用失败: %w", err)
+		}
+		if record.FeeQuote, err = json.Marshal(quote); err != nil {
+			return fmt.Errorf("序列化计费明细失败: %w", err)
+		}
+		record.TotalCost = quote.Total
+		if used != nil {
+			record.TariffID = &used.ID
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("释放车位失败: %w", err)
 	}
 
-	// 获取关联车位信息
-	spot, err := s.parkingRepo.GetSpotByID(ctx, record.SpotID)
+	// 创建停车费支付单，由出场缴费接口完成扣款；创建失败时缴费接口会重新发起
+	if _, err := s.paymentService.CreateIntent(ctx, recordPayer(record), models.PaymentForParking,
+		record.ID, record.TotalCost, parkingPaymentDescription(record)); err != nil {
+		logger.Log.Error("创建停车费支付单失败",
+			zap.Uint("recordID", record.ID),
+			zap.Error(err))
+	}
+	return record, nil
+}
+
+// entryUser 停车记录归属的用户：预约人，其次是登记该车牌的用户；都没有时按非注册用户处理
+func (s *ParkingService) entryUser(
+	ctx context.Context,
+	license string,
+	reservation *models.Reservation,
+) (*uint, error) {
+	if reservation != nil {
+		return &reservation.UserID, nil
+	}
+	vehicle, err := s.vehicleRepo.GetVehicleByLicense(ctx, license)
+	if errors.Is(err, models.ErrVehicleNotFound) {
+		return nil, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("获取车位信息失败: %w", err)
+		return nil, fmt.Errorf("查询登记车辆失败: %w", err)
 	}
+	return &vehicle.UserID, nil
+}
+
+// sendExitReceipt 发件箱处理器：向注册用户发送出场收据
+func (s *ParkingService) sendExitReceipt(ctx context.Context, event *models.OutboxEvent) error {
+	var data models.ParkingEventData
+	if err := event.DecodeData(&data); err != nil {
+		return fmt.Errorf("解析出场事件失败: %w", err)
+	}
+	if data.UserID == nil || data.ExitTime == nil {
+		return nil
+	}
+	s.notifications.Notify(ctx, *data.UserID, models.NotifyExitReceipt, notifier.ExitReceiptData{
+		RecordID:  data.RecordID,
+		SpotID:    data.SpotID,
+		License:   data.License,
+		EntryTime: data.EntryTime.Local().Format("2006-01-02 15:04"),
+		ExitTime:  data.ExitTime.Local().Format("2006-01-02 15:04"),
+		Duration:  data.ExitTime.Sub(data.EntryTime).Round(time.Minute).String(),
+		Amount:    data.TotalCost,
+	})
+	return nil
+}
 
-	// 计算并更新费用
-	record.TotalCost = s.CalculateFee(record, spot)
-	updatedRecord, err := s.parkingRepo.UpdateRecord(ctx, record)
+// PayExit 支付用户本人已出场停车记录的停车费，其他用户的记录按不存在处理
+func (s *ParkingService) PayExit(ctx context.Context, userID, recordID uint) (*models.Payment, error) {
+	record, err := s.parkingRepo.GetParkingByID(ctx, recordID)
 	if err != nil {
-		return nil, fmt.Errorf("更新记录失败: %w", err)
+		return nil, err
+	}
+	if record.UserID == nil || *record.UserID != userID {
+		return nil, models.ErrParkingNotFound
+	}
+	if !record.IsCompleted {
+		return nil, models.ErrParkingNotCompleted
 	}
-	return updatedRecord, nil
+
+	return s.paymentService.PayReference(ctx, recordPayer(record), models.PaymentForParking,
+		record.ID, record.TotalCost, parkingPaymentDescription(record))
+}
+
+// PayGateExit 出入口值守人员为非注册用户的停车记录收取停车费，注册用户的记录由本人缴费
+func (s *ParkingService) PayGateExit(ctx context.Context, operatorID, recordID uint) (*models.Payment, error) {
+	record, err := s.parkingRepo.GetParkingByID(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if record.UserID != nil {
+		return nil, models.ErrParkingHasUser
+	}
+	if !record.IsCompleted {
+		return nil, models.ErrParkingNotCompleted
+	}
+
+	payment, err := s.paymentService.PayReference(ctx, recordPayer(record), models.PaymentForParking,
+		record.ID, record.TotalCost, parkingPaymentDescription(record))
+	if err != nil {
+		return nil, err
+	}
+	logger.Log.Info("出入口已收取停车费",
+		zap.Uint("operatorID", operatorID),
+		zap.Uint("recordID", record.ID),
+		zap.Uint("paymentID", payment.ID),
+		zap.String("status", string(payment.Status)))
+	return payment, nil
+}
+
+// recordPayer 停车记录的付款用户，非注册用户为 0
+func recordPayer(record *models.ParkingRecord) uint {
+	if record.UserID != nil {
+		return *record.UserID
+	}
+	return 0
+}
+
+func parkingPaymentDescription(record *models.ParkingRecord) string {
+	return fmt.Sprintf("停车费 %s 车位 %d", record.License, record.SpotID)
 }
 
 // 业主车辆特殊入场处理
@@ -137,30 +314,81 @@ func (s *ParkingService) UpdateSpotStatus(
 		return nil, fmt.Errorf("获取车位失败: %w", err)
 	}
 
+	previous := spot.Status
 	// 将 models.ParkingStatus 类型的 status 转换为 string 类型
 	spot.Status = string(status)
 	spot.Notes = notes // 假设 models.ParkingSpot 有 Notes 字段
 
-	if err := s.parkingRepo.UpdateSpot(ctx, spot); err != nil {
+	// 状态变更与发件箱事件在同一事务中提交
+	err = s.parkingRepo.Transaction(ctx, func(repo repositories.ParkingRepository) error {
+		if err := repo.UpdateSpot(ctx, spot); err != nil {
+			return err
+		}
+		if previous == spot.Status {
+			return nil
+		}
+		event, err := models.NewOutboxEvent(models.WebhookSpotStatusChanged, models.AggregateSpot, spot.ID,
+			models.SpotStatusEventData{
+				SpotID: spot.ID,
+				From:   previous,
+				To:     spot.Status,
+				Notes:  notes,
+			})
+		if err != nil {
+			return err
+		}
+		return repo.AddOutboxEvent(ctx, event)
+	})
+	if err != nil {
 		return nil, fmt.Errorf("更新状态失败: %w", err)
 	}
-
 	return spot, nil
 }
 
-// 检查并恢复故障车位
+// CheckFaultySpots 按车位的自动恢复策略恢复故障车位。
+// 未配置策略或未开启自动恢复的车位不处理；仍有未关闭维修工单的车位保持故障
 func (s *ParkingService) CheckFaultySpots(ctx context.Context) error {
-	threshold := time.Now().Add(-24 * time.Hour)
-	// 修正：使用正确的字段 UpdatedAt 替代 UpdatedBefore
+	now := time.Now()
+	// 自动恢复时长至少 1 小时，更新时间晚于此的车位无需检查
+	earliest := now.Add(-time.Hour)
 	spots, err := s.parkingRepo.ListSpots(ctx, repositories.SpotFilter{
 		Status:    models.Faulty,
-		UpdatedAt: &threshold,
+		UpdatedAt: &earliest,
 	})
 	if err != nil {
 		return fmt.Errorf("查询故障车位失败: %w", err)
 	}
 
 	for _, spot := range spots {
+		policy, err := s.parkingRepo.GetRecoveryPolicy(ctx, spot.ID)
+		if err != nil {
+			logger.Log.Error("查询车位恢复策略失败",
+				zap.Uint("spotID", spot.ID),
+				zap.Error(err))
+			continue
+		}
+		if policy == nil || !policy.AutoRecover {
+			continue
+		}
+
+		faultySince, err := time.ParseInLocation(models.SpotTimeLayout, spot.UpdatedAt, time.Local)
+		if err != nil || now.Sub(faultySince) < time.Duration(policy.AfterHours)*time.Hour {
+			continue
+		}
+
+		pending, err := s.reportRepo.HasPendingMaintenance(ctx, spot.ID)
+		if err != nil {
+			logger.Log.Error("查询车位维修工单失败",
+				zap.Uint("spotID", spot.ID),
+				zap.Error(err))
+			continue
+		}
+		if pending {
+			logger.Log.Info("车位仍有未关闭的维修工单，保持故障状态",
+				zap.Uint("spotID", spot.ID))
+			continue
+		}
+
 		if err := s.parkingRepo.UpdateStatus(ctx, spot.ID, models.Idle); err != nil {
 			logger.Log.Error("恢复车位状态失败",
 				zap.Uint("spotID", spot.ID),
@@ -169,10 +397,59 @@ func (s *ParkingService) CheckFaultySpots(ctx context.Context) error {
 		}
 		logger.Log.Info("成功恢复车位状态",
 			zap.Uint("spotID", spot.ID))
+
+		s.auditService.RecordSystem(ctx, models.AuditSpotAutoRecovered, models.AuditTargetSpot, spot.ID,
+			fmt.Sprintf("车位 %d 已自动恢复", spot.ID),
+			fmt.Sprintf("车位 %d 自 %s 起处于故障状态，已超过 %d 小时且无未关闭的维修工单，系统已自动恢复为空闲",
+				spot.ID, spot.UpdatedAt, policy.AfterHours))
 	}
 	return nil
 }
 
+// GetRecoveryPolicy 查询车位的自动恢复策略，未配置时返回默认策略（不自动恢复）
+func (s *ParkingService) GetRecoveryPolicy(ctx context.Context, spotID uint) (*models.SpotRecoveryPolicy, error) {
+	if _, err := s.parkingRepo.GetSpotByID(ctx, spotID); err != nil {
+		return nil, models.ErrParkingSpotNotFound
+	}
+	policy, err := s.parkingRepo.GetRecoveryPolicy(ctx, spotID)
+	if err != nil {
+		return nil, fmt.Errorf("查询车位恢复策略失败: %w", err)
+	}
+	if policy == nil {
+		policy = &models.SpotRecoveryPolicy{SpotID: spotID, AfterHours: defaultRecoveryHours}
+	}
+	return policy, nil
+}
+
+// SetRecoveryPolicy 管理员配置车位的自动恢复策略
+func (s *ParkingService) SetRecoveryPolicy(
+	ctx context.Context,
+	adminID, spotID uint,
+	autoRecover bool,
+	afterHours int,
+) (*models.SpotRecoveryPolicy, error) {
+	if afterHours < 1 {
+		return nil, errors.New("自动恢复时长至少为 1 小时")
+	}
+	if _, err := s.parkingRepo.GetSpotByID(ctx, spotID); err != nil {
+		return nil, models.ErrParkingSpotNotFound
+	}
+
+	policy := &models.SpotRecoveryPolicy{
+		SpotID:      spotID,
+		AutoRecover: autoRecover,
+		AfterHours:  afterHours,
+		UpdatedBy:   adminID,
+	}
+	if err := s.parkingRepo.SaveRecoveryPolicy(ctx, policy); err != nil {
+		return nil, fmt.Errorf("保存车位恢复策略失败: %w", err)
+	}
+
+	s.auditService.Record(ctx, adminID, models.AuditRecoveryPolicyUpdated, models.AuditTargetSpot, spotID,
+		fmt.Sprintf("auto_recover=%t after_hours=%d", autoRecover, afterHours))
+	return policy, nil
+}
+
 // 获取车位列表
 func (s *ParkingService) ListSpots(ctx context.Context) ([]*models.ParkingSpot, error) {
 	return s.parkingRepo.ListSpots(ctx, repositories.SpotFilter{})
@@ -190,10 +467,18 @@ func (s *ParkingService) findAvailableSpot(
 	if err != nil {
 		return nil, fmt.Errorf("查询可用车位失败: %w", err)
 	}
-	if len(spots) == 0 {
-		return nil, errors.New("当前没有可用车位")
+
+	// 跳过即将被预约使用的车位
+	reserved, err := s.reservationService.ReservedSpotIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("查询预约车位失败: %w", err)
+	}
+	for _, spot := range spots {
+		if !reserved[spot.ID] {
+			return spot, nil
+		}
 	}
-	return spots[0], nil
+	return nil, errors.New("当前没有可用车位")
 }
 
 // 创建停车位
@@ -278,7 +563,6 @@ func (s *ParkingService) GetUserInfo(ctx context.Context, username string) (*mod
 	return &models.AdminUserInfoResponse{
 		ID:           user.ID,
 		Email:        user.Email,
-		Password:     user.Password,
 		ParkingSpots: spots,
 	}, nil
 }
@@ -0,0 +1,380 @@
+// internal/services/payment_service.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"modules/internal/models"
+	"modules/internal/repositories"
+	"modules/pkg/logger"
+	"modules/pkg/payments"
+	"time"
+)
+
+// PaymentHandler 支付成功后的业务回调，例如激活租赁
+type PaymentHandler func(ctx context.Context, payment *models.Payment) error
+
+// RefundHandler 退款完成后的业务回调，例如冲销业主收入
+type RefundHandler func(ctx context.Context, payment *models.Payment, refund *models.Refund) error
+
+type PaymentService struct {
+	paymentRepo repositories.PaymentRepository
+	gateway     payments.Gateway
+	currency    string
+	handlers    map[models.PaymentPurpose][]PaymentHandler
+	refunded    map[models.PaymentPurpose][]RefundHandler
+}
+
+func NewPaymentService(
+	pr repositories.PaymentRepository,
+	gateway payments.Gateway,
+	currency string,
+) *PaymentService {
+	if currency == "" {
+		currency = "CNY"
+	}
+	return &PaymentService{
+		paymentRepo: pr,
+		gateway:     gateway,
+		currency:    currency,
+		handlers:    make(map[models.PaymentPurpose][]PaymentHandler),
+		refunded:    make(map[models.PaymentPurpose][]RefundHandler),
+	}
+}
+
+// OnSucceeded 注册某类支付成功后的回调，同一类支付可注册多个回调，按注册顺序执行
+func (s *PaymentService) OnSucceeded(purpose models.PaymentPurpose, handler PaymentHandler) {
+	s.handlers[purpose] = append(s.handlers[purpose], handler)
+}
+
+// OnRefunded 注册某类支付退款完成后的回调，按注册顺序执行
+func (s *PaymentService) OnRefunded(purpose models.PaymentPurpose, handler RefundHandler) {
+	s.refunded[purpose] = append(s.refunded[purpose], handler)
+}
+
+// CreateIntent 为业务单据创建支付单；金额为 0 时无需经过网关，直接视为支付成功
+func (s *PaymentService) CreateIntent(
+	ctx context.Context,
+	userID uint,
+	purpose models.PaymentPurpose,
+	referenceID uint,
+	amount float64,
+	description string,
+) (*models.Payment, error) {
+	if amount < 0 {
+		return nil, errors.New("支付金额不能为负数")
+	}
+
+	payment := &models.Payment{
+		UserID:      userID,
+		Purpose:     purpose,
+		ReferenceID: referenceID,
+		Amount:      amount,
+		Currency:    s.currency,
+		Description: description,
+		Status:      models.PaymentPending,
+	}
+
+	if amount > 0 {
+		intent, err := s.gateway.CreateIntent(ctx, amount, s.currency, description)
+		if err != nil {
+			return nil, fmt.Errorf("创建支付意图失败: %w", err)
+		}
+		payment.Provider = s.gateway.Name()
+		payment.ProviderRef = intent.ID
+	}
+
+	if err := s.paymentRepo.CreatePayment(ctx, payment); err != nil {
+		return nil, fmt.Errorf("保存支付单失败: %w", err)
+	}
+
+	if amount == 0 {
+		return s.markSucceeded(ctx, payment)
+	}
+	return payment, nil
+}
+
+// Confirm 确认扣款；扣款被拒时返回状态为 failed 的支付单，支付单已被其他请求占用时返回 models.ErrPaymentProcessing
+func (s *PaymentService) Confirm(ctx context.Context, paymentID uint) (*models.Payment, error) {
+	payment, err := s.paymentRepo.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != models.PaymentPending {
+		return nil, models.ErrPaymentNotPending
+	}
+
+	// 先占用支付单再向网关扣款，并发的支付请求只有一个能继续
+	claimed, err := s.paymentRepo.TransitionPayment(ctx, payment.ID, models.PaymentPending, models.PaymentProcessing)
+	if err != nil {
+		return nil, fmt.Errorf("更新支付单失败: %w", err)
+	}
+	if !claimed {
+		return nil, models.ErrPaymentProcessing
+	}
+	payment.Status = models.PaymentProcessing
+
+	intent, err := s.gateway.Confirm(ctx, payment.ProviderRef)
+	if err != nil {
+		// 网关调用失败时恢复为待支付，允许用户重试
+		if _, rerr := s.paymentRepo.TransitionPayment(ctx, payment.ID, models.PaymentProcessing, models.PaymentPending); rerr != nil {
+			logger.Log.Error("恢复支付单状态失败", zap.Uint("paymentID", payment.ID), zap.Error(rerr))
+		}
+		return nil, fmt.Errorf("确认支付失败: %w", err)
+	}
+
+	if intent.Status != payments.IntentSucceeded {
+		payment.Status = models.PaymentFailed
+		payment.FailureReason = intent.FailureReason
+		if err := s.paymentRepo.UpdatePayment(ctx, payment); err != nil {
+			return nil, fmt.Errorf("更新支付单失败: %w", err)
+		}
+		logger.Log.Warn("支付失败",
+			zap.Uint("paymentID", payment.ID),
+			zap.String("reason", payment.FailureReason))
+		return payment, nil
+	}
+
+	return s.markSucceeded(ctx, payment)
+}
+
+// VoidPending 作废待支付的支付单，之后不能再扣款；支付单已被支付请求占用或不再待支付时返回 false
+func (s *PaymentService) VoidPending(ctx context.Context, paymentID uint) (bool, error) {
+	voided, err := s.paymentRepo.TransitionPayment(ctx, paymentID, models.PaymentPending, models.PaymentFailed)
+	if err != nil {
+		return false, fmt.Errorf("作废支付单失败: %w", err)
+	}
+	return voided, nil
+}
+
+// PayReference 支付业务单据：使用最近一次待支付的支付单，若上次支付失败则重新发起；
+// 支付单正在处理中时返回 models.ErrPaymentProcessing
+func (s *PaymentService) PayReference(
+	ctx context.Context,
+	userID uint,
+	purpose models.PaymentPurpose,
+	referenceID uint,
+	amount float64,
+	description string,
+) (*models.Payment, error) {
+	payment, err := s.paymentRepo.GetLatestPayment(ctx, purpose, referenceID)
+	if err != nil && !errors.Is(err, models.ErrPaymentNotFound) {
+		return nil, err
+	}
+
+	if payment == nil || payment.Status == models.PaymentFailed {
+		if payment, err = s.CreateIntent(ctx, userID, purpose, referenceID, amount, description); err != nil {
+			return nil, err
+		}
+	}
+	if payment.Status == models.PaymentProcessing {
+		return nil, models.ErrPaymentProcessing
+	}
+	if payment.Status != models.PaymentPending {
+		return payment, nil
+	}
+	return s.Confirm(ctx, payment.ID)
+}
+
+func (s *PaymentService) GetPayment(ctx context.Context, paymentID uint) (*models.Payment, error) {
+	return s.paymentRepo.GetPaymentByID(ctx, paymentID)
+}
+
+func (s *PaymentService) GetLatestPayment(
+	ctx context.Context,
+	purpose models.PaymentPurpose,
+	referenceID uint,
+) (*models.Payment, error) {
+	return s.paymentRepo.GetLatestPayment(ctx, purpose, referenceID)
+}
+
+func (s *PaymentService) ListUserPayments(ctx context.Context, userID uint) ([]*models.Payment, error) {
+	return s.paymentRepo.GetUserPayments(ctx, userID)
+}
+
+// RequestRefund 为已支付的支付单创建退款申请，等待管理员审核
+func (s *PaymentService) RequestRefund(
+	ctx context.Context,
+	payment *models.Payment,
+	amount float64,
+	penalty float64,
+	reason string,
+) (*models.Refund, error) {
+	refund, err := s.NewRefund(payment, amount, penalty, reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.paymentRepo.CreateRefund(ctx, refund); err != nil {
+		return nil, fmt.Errorf("创建退款申请失败: %w", err)
+	}
+
+	logger.Log.Info("退款申请已创建",
+		zap.Uint("refundID", refund.ID),
+		zap.Uint("paymentID", payment.ID),
+		zap.Float64("amount", amount))
+	return refund, nil
+}
+
+// NewRefund 校验退款金额并生成待审核的退款申请，由调用方在自己的事务中保存
+func (s *PaymentService) NewRefund(
+	payment *models.Payment,
+	amount float64,
+	penalty float64,
+	reason string,
+) (*models.Refund, error) {
+	if payment.Status != models.PaymentSucceeded && payment.Status != models.PaymentRefunded {
+		return nil, errors.New("支付单未支付成功，无法退款")
+	}
+	if amount <= 0 || amount > payment.Amount-payment.RefundedAmount+0.005 {
+		return nil, errors.New("退款金额超出可退范围")
+	}
+
+	return &models.Refund{
+		PaymentID:   payment.ID,
+		UserID:      payment.UserID,
+		Purpose:     payment.Purpose,
+		ReferenceID: payment.ReferenceID,
+		Amount:      amount,
+		Penalty:     penalty,
+		Reason:      reason,
+		Status:      models.RefundPending,
+	}, nil
+}
+
+func (s *PaymentService) ListRefunds(ctx context.Context, status models.RefundStatus) ([]*models.Refund, error) {
+	return s.paymentRepo.ListRefunds(ctx, status)
+}
+
+// ApproveRefund 审核通过退款申请并向支付网关发起退款
+func (s *PaymentService) ApproveRefund(ctx context.Context, refundID, adminID uint, note string) (*models.Refund, error) {
+	refund, err := s.paymentRepo.GetRefundByID(ctx, refundID)
+	if err != nil {
+		return nil, err
+	}
+	if refund.Status != models.RefundPending {
+		return nil, models.ErrRefundNotPending
+	}
+
+	payment, err := s.paymentRepo.GetPaymentByID(ctx, refund.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+	if refund.Amount > payment.Amount-payment.RefundedAmount+0.005 {
+		return nil, errors.New("退款金额超出可退范围")
+	}
+
+	// 先将申请改为已通过再调用网关，并发审核时只有一个请求会发起退款
+	claimed, err := s.paymentRepo.TransitionRefund(ctx, refund.ID, models.RefundPending, models.RefundApproved)
+	if err != nil {
+		return nil, fmt.Errorf("更新退款申请失败: %w", err)
+	}
+	if !claimed {
+		return nil, models.ErrRefundNotPending
+	}
+
+	if payment.ProviderRef != "" {
+		if _, err := s.gateway.Refund(ctx, payment.ProviderRef, refund.Amount); err != nil {
+			// 网关退款失败时恢复为待审核，允许重新审核
+			if _, rerr := s.paymentRepo.TransitionRefund(ctx, refund.ID, models.RefundApproved, models.RefundPending); rerr != nil {
+				logger.Log.Error("恢复退款申请状态失败", zap.Uint("refundID", refund.ID), zap.Error(rerr))
+			}
+			return nil, fmt.Errorf("网关退款失败: %w", err)
+		}
+	}
+
+	// 支付单的已退款金额与退款申请的审核结果在同一事务中提交
+	s.review(refund, models.RefundApproved, adminID, note)
+	err = s.paymentRepo.Transaction(ctx, func(repo repositories.PaymentRepository) error {
+		if err := repo.AddRefundedAmount(ctx, payment.ID, refund.Amount); err != nil {
+			return fmt.Errorf("更新支付单失败: %w", err)
+		}
+		if err := repo.UpdateRefund(ctx, refund); err != nil {
+			return fmt.Errorf("更新退款申请失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		// 网关已退款，需人工核对
+		logger.Log.Error("网关已退款但保存退款结果失败",
+			zap.Uint("refundID", refund.ID),
+			zap.Uint("paymentID", payment.ID),
+			zap.Error(err))
+		return nil, err
+	}
+	payment.RefundedAmount += refund.Amount
+	payment.Status = models.PaymentRefunded
+
+	logger.Log.Info("退款已完成",
+		zap.Uint("refundID", refund.ID),
+		zap.Uint("paymentID", payment.ID),
+		zap.Uint("adminID", adminID),
+		zap.Float64("amount", refund.Amount))
+
+	// 网关已退款，回调失败不影响审核结果，仅记录日志
+	for _, handler := range s.refunded[payment.Purpose] {
+		if err := handler(ctx, payment, refund); err != nil {
+			logger.Log.Error("处理退款结果失败",
+				zap.Uint("refundID", refund.ID),
+				zap.Error(err))
+		}
+	}
+	return refund, nil
+}
+
+// RejectRefund 驳回退款申请
+func (s *PaymentService) RejectRefund(ctx context.Context, refundID, adminID uint, note string) (*models.Refund, error) {
+	refund, err := s.paymentRepo.GetRefundByID(ctx, refundID)
+	if err != nil {
+		return nil, err
+	}
+	if refund.Status != models.RefundPending {
+		return nil, models.ErrRefundNotPending
+	}
+
+	// 与审核通过互斥，已被其他管理员处理的申请不能再驳回
+	claimed, err := s.paymentRepo.TransitionRefund(ctx, refund.ID, models.RefundPending, models.RefundRejected)
+	if err != nil {
+		return nil, fmt.Errorf("更新退款申请失败: %w", err)
+	}
+	if !claimed {
+		return nil, models.ErrRefundNotPending
+	}
+
+	s.review(refund, models.RefundRejected, adminID, note)
+	if err := s.paymentRepo.UpdateRefund(ctx, refund); err != nil {
+		return nil, fmt.Errorf("更新退款申请失败: %w", err)
+	}
+	return refund, nil
+}
+
+func (s *PaymentService) review(refund *models.Refund, status models.RefundStatus, adminID uint, note string) {
+	now := time.Now()
+	refund.Status = status
+	refund.ReviewedBy = &adminID
+	refund.ReviewNote = note
+	refund.ReviewedAt = &now
+}
+
+func (s *PaymentService) markSucceeded(ctx context.Context, payment *models.Payment) (*models.Payment, error) {
+	now := time.Now()
+	payment.Status = models.PaymentSucceeded
+	payment.PaidAt = &now
+	if err := s.paymentRepo.UpdatePayment(ctx, payment); err != nil {
+		return nil, fmt.Errorf("更新支付单失败: %w", err)
+	}
+
+	logger.Log.Info("支付成功",
+		zap.Uint("paymentID", payment.ID),
+		zap.String("purpose", string(payment.Purpose)),
+		zap.Uint("referenceID", payment.ReferenceID),
+		zap.Float64("amount", payment.Amount))
+
+	for _, handler := range s.handlers[payment.Purpose] {
+		if err := handler(ctx, payment); err != nil {
+			return nil, fmt.Errorf("处理支付结果失败: %w", err)
+		}
+	}
+	return payment, nil
+}
@@ -0,0 +1,341 @@
+// internal/services/permission_service.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"modules/internal/models"
+	"modules/internal/repositories"
+	"modules/pkg/logger"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 角色权限缓存时长，超过后重新从数据库加载，以便获取其他进程修改的权限
+const rolePermissionCacheTTL = time.Minute
+
+// 角色名：小写字母开头，可包含小写字母、数字和下划线
+var roleNameRegex = regexp.MustCompile(`^[a-z][a-z0-9_]{1,31}$`)
+
+// RoleInfo 角色及其权限
+type RoleInfo struct {
+	*models.RoleDefinition
+	Permissions []models.Permission
+}
+
+// PermissionService 角色权限：判断用户角色是否拥有接口所需的权限，并管理自定义角色。
+// 管理员角色始终拥有全部权限
+type PermissionService struct {
+	roleRepo repositories.RoleRepository
+	userRepo repositories.UserRepository
+	audit    *AuditService
+
+	mu       sync.RWMutex
+	grants   map[models.Role]map[models.Permission]bool
+	loadedAt time.Time
+}
+
+func NewPermissionService(
+	rr repositories.RoleRepository,
+	ur repositories.UserRepository,
+	audit *AuditService,
+) *PermissionService {
+	return &PermissionService{
+		roleRepo: rr,
+		userRepo: ur,
+		audit:    audit,
+	}
+}
+
+// EnsureBuiltInRoles 写入缺失的内置角色及其初始权限，已存在的角色保持管理员调整后的权限
+func (s *PermissionService) EnsureBuiltInRoles(ctx context.Context) error {
+	roles := []models.Role{models.Admin, models.Owner, models.Renter, models.Operator}
+	for _, name := range roles {
+		role := &models.RoleDefinition{
+			Name:        name,
+			Description: models.BuiltInRoleDescriptions[name],
+			BuiltIn:     true,
+		}
+		if err := s.roleRepo.EnsureRole(ctx, role, models.DefaultRolePermissions[name]); err != nil {
+			return fmt.Errorf("初始化角色 %s 失败: %w", name, err)
+		}
+	}
+	s.invalidate()
+	return nil
+}
+
+// HasPermissions 判断角色是否拥有所需权限：requireAll 为 true 时需要全部权限，否则拥有任意一个即可
+func (s *PermissionService) HasPermissions(
+	ctx context.Context,
+	roles []string,
+	permissions []models.Permission,
+	requireAll bool,
+) (bool, error) {
+	granted, err := s.permissionsOf(ctx, roles)
+	if err != nil {
+		return false, err
+	}
+	if granted == nil {
+		// 管理员
+		return true, nil
+	}
+	for _, p := range permissions {
+		if granted[p] && !requireAll {
+			return true, nil
+		}
+		if !granted[p] && requireAll {
+			return false, nil
+		}
+	}
+	return requireAll, nil
+}
+
+// RoleExists 角色是否已定义
+func (s *PermissionService) RoleExists(ctx context.Context, role models.Role) (bool, error) {
+	if err := s.reload(ctx, false); err != nil {
+		return false, err
+	}
+	s.mu.RLock()
+	_, ok := s.grants[role]
+	s.mu.RUnlock()
+	return ok || role == models.Admin, nil
+}
+
+// ListRoles 查询全部角色及其权限
+func (s *PermissionService) ListRoles(ctx context.Context) ([]*RoleInfo, error) {
+	roles, err := s.roleRepo.ListRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("查询角色失败: %w", err)
+	}
+	if err := s.reload(ctx, true); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	infos := make([]*RoleInfo, 0, len(roles))
+	for _, role := range roles {
+		infos = append(infos, &RoleInfo{RoleDefinition: role, Permissions: s.sortedPermissions(role.Name)})
+	}
+	return infos, nil
+}
+
+// CreateRole 创建自定义角色
+func (s *PermissionService) CreateRole(
+	ctx context.Context,
+	adminID uint,
+	name models.Role,
+	description string,
+	permissions []models.Permission,
+) (*RoleInfo, error) {
+	if !roleNameRegex.MatchString(string(name)) {
+		return nil, models.ErrInvalidRole
+	}
+	permissions, err := normalizePermissions(permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &models.RoleDefinition{Name: name, Description: strings.TrimSpace(description)}
+	if err := s.roleRepo.CreateRole(ctx, role, permissions); err != nil {
+		if errors.Is(err, models.ErrRoleExists) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("创建角色失败: %w", err)
+	}
+	s.invalidate()
+
+	s.audit.Record(ctx, adminID, models.AuditRoleCreated, models.AuditTargetRole, role.ID,
+		fmt.Sprintf("%s: %s", name, joinPermissions(permissions)))
+	logger.Log.Info("已创建角色", zap.Uint("adminID", adminID), zap.String("role", string(name)))
+	return &RoleInfo{RoleDefinition: role, Permissions: permissions}, nil
+}
+
+// UpdateRole 修改角色说明并替换其全部权限，管理员角色的权限不能修改；
+// 操作人不能修改自己拥有的角色，避免给自己加权限
+func (s *PermissionService) UpdateRole(
+	ctx context.Context,
+	adminID uint,
+	name models.Role,
+	description string,
+	permissions []models.Permission,
+) (*RoleInfo, error) {
+	if name == models.Admin {
+		return nil, models.ErrBuiltInRole
+	}
+	actor, err := s.userRepo.GetUserByID(ctx, adminID)
+	if err != nil {
+		return nil, fmt.Errorf("查询操作人失败: %w", err)
+	}
+	if actor != nil {
+		held, err := userRoles(actor)
+		if err != nil {
+			return nil, err
+		}
+		if hasRole(held, name) {
+			return nil, models.ErrOwnRole
+		}
+	}
+	role, err := s.roleRepo.GetRole(ctx, name)
+	if err != nil {
+		if errors.Is(err, models.ErrRoleNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("查询角色失败: %w", err)
+	}
+	permissions, err = normalizePermissions(permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := s.permissionsOf(ctx, []string{string(name)})
+	if err != nil {
+		return nil, err
+	}
+	role.Description = strings.TrimSpace(description)
+	if err := s.roleRepo.UpdateRole(ctx, role, permissions); err != nil {
+		return nil, fmt.Errorf("更新角色失败: %w", err)
+	}
+	s.invalidate()
+
+	s.audit.Record(ctx, adminID, models.AuditRoleUpdated, models.AuditTargetRole, role.ID,
+		fmt.Sprintf("%s: %s -> %s", name, joinPermissions(permissionList(previous)), joinPermissions(permissions)))
+	logger.Log.Info("已更新角色权限", zap.Uint("adminID", adminID), zap.String("role", string(name)))
+	return &RoleInfo{RoleDefinition: role, Permissions: permissions}, nil
+}
+
+// DeleteRole 删除自定义角色，仍有用户使用时不能删除
+func (s *PermissionService) DeleteRole(ctx context.Context, adminID uint, name models.Role) error {
+	role, err := s.roleRepo.GetRole(ctx, name)
+	if err != nil {
+		if errors.Is(err, models.ErrRoleNotFound) {
+			return err
+		}
+		return fmt.Errorf("查询角色失败: %w", err)
+	}
+	if role.BuiltIn {
+		return models.ErrBuiltInRole
+	}
+	_, users, err := s.userRepo.ListUsers(ctx, repositories.UserFilter{Role: name, Limit: 1})
+	if err != nil {
+		return fmt.Errorf("查询角色用户失败: %w", err)
+	}
+	if users > 0 {
+		return models.ErrRoleInUse
+	}
+
+	if err := s.roleRepo.DeleteRole(ctx, role); err != nil {
+		return fmt.Errorf("删除角色失败: %w", err)
+	}
+	s.invalidate()
+
+	s.audit.Record(ctx, adminID, models.AuditRoleDeleted, models.AuditTargetRole, role.ID, string(name))
+	logger.Log.Info("已删除角色", zap.Uint("adminID", adminID), zap.String("role", string(name)))
+	return nil
+}
+
+// permissionsOf 角色拥有的权限合集；包含管理员角色时返回 nil，表示拥有全部权限
+func (s *PermissionService) permissionsOf(ctx context.Context, roles []string) (map[models.Permission]bool, error) {
+	if err := s.reload(ctx, false); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	granted := map[models.Permission]bool{}
+	for _, role := range roles {
+		if models.Role(role) == models.Admin {
+			return nil, nil
+		}
+		for p := range s.grants[models.Role(role)] {
+			granted[p] = true
+		}
+	}
+	return granted, nil
+}
+
+// reload 从数据库加载角色权限，force 为 false 时缓存未过期则跳过
+func (s *PermissionService) reload(ctx context.Context, force bool) error {
+	s.mu.RLock()
+	fresh := s.grants != nil && time.Since(s.loadedAt) < rolePermissionCacheTTL
+	s.mu.RUnlock()
+	if fresh && !force {
+		return nil
+	}
+
+	roles, err := s.roleRepo.ListRoles(ctx)
+	if err != nil {
+		return fmt.Errorf("查询角色失败: %w", err)
+	}
+	rows, err := s.roleRepo.ListRolePermissions(ctx)
+	if err != nil {
+		return fmt.Errorf("查询角色权限失败: %w", err)
+	}
+	grants := make(map[models.Role]map[models.Permission]bool, len(roles))
+	for _, role := range roles {
+		grants[role.Name] = map[models.Permission]bool{}
+	}
+	for _, row := range rows {
+		if set, ok := grants[row.Role]; ok {
+			set[row.Permission] = true
+		}
+	}
+
+	s.mu.Lock()
+	s.grants = grants
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *PermissionService) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+// sortedPermissions 角色的权限列表，调用方需持有读锁
+func (s *PermissionService) sortedPermissions(role models.Role) []models.Permission {
+	if role == models.Admin {
+		all := make([]models.Permission, 0, len(models.Permissions))
+		for _, info := range models.Permissions {
+			all = append(all, info.Name)
+		}
+		return all
+	}
+	return permissionList(s.grants[role])
+}
+
+// normalizePermissions 校验权限并去重
+func normalizePermissions(permissions []models.Permission) ([]models.Permission, error) {
+	set := make(map[models.Permission]bool, len(permissions))
+	for _, p := range permissions {
+		if !p.Valid() {
+			return nil, fmt.Errorf("%w: %s", models.ErrInvalidPermission, p)
+		}
+		set[p] = true
+	}
+	return permissionList(set), nil
+}
+
+func permissionList(set map[models.Permission]bool) []models.Permission {
+	list := make([]models.Permission, 0, len(set))
+	for p := range set {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	return list
+}
+
+func joinPermissions(permissions []models.Permission) string {
+	names := make([]string, len(permissions))
+	for i, p := range permissions {
+		names[i] = string(p)
+	}
+	return "[" + strings.Join(names, ",") + "]"
+}
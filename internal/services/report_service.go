@@ -4,8 +4,11 @@ package services
 import (
 	"context"
 	"fmt"
+	"math"
 	"modules/internal/models"
 	"modules/internal/repositories"
+	"sort"
+	"strconv"
 	"time"
 )
 
@@ -24,23 +27,53 @@ func NewReportService(
 	}
 }
 
-func (s *ReportService) GenerateDailyReport(ctx context.Context, days int) (*models.DailyReport, error) {
-	end := time.Now()
-	start := end.AddDate(0, 0, -days)
+// SnapshotDailyReports 汇总 [from, to] 每一天的停车数据并保存为日报快照，没有记录的日期也写入零值
+func (s *ReportService) SnapshotDailyReports(ctx context.Context, from, to time.Time) (int, error) {
+	from = truncateToDay(from)
+	to = truncateToDay(to)
+	if to.Before(from) {
+		return 0, fmt.Errorf("结束日期不能早于开始日期")
+	}
 
-	reports, err := s.reportRepo.GetDailyReports(ctx, start, end)
+	aggregated, err := s.reportRepo.GetDailyReports(ctx, from, to.AddDate(0, 0, 1))
 	if err != nil {
-		return nil, fmt.Errorf("获取日报表数据失败: %w", err)
+		return 0, fmt.Errorf("汇总日报表数据失败: %w", err)
+	}
+	byDate := make(map[string]*models.DailyReport, len(aggregated))
+	for _, r := range aggregated {
+		byDate[r.Date.Format("2006-01-02")] = r
 	}
 
-	var total models.DailyReport
-	for _, r := range reports {
-		total.TotalIncome += r.TotalIncome
-		total.TemporaryCnt += r.TemporaryCnt
-		total.ShortTermCnt += r.ShortTermCnt
-		total.PermanentCnt += r.PermanentCnt
+	var reports []*models.DailyReport
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		report, ok := byDate[day.Format("2006-01-02")]
+		if !ok {
+			report = &models.DailyReport{}
+		}
+		report.Date = day
+		reports = append(reports, report)
+	}
+
+	if err := s.reportRepo.UpsertDailyReports(ctx, reports); err != nil {
+		return 0, fmt.Errorf("保存日报表失败: %w", err)
+	}
+	return len(reports), nil
+}
+
+// ListDailyReports 查询 [from, to] 内已保存的日报快照
+func (s *ReportService) ListDailyReports(ctx context.Context, from, to time.Time) ([]*models.DailyReport, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("结束日期不能早于开始日期")
 	}
-	return &total, nil
+	reports, err := s.reportRepo.ListDailyReports(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("获取日报表数据失败: %w", err)
+	}
+	return reports, nil
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
 }
 
 func (s *ReportService) GetSpotStats(ctx context.Context) (map[string]interface{}, error) {
@@ -71,3 +104,220 @@ func (s *ReportService) GetSpotStats(ctx context.Context) (map[string]interface{
 		"utilization_rates": utilization,
 	}, nil
 }
+
+// 收入报表的统计粒度
+const (
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+// 收入报表最长统计范围（天）
+const maxRevenueReportDays = 731
+
+// 收入报表的分组维度
+const (
+	GroupBySpot  = "spot"
+	GroupByOwner = "owner"
+	GroupByType  = "type"
+)
+
+// RevenueGroup 某个统计周期内一个分组的收入及环比
+type RevenueGroup struct {
+	Key       string
+	Parking   float64
+	Lease     float64
+	Total     float64
+	Count     int
+	PrevTotal float64
+	Delta     float64
+	DeltaRate *float64 // 上期为 0 时无法计算
+}
+
+// RevenuePeriod 一个统计周期的收入及环比
+type RevenuePeriod struct {
+	Start     time.Time
+	End       time.Time
+	Parking   float64
+	Lease     float64
+	Total     float64
+	Count     int
+	PrevTotal float64
+	Delta     float64
+	DeltaRate *float64
+	Groups    []*RevenueGroup
+}
+
+type RevenueReport struct {
+	Granularity string
+	GroupBy     string
+	Periods     []*RevenuePeriod
+}
+
+// RevenueReport 按粒度统计 [from, to] 所在周期的停车费和租金收入，可按车位、业主或车位类型分组，
+// 每个周期与上一周期比较；第一个周期同样与区间之前的一个周期比较
+func (s *ReportService) RevenueReport(
+	ctx context.Context,
+	granularity, groupBy string,
+	from, to time.Time,
+) (*RevenueReport, error) {
+	switch granularity {
+	case GranularityDay, GranularityWeek, GranularityMonth:
+	default:
+		return nil, fmt.Errorf("%w: 不支持的统计粒度 %s", models.ErrInvalidReportQuery, granularity)
+	}
+	switch groupBy {
+	case "", GroupBySpot, GroupByOwner, GroupByType:
+	default:
+		return nil, fmt.Errorf("%w: 不支持的分组维度 %s", models.ErrInvalidReportQuery, groupBy)
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: 结束日期不能早于开始日期", models.ErrInvalidReportQuery)
+	}
+	if to.After(from.AddDate(0, 0, maxRevenueReportDays)) {
+		return nil, fmt.Errorf("%w: 统计范围不能超过 %d 天", models.ErrInvalidReportQuery, maxRevenueReportDays)
+	}
+
+	first := periodStart(from, granularity)
+	last := periodStart(to, granularity)
+	prev := shiftPeriod(first, granularity, -1)
+	end := shiftPeriod(last, granularity, 1)
+
+	rows, err := s.reportRepo.GetDailyRevenue(ctx, prev, end)
+	if err != nil {
+		return nil, fmt.Errorf("获取收入数据失败: %w", err)
+	}
+
+	// 依次建立包含上一周期在内的所有周期
+	var periods []*RevenuePeriod
+	index := make(map[time.Time]*RevenuePeriod)
+	groups := make(map[*RevenuePeriod]map[string]*RevenueGroup)
+	for start := prev; start.Before(end); start = shiftPeriod(start, granularity, 1) {
+		p := &RevenuePeriod{Start: start, End: shiftPeriod(start, granularity, 1)}
+		periods = append(periods, p)
+		index[start] = p
+		groups[p] = make(map[string]*RevenueGroup)
+	}
+
+	for _, row := range rows {
+		p, ok := index[periodStart(row.Date.In(from.Location()), granularity)]
+		if !ok {
+			continue
+		}
+		addRevenue(&p.Parking, &p.Lease, &p.Count, row)
+		if groupBy == "" {
+			continue
+		}
+		key := revenueGroupKey(row, groupBy)
+		g, ok := groups[p][key]
+		if !ok {
+			g = &RevenueGroup{Key: key}
+			groups[p][key] = g
+		}
+		addRevenue(&g.Parking, &g.Lease, &g.Count, row)
+	}
+
+	for i, p := range periods {
+		p.Parking = roundAmount(p.Parking)
+		p.Lease = roundAmount(p.Lease)
+		p.Total = roundAmount(p.Parking + p.Lease)
+		for _, g := range groups[p] {
+			g.Parking = roundAmount(g.Parking)
+			g.Lease = roundAmount(g.Lease)
+			g.Total = roundAmount(g.Parking + g.Lease)
+		}
+		if i == 0 {
+			continue
+		}
+
+		previous := periods[i-1]
+		p.PrevTotal = previous.Total
+		p.Delta, p.DeltaRate = revenueDelta(p.Total, previous.Total)
+
+		if groupBy == "" {
+			continue
+		}
+		// 上一周期有收入、本周期没有的分组也需要列出
+		for key := range groups[previous] {
+			if _, ok := groups[p][key]; !ok {
+				groups[p][key] = &RevenueGroup{Key: key}
+			}
+		}
+		for key, g := range groups[p] {
+			if pg, ok := groups[previous][key]; ok {
+				g.PrevTotal = pg.Total
+			}
+			g.Delta, g.DeltaRate = revenueDelta(g.Total, g.PrevTotal)
+			p.Groups = append(p.Groups, g)
+		}
+		sort.Slice(p.Groups, func(a, b int) bool {
+			if p.Groups[a].Total != p.Groups[b].Total {
+				return p.Groups[a].Total > p.Groups[b].Total
+			}
+			return p.Groups[a].Key < p.Groups[b].Key
+		})
+	}
+
+	return &RevenueReport{
+		Granularity: granularity,
+		GroupBy:     groupBy,
+		Periods:     periods[1:],
+	}, nil
+}
+
+func addRevenue(parking, lease *float64, count *int, row *models.RevenueRow) {
+	if row.Source == "lease" {
+		*lease += row.Amount
+	} else {
+		*parking += row.Amount
+	}
+	*count += row.Count
+}
+
+func revenueGroupKey(row *models.RevenueRow, groupBy string) string {
+	switch groupBy {
+	case GroupBySpot:
+		return strconv.FormatUint(uint64(row.SpotID), 10)
+	case GroupByOwner:
+		return strconv.FormatUint(uint64(row.OwnerID), 10)
+	default:
+		return row.SpotType
+	}
+}
+
+func revenueDelta(current, previous float64) (float64, *float64) {
+	delta := roundAmount(current - previous)
+	if previous == 0 {
+		return delta, nil
+	}
+	rate := math.Round(delta/previous*10000) / 10000
+	return delta, &rate
+}
+
+// periodStart 返回时间所在统计周期的起点，周以周一为起点
+func periodStart(t time.Time, granularity string) time.Time {
+	day := truncateToDay(t)
+	switch granularity {
+	case GranularityWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case GranularityMonth:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+	default:
+		return day
+	}
+}
+
+func shiftPeriod(start time.Time, granularity string, n int) time.Time {
+	switch granularity {
+	case GranularityWeek:
+		return start.AddDate(0, 0, 7*n)
+	case GranularityMonth:
+		return start.AddDate(0, n, 0)
+	default:
+		return start.AddDate(0, 0, n)
+	}
+}
+
+func roundAmount(v float64) float64 {
+	return math.Round(v*100) / 100
+}
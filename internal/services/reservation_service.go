@@ -0,0 +1,175 @@
+// internal/services/reservation_service.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"modules/config"
+	"modules/internal/models"
+	"modules/internal/repositories"
+	"modules/pkg/logger"
+	"strings"
+	"time"
+)
+
+// 未配置宽限期时的默认值
+const defaultReservationGrace = 15 * time.Minute
+
+type ReservationService struct {
+	reservationRepo repositories.ReservationRepository
+	parkingRepo     repositories.ParkingRepository
+	grace           time.Duration
+}
+
+func NewReservationService(
+	rr repositories.ReservationRepository,
+	pr repositories.ParkingRepository,
+	cfg *config.Config,
+) *ReservationService {
+	grace := defaultReservationGrace
+	if cfg != nil && cfg.Reservation.GracePeriod != "" {
+		d, err := time.ParseDuration(cfg.Reservation.GracePeriod)
+		if err != nil {
+			logger.Log.Warn("解析预约宽限期失败，使用默认值",
+				zap.String("gracePeriod", cfg.Reservation.GracePeriod),
+				zap.Error(err))
+		} else {
+			grace = d
+		}
+	}
+	return &ReservationService{
+		reservationRepo: rr,
+		parkingRepo:     pr,
+		grace:           grace,
+	}
+}
+
+// CreateReservation 预约车位：指定 spotID 时预约该车位，否则在 spotType 类型中自动分配
+func (s *ReservationService) CreateReservation(
+	ctx context.Context,
+	userID uint,
+	license string,
+	spotID uint,
+	spotType models.ParkingType,
+	start, end time.Time,
+) (*models.Reservation, error) {
+	license = strings.TrimSpace(license)
+	if license == "" {
+		return nil, errors.New("车牌号不能为空")
+	}
+	if !end.After(start) {
+		return nil, errors.New("预约结束时间必须晚于开始时间")
+	}
+	if start.Before(time.Now().Add(-time.Minute)) {
+		return nil, errors.New("预约开始时间不能早于当前时间")
+	}
+
+	var candidates []uint
+	if spotID != 0 {
+		spot, err := s.parkingRepo.GetSpotByID(ctx, spotID)
+		if err != nil {
+			return nil, models.ErrParkingSpotNotFound
+		}
+		if spot.OwnerID != 0 && spot.OwnerID != userID {
+			return nil, errors.New("该车位为私人车位，无法预约")
+		}
+		candidates = []uint{spot.ID}
+	} else {
+		if spotType == "" {
+			return nil, errors.New("请指定车位或车位类型")
+		}
+		spots, err := s.parkingRepo.ListSpots(ctx, repositories.SpotFilter{Type: spotType})
+		if err != nil {
+			return nil, fmt.Errorf("查询车位失败: %w", err)
+		}
+		for _, spot := range spots {
+			if spot.OwnerID == 0 && spot.Status != string(models.Faulty) {
+				candidates = append(candidates, spot.ID)
+			}
+		}
+	}
+
+	reservation := &models.Reservation{
+		UserID:    userID,
+		License:   license,
+		StartTime: start,
+		EndTime:   end,
+	}
+	if err := s.reservationRepo.CreateReservation(ctx, reservation, candidates); err != nil {
+		return nil, err
+	}
+
+	logger.Log.Info("车位预约成功",
+		zap.Uint("reservationID", reservation.ID),
+		zap.Uint("userID", userID),
+		zap.Uint("spotID", reservation.SpotID),
+		zap.Time("start", start),
+		zap.Time("end", end))
+	return reservation, nil
+}
+
+func (s *ReservationService) ListReservations(
+	ctx context.Context,
+	userID uint,
+	status models.ReservationStatus,
+) ([]*models.Reservation, error) {
+	return s.reservationRepo.GetUserReservations(ctx, userID, status)
+}
+
+// CancelReservation 取消自己尚未履约的预约
+func (s *ReservationService) CancelReservation(ctx context.Context, userID, reservationID uint) (*models.Reservation, error) {
+	reservation, err := s.reservationRepo.GetReservationByID(ctx, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	if reservation.UserID != userID {
+		return nil, models.ErrReservationNotFound
+	}
+	if reservation.Status != models.ReservationBooked {
+		return nil, fmt.Errorf("预约当前状态为 %s，无法取消", reservation.Status)
+	}
+
+	if err := s.reservationRepo.UpdateReservationStatus(ctx, reservation.ID, models.ReservationCancelled); err != nil {
+		return nil, fmt.Errorf("取消预约失败: %w", err)
+	}
+	reservation.Status = models.ReservationCancelled
+	return reservation, nil
+}
+
+// FindForEntry 查找车牌入场时可使用的预约（允许在开始前宽限期内提前入场），没有时返回 nil
+func (s *ReservationService) FindForEntry(ctx context.Context, license string) (*models.Reservation, error) {
+	now := time.Now()
+	return s.reservationRepo.FindBookedByLicense(ctx, license, now.Add(s.grace), now)
+}
+
+// ReservedSpotIDs 返回当前及宽限期内已被预约、不应分配给临时入场车辆的车位
+func (s *ReservationService) ReservedSpotIDs(ctx context.Context) (map[uint]bool, error) {
+	now := time.Now()
+	ids, err := s.reservationRepo.GetReservedSpotIDs(ctx, now, now.Add(s.grace))
+	if err != nil {
+		return nil, err
+	}
+	reserved := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		reserved[id] = true
+	}
+	return reserved, nil
+}
+
+func (s *ReservationService) MarkFulfilled(ctx context.Context, reservationID, recordID uint) error {
+	return s.reservationRepo.FulfillReservation(ctx, reservationID, recordID)
+}
+
+// ExpireNoShows 将超过宽限期仍未入场的预约标记为过期，释放车位
+func (s *ReservationService) ExpireNoShows(ctx context.Context) error {
+	count, err := s.reservationRepo.ExpireNoShows(ctx, time.Now().Add(-s.grace))
+	if err != nil {
+		return fmt.Errorf("处理爽约预约失败: %w", err)
+	}
+	if count > 0 {
+		logger.Log.Info("已过期爽约预约", zap.Int64("count", count))
+	}
+	return nil
+}
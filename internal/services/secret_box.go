@@ -0,0 +1,55 @@
+// internal/services/secret_box.go
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// secretBox 用 AES-256-GCM 加密保存在数据库中的密钥（TOTP 密钥、JWT 签名私钥）
+type secretBox struct {
+	aead cipher.AEAD
+	// 对应的配置项，解密失败时提示检查
+	configKey string
+}
+
+// newSecretBox 由配置的密钥派生 AES-256-GCM
+func newSecretBox(key, configKey string) *secretBox {
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		panic(fmt.Sprintf("初始化加密失败: %v", err))
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(fmt.Sprintf("初始化加密失败: %v", err))
+	}
+	return &secretBox{aead: aead, configKey: configKey}
+}
+
+// Seal 加密明文，结果为 base64(nonce || 密文)
+func (b *secretBox) Seal(plaintext []byte) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("加密失败: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(b.aead.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+func (b *secretBox) Open(sealed string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < b.aead.NonceSize() {
+		return nil, errors.New("加密数据已损坏")
+	}
+	nonce, ciphertext := raw[:b.aead.NonceSize()], raw[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("解密失败，请检查 %s", b.configKey)
+	}
+	return plaintext, nil
+}
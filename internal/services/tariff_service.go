@@ -0,0 +1,173 @@
+// internal/services/tariff_service.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/goccy/go-json"
+	"go.uber.org/zap"
+	"modules/internal/models"
+	"modules/internal/repositories"
+	"modules/internal/tariff"
+	"modules/pkg/logger"
+	"strings"
+	"time"
+)
+
+type TariffService struct {
+	tariffRepo  repositories.TariffRepository
+	parkingRepo repositories.ParkingRepository
+}
+
+func NewTariffService(
+	tr repositories.TariffRepository,
+	pr repositories.ParkingRepository,
+) *TariffService {
+	return &TariffService{
+		tariffRepo:  tr,
+		parkingRepo: pr,
+	}
+}
+
+// FeeExplanation 停车记录计费说明
+type FeeExplanation struct {
+	Record *models.ParkingRecord
+	Tariff *models.Tariff
+	Quote  *tariff.Quote
+}
+
+// CreateTariff 创建资费新版本（草稿状态）
+func (s *TariffService) CreateTariff(
+	ctx context.Context,
+	name string,
+	description string,
+	schedule *tariff.Schedule,
+	createdBy uint,
+) (*models.Tariff, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("资费名称不能为空")
+	}
+	if schedule == nil {
+		return nil, errors.New("资费规则不能为空")
+	}
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+
+	rules, err := json.Marshal(schedule)
+	if err != nil {
+		return nil, fmt.Errorf("序列化资费规则失败: %w", err)
+	}
+
+	t := &models.Tariff{
+		Name:        name,
+		Description: description,
+		Rules:       rules,
+		CreatedBy:   createdBy,
+	}
+	if err := s.tariffRepo.CreateTariff(ctx, t); err != nil {
+		return nil, fmt.Errorf("创建资费失败: %w", err)
+	}
+
+	logger.Log.Info("资费版本已创建",
+		zap.String("name", t.Name),
+		zap.Int("version", t.Version),
+		zap.Uint("createdBy", createdBy))
+	return t, nil
+}
+
+func (s *TariffService) ListTariffs(ctx context.Context, name string) ([]*models.Tariff, error) {
+	return s.tariffRepo.ListTariffs(ctx, name)
+}
+
+func (s *TariffService) GetTariff(ctx context.Context, id uint) (*models.Tariff, error) {
+	return s.tariffRepo.GetTariffByID(ctx, id)
+}
+
+// ActivateTariff 启用资费版本，之前生效的版本自动归档
+func (s *TariffService) ActivateTariff(ctx context.Context, id uint) (*models.Tariff, error) {
+	t, err := s.tariffRepo.ActivateTariff(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	logger.Log.Info("资费版本已启用",
+		zap.Uint("tariffID", t.ID),
+		zap.String("name", t.Name),
+		zap.Int("version", t.Version))
+	return t, nil
+}
+
+// Quote 按当前生效资费计算费用；没有生效资费时使用默认规则，返回的资费为 nil
+func (s *TariffService) Quote(
+	ctx context.Context,
+	spot *models.ParkingSpot,
+	entry, exit time.Time,
+) (*tariff.Quote, *models.Tariff, error) {
+	active, err := s.tariffRepo.GetActiveTariff(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取生效资费失败: %w", err)
+	}
+
+	schedule := tariff.DefaultSchedule()
+	if active != nil {
+		if schedule, err = DecodeSchedule(active); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return schedule.Calculate(models.ParkingType(spot.Type), spot.HourlyRate, entry, exit), active, nil
+}
+
+// ExplainRecord 返回停车记录结算时保存的计费明细；早期没有保存明细的记录使用当时的资费版本和车位当前费率重新计算
+func (s *TariffService) ExplainRecord(ctx context.Context, recordID uint) (*FeeExplanation, error) {
+	record, err := s.parkingRepo.GetParkingByID(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if record.ExitTime == nil {
+		return nil, errors.New("停车记录尚未结算")
+	}
+
+	var used *models.Tariff
+	if record.TariffID != nil {
+		if used, err = s.tariffRepo.GetTariffByID(ctx, *record.TariffID); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(record.FeeQuote) > 0 {
+		var quote tariff.Quote
+		if err := json.Unmarshal(record.FeeQuote, &quote); err != nil {
+			return nil, fmt.Errorf("解析计费明细失败: %w", err)
+		}
+		return &FeeExplanation{Record: record, Tariff: used, Quote: &quote}, nil
+	}
+
+	schedule := tariff.DefaultSchedule()
+	if used != nil {
+		if schedule, err = DecodeSchedule(used); err != nil {
+			return nil, err
+		}
+	}
+
+	spot, err := s.parkingRepo.GetSpotByID(ctx, record.SpotID)
+	if err != nil {
+		return nil, fmt.Errorf("获取车位信息失败: %w", err)
+	}
+	return &FeeExplanation{
+		Record: record,
+		Tariff: used,
+		Quote:  schedule.Calculate(models.ParkingType(spot.Type), spot.HourlyRate, record.EntryTime, *record.ExitTime),
+	}, nil
+}
+
+// DecodeSchedule 解析资费版本中保存的规则集
+func DecodeSchedule(t *models.Tariff) (*tariff.Schedule, error) {
+	var schedule tariff.Schedule
+	if err := json.Unmarshal(t.Rules, &schedule); err != nil {
+		return nil, fmt.Errorf("解析资费规则失败: %w", err)
+	}
+	return &schedule, nil
+}
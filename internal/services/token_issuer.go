@@ -0,0 +1,421 @@
+// internal/services/token_issuer.go
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"math/big"
+	"modules/config"
+	"modules/internal/models"
+	"modules/internal/repositories"
+	"modules/pkg/logger"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 签名密钥默认配置
+const (
+	defaultSigningAlgorithm = models.SigningAlgEdDSA
+	defaultJWTIssuer        = "parking"
+	defaultKeyRotation      = 30 * 24 * time.Hour
+	// 缓存的密钥超过该时长后重新从数据库加载，以便获取其他进程轮换出的密钥
+	signingKeyCacheTTL = time.Minute
+	// 轮换后旧密钥在访问令牌有效期之外额外保留的时长，覆盖各服务的时钟偏差和 JWKS 缓存
+	retiredKeyGrace = 10 * time.Minute
+	rsaKeyBits      = 2048
+)
+
+// JWK 公钥的 JSON Web Key 表示（RFC 7517）
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKS 公钥集合
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// issuerKey 解析后的签名密钥
+type issuerKey struct {
+	*models.SigningKey
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// TokenIssuer 签发和验证 JWT。支持 RS256 和 EdDSA，同时持有多把按 kid 区分的密钥并定期轮换，
+// 公钥通过 JWKS 发布，其他服务无需共享密钥即可验证令牌
+type TokenIssuer struct {
+	keyRepo          repositories.SigningKeyRepository
+	secrets          *secretBox
+	algorithm        string
+	issuer           string
+	rotationInterval time.Duration
+	accessTTL        time.Duration
+
+	mu       sync.RWMutex
+	keys     map[string]*issuerKey
+	current  *issuerKey
+	loadedAt time.Time
+}
+
+func NewTokenIssuer(kr repositories.SigningKeyRepository, cfg *config.Config) *TokenIssuer {
+	algorithm := cfg.JWT.Algorithm
+	switch algorithm {
+	case models.SigningAlgRS256, models.SigningAlgEdDSA:
+	case "":
+		algorithm = defaultSigningAlgorithm
+	default:
+		logger.Log.Warn("不支持的 JWT 签名算法，使用默认算法",
+			zap.String("algorithm", algorithm),
+			zap.String("default", defaultSigningAlgorithm))
+		algorithm = defaultSigningAlgorithm
+	}
+	issuer := cfg.JWT.Issuer
+	if issuer == "" {
+		issuer = defaultJWTIssuer
+	}
+	if cfg.JWT.KeyEncryptionKey == "" {
+		logger.Log.Warn("未配置 jwt.key_encryption_key，签名私钥仅使用空密钥加密")
+	}
+	return &TokenIssuer{
+		keyRepo:          kr,
+		secrets:          newSecretBox(cfg.JWT.KeyEncryptionKey, "jwt.key_encryption_key"),
+		algorithm:        algorithm,
+		issuer:           issuer,
+		rotationInterval: parseDurationOr(cfg.JWT.RotationInterval, defaultKeyRotation, "jwt.rotation_interval"),
+		accessTTL:        parseTokenTTL(cfg.JWT.ExpiresIn, defaultAccessTokenTTL),
+		keys:             map[string]*issuerKey{},
+	}
+}
+
+// Sign 使用当前签发密钥签名，签发者和 kid 由签发器填写；尚无密钥时先生成一把
+func (i *TokenIssuer) Sign(ctx context.Context, claims *Claims) (string, error) {
+	key, err := i.currentKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	claims.Issuer = i.issuer
+
+	token := jwt.NewWithClaims(signingMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.KID
+	return token.SignedString(key.private)
+}
+
+// Parse 按 kid 选择公钥验证令牌，并校验签名算法与签发者
+func (i *TokenIssuer) Parse(ctx context.Context, tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, models.ErrSigningKeyNotFound
+		}
+		key, err := i.lookup(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != key.Algorithm {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return key.public, nil
+	},
+		jwt.WithValidMethods([]string{models.SigningAlgRS256, models.SigningAlgEdDSA}),
+		jwt.WithIssuer(i.issuer),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// JWKS 当前可用于验证的全部公钥
+func (i *TokenIssuer) JWKS(ctx context.Context) (*JWKS, error) {
+	if err := i.reload(ctx, false); err != nil {
+		return nil, err
+	}
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	set := &JWKS{Keys: make([]JWK, 0, len(i.keys))}
+	for _, key := range i.sortedKeys() {
+		set.Keys = append(set.Keys, toJWK(key))
+	}
+	return set, nil
+}
+
+// ListKeys 当前可用于验证的全部密钥，最新的在前
+func (i *TokenIssuer) ListKeys(ctx context.Context) ([]*models.SigningKey, error) {
+	if err := i.reload(ctx, true); err != nil {
+		return nil, err
+	}
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	keys := make([]*models.SigningKey, 0, len(i.keys))
+	for _, key := range i.sortedKeys() {
+		keys = append(keys, key.SigningKey)
+	}
+	return keys, nil
+}
+
+// Rotate 立即生成新的签发密钥，旧密钥在其签发的访问令牌过期前仍可用于验证
+func (i *TokenIssuer) Rotate(ctx context.Context) (*models.SigningKey, error) {
+	if err := i.reload(ctx, true); err != nil {
+		return nil, err
+	}
+	i.mu.RLock()
+	currentKID := ""
+	if i.current != nil {
+		currentKID = i.current.KID
+	}
+	i.mu.RUnlock()
+
+	next, err := i.generateKey()
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := i.keyRepo.RotateKey(ctx, currentKID, next, time.Now().Add(i.accessTTL+retiredKeyGrace))
+	if err != nil {
+		return nil, fmt.Errorf("保存签名密钥失败: %w", err)
+	}
+	if err := i.reload(ctx, true); err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// 其他进程已完成轮换，使用其生成的密钥
+		i.mu.RLock()
+		defer i.mu.RUnlock()
+		if i.current == nil {
+			return nil, models.ErrSigningKeyNotFound
+		}
+		return i.current.SigningKey, nil
+	}
+
+	logger.Log.Info("已轮换 JWT 签名密钥",
+		zap.String("kid", next.KID),
+		zap.String("algorithm", next.Algorithm),
+		zap.String("retiredKID", currentKID))
+	return next, nil
+}
+
+// RotateIfDue 当前签发密钥超过轮换周期或算法与配置不一致时轮换，并清理已过期的密钥，由定时任务调用
+func (i *TokenIssuer) RotateIfDue(ctx context.Context) error {
+	if err := i.keyRepo.DeleteExpired(ctx, time.Now()); err != nil {
+		return fmt.Errorf("清理过期签名密钥失败: %w", err)
+	}
+	if err := i.reload(ctx, true); err != nil {
+		return err
+	}
+
+	i.mu.RLock()
+	current := i.current
+	i.mu.RUnlock()
+	if current != nil &&
+		current.Algorithm == i.algorithm &&
+		time.Since(current.CreatedAt) < i.rotationInterval {
+		return nil
+	}
+	_, err := i.Rotate(ctx)
+	return err
+}
+
+// currentKey 当前签发密钥，尚无密钥时生成第一把
+func (i *TokenIssuer) currentKey(ctx context.Context) (*issuerKey, error) {
+	if err := i.reload(ctx, false); err != nil {
+		return nil, err
+	}
+	i.mu.RLock()
+	current := i.current
+	i.mu.RUnlock()
+	if current != nil {
+		return current, nil
+	}
+
+	if _, err := i.Rotate(ctx); err != nil {
+		return nil, err
+	}
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if i.current == nil {
+		return nil, models.ErrSigningKeyNotFound
+	}
+	return i.current, nil
+}
+
+// lookup 按 kid 查找密钥，缓存中没有时重新加载一次
+func (i *TokenIssuer) lookup(ctx context.Context, kid string) (*issuerKey, error) {
+	if err := i.reload(ctx, false); err != nil {
+		return nil, err
+	}
+	i.mu.RLock()
+	key, ok := i.keys[kid]
+	i.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	if err := i.reload(ctx, true); err != nil {
+		return nil, err
+	}
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if key, ok = i.keys[kid]; !ok {
+		return nil, models.ErrSigningKeyNotFound
+	}
+	return key, nil
+}
+
+// reload 从数据库加载仍可用于验证的密钥，force 为 false 时缓存未过期则跳过
+func (i *TokenIssuer) reload(ctx context.Context, force bool) error {
+	i.mu.RLock()
+	fresh := time.Since(i.loadedAt) < signingKeyCacheTTL
+	i.mu.RUnlock()
+	if fresh && !force {
+		return nil
+	}
+
+	now := time.Now()
+	records, err := i.keyRepo.ListValidKeys(ctx, now)
+	if err != nil {
+		return fmt.Errorf("查询签名密钥失败: %w", err)
+	}
+	keys := make(map[string]*issuerKey, len(records))
+	var current *issuerKey
+	for _, record := range records {
+		key, err := i.decodeKey(record)
+		if err != nil {
+			logger.Log.Error("解析签名密钥失败", zap.String("kid", record.KID), zap.Error(err))
+			continue
+		}
+		keys[key.KID] = key
+		// 记录按 ID 倒序，第一把未停用的即当前签发密钥
+		if current == nil && key.Active() {
+			current = key
+		}
+	}
+
+	i.mu.Lock()
+	i.keys = keys
+	i.current = current
+	i.loadedAt = now
+	i.mu.Unlock()
+	return nil
+}
+
+// sortedKeys 按创建顺序倒序排列的密钥，调用方需持有读锁
+func (i *TokenIssuer) sortedKeys() []*issuerKey {
+	keys := make([]*issuerKey, 0, len(i.keys))
+	for _, key := range i.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool { return keys[a].ID > keys[b].ID })
+	return keys
+}
+
+// generateKey 按配置的算法生成新密钥，私钥加密后保存
+func (i *TokenIssuer) generateKey() (*models.SigningKey, error) {
+	var (
+		private crypto.Signer
+		err     error
+	)
+	switch i.algorithm {
+	case models.SigningAlgRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	default:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("生成签名密钥失败: %w", err)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("编码签名私钥失败: %w", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return nil, fmt.Errorf("编码签名公钥失败: %w", err)
+	}
+	sealed, err := i.secrets.Seal(privateDER)
+	if err != nil {
+		return nil, fmt.Errorf("加密签名私钥失败: %w", err)
+	}
+
+	return &models.SigningKey{
+		KID:        randomToken(12),
+		Algorithm:  i.algorithm,
+		PrivateKey: sealed,
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+	}, nil
+}
+
+func (i *TokenIssuer) decodeKey(record *models.SigningKey) (*issuerKey, error) {
+	block, _ := pem.Decode([]byte(record.PublicKey))
+	if block == nil {
+		return nil, errors.New("公钥格式错误")
+	}
+	public, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析公钥失败: %w", err)
+	}
+	key := &issuerKey{SigningKey: record, public: public}
+
+	// 已停用的密钥只用于验证，不需要私钥
+	if record.Active() {
+		der, err := i.secrets.Open(record.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		private, err := x509.ParsePKCS8PrivateKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("解析私钥失败: %w", err)
+		}
+		signer, ok := private.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("不支持的私钥类型")
+		}
+		key.private = signer
+	}
+	return key, nil
+}
+
+func signingMethod(algorithm string) jwt.SigningMethod {
+	if algorithm == models.SigningAlgRS256 {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodEdDSA
+}
+
+func toJWK(key *issuerKey) JWK {
+	jwk := JWK{KeyID: key.KID, Use: "sig", Algorithm: key.Algorithm}
+	switch pub := key.public.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
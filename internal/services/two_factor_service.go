@@ -0,0 +1,334 @@
+// internal/services/two_factor_service.go
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"modules/config"
+	"modules/internal/models"
+	"modules/internal/repositories"
+	"modules/pkg/logger"
+	"modules/pkg/totp"
+	"strings"
+	"time"
+)
+
+// 两步验证默认配置
+const (
+	defaultTwoFactorIssuer = "停车场"
+	defaultChallengeTTL    = 5 * time.Minute
+	defaultTOTPSkew        = 1
+	// 每次生成的恢复码数量
+	recoveryCodeCount = 10
+)
+
+// TwoFactorSetup 生成密钥后返回给用户，用于在验证器应用中添加账户
+type TwoFactorSetup struct {
+	Secret string
+	URI    string
+}
+
+// TwoFactorStatus 用户的两步验证状态
+type TwoFactorStatus struct {
+	Enabled                bool
+	Required               bool
+	RecoveryCodesRemaining int64
+}
+
+// TwoFactorService TOTP 两步验证：密钥绑定、验证码和恢复码校验，管理员账户必须启用
+type TwoFactorService struct {
+	twoFactorRepo repositories.TwoFactorRepository
+	userRepo      repositories.UserRepository
+	tokenRepo     repositories.TokenRepository
+	audit         *AuditService
+	issuer        string
+	skew          int
+	secrets       *secretBox
+}
+
+func NewTwoFactorService(
+	tr repositories.TwoFactorRepository,
+	ur repositories.UserRepository,
+	tokenRepo repositories.TokenRepository,
+	audit *AuditService,
+	cfg *config.Config,
+) *TwoFactorService {
+	issuer := cfg.TwoFactor.Issuer
+	if issuer == "" {
+		issuer = defaultTwoFactorIssuer
+	}
+	skew := cfg.TwoFactor.Skew
+	if skew <= 0 {
+		skew = defaultTOTPSkew
+	}
+	key, configKey := cfg.TwoFactor.EncryptionKey, "two_factor.encryption_key"
+	if key == "" {
+		key, configKey = cfg.JWT.KeyEncryptionKey, "jwt.key_encryption_key"
+	}
+	return &TwoFactorService{
+		twoFactorRepo: tr,
+		userRepo:      ur,
+		tokenRepo:     tokenRepo,
+		audit:         audit,
+		issuer:        issuer,
+		skew:          skew,
+		secrets:       newSecretBox(key, configKey),
+	}
+}
+
+// RequiredFor 该用户是否必须启用两步验证
+func (s *TwoFactorService) RequiredFor(roles []models.Role) bool {
+	for _, role := range roles {
+		if role == models.Admin {
+			return true
+		}
+	}
+	return false
+}
+
+// Get 查询用户的两步验证配置，未配置时返回 nil
+func (s *TwoFactorService) Get(ctx context.Context, userID uint) (*models.UserTwoFactor, error) {
+	tf, err := s.twoFactorRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询两步验证配置失败: %w", err)
+	}
+	return tf, nil
+}
+
+// Status 查询当前用户的两步验证状态
+func (s *TwoFactorService) Status(ctx context.Context, userID uint) (*TwoFactorStatus, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := userRoles(user)
+	if err != nil {
+		return nil, err
+	}
+	tf, err := s.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &TwoFactorStatus{Enabled: tf.Enabled(), Required: s.RequiredFor(roles)}
+	if status.Enabled {
+		if status.RecoveryCodesRemaining, err = s.twoFactorRepo.CountRecoveryCodes(ctx, userID); err != nil {
+			return nil, fmt.Errorf("查询恢复码失败: %w", err)
+		}
+	}
+	return status, nil
+}
+
+// Setup 生成新的 TOTP 密钥，启用前需要用验证码确认
+func (s *TwoFactorService) Setup(ctx context.Context, userID uint) (*TwoFactorSetup, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	tf, err := s.Get(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if tf.Enabled() {
+		return nil, models.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.secrets.Seal([]byte(secret))
+	if err != nil {
+		return nil, fmt.Errorf("加密两步验证密钥失败: %w", err)
+	}
+	if err := s.twoFactorRepo.SavePending(ctx, user.ID, sealed); err != nil {
+		return nil, fmt.Errorf("保存两步验证密钥失败: %w", err)
+	}
+	return &TwoFactorSetup{Secret: secret, URI: totp.URI(s.issuer, user.Username, secret)}, nil
+}
+
+// Enable 用验证器生成的验证码确认密钥并启用两步验证，返回一次性恢复码
+func (s *TwoFactorService) Enable(ctx context.Context, userID uint, code string) ([]string, error) {
+	tf, err := s.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil {
+		return nil, models.ErrTwoFactorSetupRequired
+	}
+	if tf.Enabled() {
+		return nil, models.ErrTwoFactorAlreadyEnabled
+	}
+
+	step, err := s.validateOTP(tf, code)
+	if err != nil {
+		return nil, err
+	}
+	codes, hashes := generateRecoveryCodes()
+	if err := s.twoFactorRepo.Enable(ctx, userID, step, hashes); err != nil {
+		if errors.Is(err, models.ErrTwoFactorAlreadyEnabled) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("启用两步验证失败: %w", err)
+	}
+	logger.Log.Info("已启用两步验证", zap.Uint("userID", userID))
+	return codes, nil
+}
+
+// Verify 校验验证码或恢复码，验证码在同一时间步内只能使用一次，恢复码只能使用一次
+func (s *TwoFactorService) Verify(ctx context.Context, userID uint, code string) error {
+	tf, err := s.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !tf.Enabled() {
+		return models.ErrTwoFactorNotEnabled
+	}
+
+	if isOTP(code) {
+		step, err := s.validateOTP(tf, code)
+		if err != nil {
+			return err
+		}
+		fresh, err := s.twoFactorRepo.MarkStepUsed(ctx, userID, step)
+		if err != nil {
+			return fmt.Errorf("更新两步验证状态失败: %w", err)
+		}
+		if !fresh {
+			return models.ErrInvalidOTP
+		}
+		return nil
+	}
+
+	used, err := s.twoFactorRepo.ConsumeRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return fmt.Errorf("校验恢复码失败: %w", err)
+	}
+	if !used {
+		return models.ErrInvalidOTP
+	}
+	logger.Log.Warn("使用恢复码完成两步验证", zap.Uint("userID", userID))
+	return nil
+}
+
+// Disable 关闭两步验证，需要当前验证码或恢复码；管理员不能关闭
+func (s *TwoFactorService) Disable(ctx context.Context, userID uint, code string) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	roles, err := userRoles(user)
+	if err != nil {
+		return err
+	}
+	if s.RequiredFor(roles) {
+		return models.ErrTwoFactorMandatory
+	}
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	if err := s.twoFactorRepo.Delete(ctx, userID); err != nil {
+		return fmt.Errorf("关闭两步验证失败: %w", err)
+	}
+	logger.Log.Info("已关闭两步验证", zap.Uint("userID", userID))
+	return nil
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部作废
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error) {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+	codes, hashes := generateRecoveryCodes()
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("生成恢复码失败: %w", err)
+	}
+	return codes, nil
+}
+
+// Reset 管理员重置用户的两步验证，用户的登录会话同时失效；管理员账户下次登录时需要重新绑定，
+// 且只能由管理员重置
+func (s *TwoFactorService) Reset(ctx context.Context, adminID, userID uint) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := requireAdminForTarget(ctx, s.userRepo, adminID, user); err != nil {
+		return err
+	}
+	if err := s.twoFactorRepo.Delete(ctx, userID); err != nil {
+		return fmt.Errorf("重置两步验证失败: %w", err)
+	}
+	if err := s.tokenRepo.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		logger.Log.Error("重置两步验证后撤销刷新令牌失败", zap.Uint("userID", userID), zap.Error(err))
+	}
+	s.audit.Record(ctx, adminID, models.AuditUserTwoFactorReset, models.AuditTargetUser, userID, "")
+	logger.Log.Info("管理员已重置两步验证", zap.Uint("adminID", adminID), zap.Uint("userID", userID))
+	return nil
+}
+
+func (s *TwoFactorService) getUser(ctx context.Context, userID uint) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	if user == nil {
+		return nil, models.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// validateOTP 校验验证码，返回匹配的时间步
+func (s *TwoFactorService) validateOTP(tf *models.UserTwoFactor, code string) (int64, error) {
+	secret, err := s.secrets.Open(tf.Secret)
+	if err != nil {
+		return 0, fmt.Errorf("读取两步验证密钥失败: %w", err)
+	}
+	step, ok := totp.Validate(string(secret), code, time.Now(), s.skew)
+	if !ok || step <= tf.LastUsedStep {
+		return 0, models.ErrInvalidOTP
+	}
+	return step, nil
+}
+
+// isOTP 6 位数字视为验证码，其余视为恢复码
+func isOTP(code string) bool {
+	code = strings.TrimSpace(code)
+	if len(code) != totp.Digits {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateRecoveryCodes 生成恢复码（形如 abcde-fghij）及其摘要
+func generateRecoveryCodes() ([]string, []string) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			panic(fmt.Sprintf("生成恢复码失败: %v", err))
+		}
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, hashToken(raw))
+	}
+	return codes, hashes
+}
+
+// normalizeRecoveryCode 忽略大小写、空格和连字符
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,253 @@
+// internal/services/user_admin_service.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"modules/internal/models"
+	"modules/internal/repositories"
+	"modules/internal/utils"
+	"modules/pkg/logger"
+	"strings"
+)
+
+// UserAdminService 管理员管理用户：查询、分配角色、启用停用和强制重置密码，每次修改都记录审计事件
+type UserAdminService struct {
+	userRepo    repositories.UserRepository
+	tokenRepo   repositories.TokenRepository
+	accounts    *AccountService
+	permissions *PermissionService
+	twoFactor   *TwoFactorService
+	audit       *AuditService
+}
+
+func NewUserAdminService(
+	ur repositories.UserRepository,
+	tr repositories.TokenRepository,
+	accounts *AccountService,
+	permissions *PermissionService,
+	twoFactor *TwoFactorService,
+	audit *AuditService,
+) *UserAdminService {
+	return &UserAdminService{
+		userRepo:    ur,
+		tokenRepo:   tr,
+		accounts:    accounts,
+		permissions: permissions,
+		twoFactor:   twoFactor,
+		audit:       audit,
+	}
+}
+
+// ListUsers 分页查询用户
+func (s *UserAdminService) ListUsers(ctx context.Context, filter repositories.UserFilter) ([]*models.User, int64, error) {
+	users, total, err := s.userRepo.ListUsers(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询用户失败: %w", err)
+	}
+	return users, total, nil
+}
+
+// SetRoles 替换用户的角色，角色须已定义，重复的角色只保留一个；管理员不能移除自己的管理员角色。
+// 授予或移除管理员角色、修改管理员账户或自己的角色时，操作人须是管理员。
+// 授予需要两步验证的角色而用户尚未启用时，用户现有的登录会话全部失效
+func (s *UserAdminService) SetRoles(ctx context.Context, adminID, userID uint, roles []models.Role) (*models.User, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	unique := make([]models.Role, 0, len(roles))
+	seen := map[models.Role]bool{}
+	isAdmin := false
+	for _, role := range roles {
+		exists, err := s.permissions.RoleExists(ctx, role)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, models.ErrInvalidRole
+		}
+		if seen[role] {
+			continue
+		}
+		seen[role] = true
+		unique = append(unique, role)
+		isAdmin = isAdmin || role == models.Admin
+	}
+
+	previous, err := userRoles(user)
+	if err != nil {
+		return nil, err
+	}
+	if isAdmin || hasRole(previous, models.Admin) || adminID == userID {
+		if err := requireAdminActor(ctx, s.userRepo, adminID); err != nil {
+			return nil, err
+		}
+	}
+	if adminID == userID && !isAdmin {
+		return nil, models.ErrSelfModification
+	}
+	encoded, err := models.MarshalRoles(unique)
+	if err != nil {
+		return nil, fmt.Errorf("序列化用户角色失败: %w", err)
+	}
+	if err := s.userRepo.UpdateRoles(ctx, userID, encoded); err != nil {
+		return nil, fmt.Errorf("更新用户角色失败: %w", err)
+	}
+	user.Roles = encoded
+
+	// 新角色要求两步验证而用户尚未启用时，撤销现有会话，重新登录时完成两步验证设置
+	if s.twoFactor.RequiredFor(unique) {
+		tf, err := s.twoFactor.Get(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !tf.Enabled() {
+			if err := s.tokenRepo.RevokeUserRefreshTokens(ctx, userID); err != nil {
+				return nil, fmt.Errorf("撤销用户会话失败: %w", err)
+			}
+		}
+	}
+
+	s.audit.Record(ctx, adminID, models.AuditUserRolesUpdated, models.AuditTargetUser, userID,
+		fmt.Sprintf("%s -> %s", joinRoles(previous), joinRoles(unique)))
+	logger.Log.Info("管理员已更新用户角色",
+		zap.Uint("adminID", adminID),
+		zap.Uint("userID", userID),
+		zap.String("roles", joinRoles(unique)))
+	return user, nil
+}
+
+// SetActive 启用或停用账户，停用后登录会话立即失效；管理员不能停用自己，只有管理员可以启停管理员账户
+func (s *UserAdminService) SetActive(ctx context.Context, adminID, userID uint, active bool) (*models.User, error) {
+	if adminID == userID && !active {
+		return nil, models.ErrSelfModification
+	}
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireAdminForTarget(ctx, s.userRepo, adminID, user); err != nil {
+		return nil, err
+	}
+	if user.IsActive == active {
+		return user, nil
+	}
+
+	if err := s.userRepo.SetActive(ctx, userID, active); err != nil {
+		return nil, fmt.Errorf("更新账户状态失败: %w", err)
+	}
+	user.IsActive = active
+
+	action := models.AuditUserActivated
+	if !active {
+		action = models.AuditUserDeactivated
+		// 访问令牌在认证时校验账户状态，这里只需撤销刷新令牌
+		if err := s.tokenRepo.RevokeUserRefreshTokens(ctx, userID); err != nil {
+			logger.Log.Error("停用账户后撤销刷新令牌失败", zap.Uint("userID", userID), zap.Error(err))
+		}
+	}
+	s.audit.Record(ctx, adminID, action, models.AuditTargetUser, userID, "")
+	logger.Log.Info("管理员已更新账户状态",
+		zap.Uint("adminID", adminID),
+		zap.Uint("userID", userID),
+		zap.Bool("active", active))
+	return user, nil
+}
+
+// ForcePasswordReset 使用户的当前密码和登录会话失效，并向其邮箱发送密码重置邮件；只有管理员可以重置管理员账户
+func (s *UserAdminService) ForcePasswordReset(ctx context.Context, adminID, userID uint) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := requireAdminForTarget(ctx, s.userRepo, adminID, user); err != nil {
+		return err
+	}
+
+	// 替换为随机密码，用户只能通过重置邮件设置新密码
+	hashed, err := utils.HashPassword(randomToken(32))
+	if err != nil {
+		return fmt.Errorf("生成随机密码失败: %w", err)
+	}
+	if err := s.userRepo.UpdatePassword(ctx, userID, hashed); err != nil {
+		return fmt.Errorf("更新密码失败: %w", err)
+	}
+	if err := s.tokenRepo.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		logger.Log.Error("强制重置密码后撤销刷新令牌失败", zap.Uint("userID", userID), zap.Error(err))
+	}
+	s.audit.Record(ctx, adminID, models.AuditUserPasswordReset, models.AuditTargetUser, userID, "")
+
+	// 密码已失效，邮件发送失败时返回错误，管理员可以重新发起
+	if err := s.accounts.SendPasswordReset(ctx, user); err != nil {
+		return fmt.Errorf("发送密码重置邮件失败: %w", err)
+	}
+	logger.Log.Info("管理员已强制重置密码", zap.Uint("adminID", adminID), zap.Uint("userID", userID))
+	return nil
+}
+
+func (s *UserAdminService) getUser(ctx context.Context, userID uint) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	if user == nil {
+		return nil, models.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// requireAdminActor 操作人须拥有管理员角色，角色以数据库中的当前值为准
+func requireAdminActor(ctx context.Context, userRepo repositories.UserRepository, actorID uint) error {
+	actor, err := userRepo.GetUserByID(ctx, actorID)
+	if err != nil {
+		return fmt.Errorf("查询操作人失败: %w", err)
+	}
+	if actor == nil {
+		return models.ErrAdminRequired
+	}
+	roles, err := userRoles(actor)
+	if err != nil {
+		return err
+	}
+	if !hasRole(roles, models.Admin) {
+		return models.ErrAdminRequired
+	}
+	return nil
+}
+
+// requireAdminForTarget 目标账户是管理员时，操作人须是管理员
+func requireAdminForTarget(
+	ctx context.Context,
+	userRepo repositories.UserRepository,
+	actorID uint,
+	target *models.User,
+) error {
+	roles, err := userRoles(target)
+	if err != nil {
+		return err
+	}
+	if !hasRole(roles, models.Admin) {
+		return nil
+	}
+	return requireAdminActor(ctx, userRepo, actorID)
+}
+
+func hasRole(roles []models.Role, role models.Role) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func joinRoles(roles []models.Role) string {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+	return "[" + strings.Join(names, ",") + "]"
+}
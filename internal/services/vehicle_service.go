@@ -3,48 +3,41 @@ package services
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"modules/internal/models"
 	"modules/internal/repositories"
+	"time"
 )
 
 type VehicleService struct {
-	repo         repositories.VehicleRepository
-	userRepo     repositories.UserRepository
-	parkingRepo  repositories.ParkingRepository
-	leaseService *LeaseService
+	repo        repositories.VehicleRepository
+	userRepo    repositories.UserRepository
+	parkingRepo repositories.ParkingRepository
+	marketplace *MarketplaceService
 }
 
 func NewVehicleService(
 	vr repositories.VehicleRepository,
 	ur repositories.UserRepository,
 	pr repositories.ParkingRepository,
-	ls *LeaseService,
+	ms *MarketplaceService,
 ) *VehicleService {
 	return &VehicleService{
-		repo:         vr,
-		userRepo:     ur,
-		parkingRepo:  pr,
-		leaseService: ls,
+		repo:        vr,
+		userRepo:    ur,
+		parkingRepo: pr,
+		marketplace: ms,
 	}
 }
 
-func (s *VehicleService) PublishSpotForRent(ctx context.Context, userID, spotID uint, rate float64, period int) (*models.LeaseOrder, error) {
-	spot, err := s.parkingRepo.GetSpotByID(ctx, spotID)
-	if err != nil {
-		return nil, fmt.Errorf("获取车位信息失败: %w", err)
+// PublishSpotForRent 以月租价格发布自己车位从现在起 days 天内的出租信息
+func (s *VehicleService) PublishSpotForRent(ctx context.Context, userID, spotID uint, rate float64, days int) (*models.SpotListing, error) {
+	if days <= 0 {
+		return nil, fmt.Errorf("出租天数必须为正整数")
 	}
-
-	if spot.OwnerID == 0 {
-		return nil, errors.New("车位无业主，无法出租")
-	}
-
-	if spot.OwnerID != userID {
-		return nil, errors.New("无权操作该车位")
-	}
-
-	return s.leaseService.CreateLease(ctx, userID, spotID, period, rate)
+	now := time.Now()
+	window := models.ListingWindow{StartTime: now, EndTime: now.AddDate(0, 0, days)}
+	return s.marketplace.CreateListing(ctx, userID, spotID, "", models.PriceMonthly, rate, "", []models.ListingWindow{window})
 }
 
 func (s *VehicleService) RemoveVehicle(ctx context.Context, userID, vehicleID uint) error {
@@ -0,0 +1,470 @@
+// internal/services/webhook_service.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"io"
+	"modules/config"
+	"modules/internal/models"
+	"modules/internal/repositories"
+	"modules/pkg/logger"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Webhook 投递默认配置
+const (
+	defaultWebhookMaxAttempts = 6
+	defaultWebhookBackoffBase = 30 * time.Second
+	defaultWebhookTimeout     = 10 * time.Second
+	// 重试间隔上限
+	maxWebhookBackoff = 6 * time.Hour
+	// 每次扫描最多重试的投递数
+	webhookRetryBatch = 100
+	// 记录的响应体长度上限
+	webhookResponseLimit = 1024
+)
+
+// Webhook 请求头
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// WebhookService 管理 Webhook 订阅，并以 HMAC-SHA256 签名的 JSON 推送事件，失败时按指数退避重试
+type WebhookService struct {
+	webhookRepo repositories.WebhookRepository
+	client      *http.Client
+	maxAttempts int
+	backoffBase time.Duration
+	// 投递进行中时的租约时长，超过后其他进程可以接手重试
+	leaseDuration time.Duration
+}
+
+func NewWebhookService(
+	wr repositories.WebhookRepository,
+	obs *OutboxService,
+	cfg *config.Config,
+) *WebhookService {
+	maxAttempts := cfg.Webhook.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultWebhookMaxAttempts
+	}
+	backoff := parseDurationOr(cfg.Webhook.BackoffBase, defaultWebhookBackoffBase, "webhook.backoff_base")
+	timeout := parseDurationOr(cfg.Webhook.Timeout, defaultWebhookTimeout, "webhook.timeout")
+
+	s := &WebhookService{
+		webhookRepo:   wr,
+		client:        &http.Client{Timeout: timeout},
+		maxAttempts:   maxAttempts,
+		backoffBase:   backoff,
+		leaseDuration: timeout + time.Minute,
+	}
+	obs.Subscribe("webhook", "", s.handleOutboxEvent)
+	return s
+}
+
+func parseDurationOr(value string, fallback time.Duration, name string) time.Duration {
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		logger.Log.Warn("解析时长配置失败，使用默认值",
+			zap.String("name", name),
+			zap.String("value", value),
+			zap.Error(err))
+		return fallback
+	}
+	return d
+}
+
+// handleOutboxEvent 发件箱分发器的处理器：为事件生成投递记录并推送
+func (s *WebhookService) handleOutboxEvent(ctx context.Context, event *models.OutboxEvent) error {
+	_, err := s.Enqueue(ctx, event.EventID, event.Event, event.Payload)
+	return err
+}
+
+// Enqueue 为订阅了该事件的订阅生成投递记录并异步推送，payload 为已序列化的事件内容。
+// 同一事件重复入队时跳过已有投递记录的订阅
+func (s *WebhookService) Enqueue(
+	ctx context.Context,
+	eventID string,
+	event models.WebhookEvent,
+	payload string,
+) ([]*models.WebhookDelivery, error) {
+	subs, err := s.webhookRepo.ListSubscriptions(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("查询 Webhook 订阅失败: %w", err)
+	}
+
+	existing, err := s.webhookRepo.ListEventDeliveries(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("查询事件投递记录失败: %w", err)
+	}
+	enqueued := make(map[uint]bool, len(existing))
+	for _, d := range existing {
+		enqueued[d.SubscriptionID] = true
+	}
+
+	now := time.Now()
+	var deliveries []*models.WebhookDelivery
+	targets := make(map[*models.WebhookDelivery]*models.WebhookSubscription)
+	for _, sub := range subs {
+		if enqueued[sub.ID] || !subscribes(sub, event) {
+			continue
+		}
+		d := &models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        eventID,
+			Event:          event,
+			Payload:        payload,
+			Status:         models.DeliveryPending,
+			// 首次推送由当前进程负责，租约到期前重试任务不会接手
+			NextAttemptAt: now.Add(s.leaseDuration),
+		}
+		deliveries = append(deliveries, d)
+		targets[d] = sub
+	}
+	if len(deliveries) == 0 {
+		return nil, nil
+	}
+	if err := s.webhookRepo.CreateDeliveries(ctx, deliveries); err != nil {
+		return nil, err
+	}
+
+	for d, sub := range targets {
+		go s.attempt(context.Background(), d, sub)
+	}
+	return deliveries, nil
+}
+
+// RetryDueDeliveries 重试到期的投递，由定时任务调用
+func (s *WebhookService) RetryDueDeliveries(ctx context.Context) error {
+	now := time.Now()
+	due, err := s.webhookRepo.ListDueDeliveries(ctx, now, webhookRetryBatch)
+	if err != nil {
+		return fmt.Errorf("查询待重试的 Webhook 投递失败: %w", err)
+	}
+
+	for _, d := range due {
+		claimed, err := s.webhookRepo.ClaimDelivery(ctx, d.ID, d.Attempts, now, now.Add(s.leaseDuration))
+		if err != nil {
+			logger.Log.Error("抢占 Webhook 投递失败", zap.Uint("deliveryID", d.ID), zap.Error(err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		sub, err := s.webhookRepo.GetSubscription(ctx, d.SubscriptionID)
+		if err != nil || !sub.Active {
+			d.Status = models.DeliveryFailed
+			d.LastError = "订阅已删除或停用"
+			s.saveAttempt(ctx, d, &models.WebhookAttempt{Attempt: d.Attempts, Error: d.LastError})
+			continue
+		}
+		s.attempt(ctx, d, sub)
+	}
+	return nil
+}
+
+// Replay 立即重新推送一条投递，无论其当前状态
+func (s *WebhookService) Replay(ctx context.Context, deliveryID uint) (*models.WebhookDelivery, error) {
+	d, err := s.webhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := s.webhookRepo.GetSubscription(ctx, d.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 重试次数已用尽的投递重放失败后保持失败状态，不再自动重试
+	s.attempt(ctx, d, sub)
+
+	return s.webhookRepo.GetDelivery(ctx, deliveryID)
+}
+
+// attempt 推送一次并记录结果；失败且尝试次数未用尽时按退避策略安排下一次重试
+func (s *WebhookService) attempt(ctx context.Context, d *models.WebhookDelivery, sub *models.WebhookSubscription) {
+	d.Attempts++
+	attempt := &models.WebhookAttempt{Attempt: d.Attempts}
+
+	start := time.Now()
+	statusCode, response, err := s.send(ctx, d, sub)
+	attempt.DurationMs = time.Since(start).Milliseconds()
+	attempt.StatusCode = statusCode
+	attempt.Response = response
+
+	d.LastStatusCode = statusCode
+	switch {
+	case err == nil:
+		d.Status = models.DeliverySucceeded
+		d.LastError = ""
+	default:
+		attempt.Error = err.Error()
+		d.LastError = err.Error()
+		if d.Attempts >= s.maxAttempts {
+			d.Status = models.DeliveryFailed
+		} else {
+			d.Status = models.DeliveryPending
+			d.NextAttemptAt = time.Now().Add(s.backoff(d.Attempts))
+		}
+	}
+	s.saveAttempt(ctx, d, attempt)
+
+	if err != nil {
+		logger.Log.Warn("Webhook 推送失败",
+			zap.Uint("deliveryID", d.ID),
+			zap.Uint("subscriptionID", sub.ID),
+			zap.Int("attempt", d.Attempts),
+			zap.String("status", string(d.Status)),
+			zap.Error(err))
+	}
+}
+
+func (s *WebhookService) saveAttempt(ctx context.Context, d *models.WebhookDelivery, attempt *models.WebhookAttempt) {
+	if err := s.webhookRepo.SaveAttempt(ctx, d, attempt); err != nil {
+		logger.Log.Error("保存 Webhook 投递记录失败", zap.Uint("deliveryID", d.ID), zap.Error(err))
+	}
+}
+
+// send 发送请求，非 2xx 响应视为失败
+func (s *WebhookService) send(
+	ctx context.Context,
+	d *models.WebhookDelivery,
+	sub *models.WebhookSubscription,
+) (int, string, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, strings.NewReader(d.Payload))
+	if err != nil {
+		return 0, "", fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "parking-webhook/1.0")
+	req.Header.Set(WebhookEventHeader, string(d.Event))
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(uint64(d.ID), 10))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhook(sub.Secret, timestamp, []byte(d.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	var buf bytes.Buffer
+	io.Copy(&buf, io.LimitReader(resp.Body, webhookResponseLimit))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, buf.String(), fmt.Errorf("接收方返回状态码 %d", resp.StatusCode)
+	}
+	return resp.StatusCode, buf.String(), nil
+}
+
+// SignWebhook 计算签名：HMAC-SHA256(secret, timestamp + "." + body) 的十六进制编码。
+// 接收方应使用相同方式计算并比对 X-Webhook-Signature，同时校验时间戳防止重放
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff 第 n 次失败后的重试间隔
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	return exponentialBackoff(s.backoffBase, attempts, maxWebhookBackoff)
+}
+
+// CreateSubscription 新建订阅，未指定密钥时自动生成
+func (s *WebhookService) CreateSubscription(
+	ctx context.Context,
+	adminID uint,
+	rawURL string,
+	events []models.WebhookEvent,
+	description, secret string,
+) (*models.WebhookSubscription, error) {
+	if err := validateWebhookURL(rawURL); err != nil {
+		return nil, err
+	}
+	eventList, err := joinWebhookEvents(events)
+	if err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		secret = newWebhookSecret()
+	}
+
+	sub := &models.WebhookSubscription{
+		URL:         rawURL,
+		Secret:      secret,
+		Events:      eventList,
+		Description: description,
+		Active:      true,
+		CreatedBy:   adminID,
+	}
+	if err := s.webhookRepo.CreateSubscription(ctx, sub); err != nil {
+		return nil, fmt.Errorf("创建 Webhook 订阅失败: %w", err)
+	}
+	return sub, nil
+}
+
+// WebhookUpdate 订阅修改内容，为 nil 的字段保持不变
+type WebhookUpdate struct {
+	URL          *string
+	Events       *[]models.WebhookEvent
+	Description  *string
+	Active       *bool
+	RotateSecret bool
+}
+
+// UpdateSubscription 修改订阅
+func (s *WebhookService) UpdateSubscription(
+	ctx context.Context,
+	id uint,
+	update WebhookUpdate,
+) (*models.WebhookSubscription, error) {
+	sub, err := s.webhookRepo.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.URL != nil {
+		if err := validateWebhookURL(*update.URL); err != nil {
+			return nil, err
+		}
+		sub.URL = *update.URL
+	}
+	if update.Events != nil {
+		if sub.Events, err = joinWebhookEvents(*update.Events); err != nil {
+			return nil, err
+		}
+	}
+	if update.Description != nil {
+		sub.Description = *update.Description
+	}
+	if update.Active != nil {
+		sub.Active = *update.Active
+	}
+	if update.RotateSecret {
+		sub.Secret = newWebhookSecret()
+	}
+
+	if err := s.webhookRepo.UpdateSubscription(ctx, sub); err != nil {
+		return nil, fmt.Errorf("更新 Webhook 订阅失败: %w", err)
+	}
+	return sub, nil
+}
+
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id uint) error {
+	return s.webhookRepo.DeleteSubscription(ctx, id)
+}
+
+func (s *WebhookService) GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	return s.webhookRepo.GetSubscription(ctx, id)
+}
+
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	subs, err := s.webhookRepo.ListSubscriptions(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("查询 Webhook 订阅失败: %w", err)
+	}
+	return subs, nil
+}
+
+// ListDeliveries 分页查询订阅的投递记录
+func (s *WebhookService) ListDeliveries(
+	ctx context.Context,
+	subscriptionID uint,
+	status models.DeliveryStatus,
+	page, pageSize int,
+) ([]*models.WebhookDelivery, int64, error) {
+	if _, err := s.webhookRepo.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, 0, err
+	}
+	deliveries, total, err := s.webhookRepo.ListDeliveries(ctx, repositories.DeliveryFilter{
+		SubscriptionID: subscriptionID,
+		Status:         status,
+		Offset:         (page - 1) * pageSize,
+		Limit:          pageSize,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询 Webhook 投递记录失败: %w", err)
+	}
+	return deliveries, total, nil
+}
+
+// GetDelivery 查询投递详情，包含每一次尝试
+func (s *WebhookService) GetDelivery(ctx context.Context, id uint) (*models.WebhookDelivery, error) {
+	return s.webhookRepo.GetDelivery(ctx, id)
+}
+
+// WebhookEventList 解析订阅的事件列表，为空表示全部事件
+func WebhookEventList(sub *models.WebhookSubscription) []models.WebhookEvent {
+	if sub.Events == "" {
+		return nil
+	}
+	parts := strings.Split(sub.Events, ",")
+	events := make([]models.WebhookEvent, 0, len(parts))
+	for _, p := range parts {
+		events = append(events, models.WebhookEvent(p))
+	}
+	return events
+}
+
+func subscribes(sub *models.WebhookSubscription, event models.WebhookEvent) bool {
+	events := WebhookEventList(sub)
+	if len(events) == 0 {
+		return true
+	}
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func joinWebhookEvents(events []models.WebhookEvent) (string, error) {
+	names := make([]string, 0, len(events))
+	for _, e := range events {
+		valid := false
+		for _, known := range models.WebhookEvents {
+			if e == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return "", fmt.Errorf("不支持的事件类型: %s", e)
+		}
+		names = append(names, string(e))
+	}
+	return strings.Join(names, ","), nil
+}
+
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("Webhook 地址必须是有效的 http 或 https URL")
+	}
+	return nil
+}
+
+func newWebhookSecret() string {
+	// 密钥用于 HMAC 签名，必须来自 crypto/rand
+	return "whsec_" + randomToken(24)
+}
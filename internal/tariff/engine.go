@@ -0,0 +1,257 @@
+// internal/tariff/engine.go
+// Package tariff 停车计费引擎，按资费规则集计算一次停车的费用明细
+package tariff
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"modules/internal/models"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// NightRule 夜间统一价：停车时段落入夜间区间时，每晚只收一次固定费用
+type NightRule struct {
+	// 夜间开始时间，格式 HH:MM
+	Start string `json:"start"`
+	// 夜间结束时间，格式 HH:MM，可跨零点
+	End string `json:"end"`
+	// 每晚统一价
+	FlatRate float64 `json:"flat_rate"`
+}
+
+// Rule 单一车位类型的计费规则
+type Rule struct {
+	// 免费时长（分钟），从入场开始计算
+	FreeMinutes int `json:"free_minutes"`
+	// 计费单位（分钟），不足一个单位按一个单位计
+	IncrementMinutes int `json:"increment_minutes"`
+	// 每小时费率，为 0 时使用车位自身的 HourlyRate
+	HourlyRate float64 `json:"hourly_rate"`
+	// 周末及节假日每小时费率，为 0 时与平日相同
+	HolidayHourlyRate float64 `json:"holiday_hourly_rate"`
+	// 每日封顶金额，为 0 表示不封顶
+	DailyCap float64 `json:"daily_cap"`
+	// 夜间统一价，可选
+	Night *NightRule `json:"night,omitempty"`
+}
+
+// Schedule 资费规则集，按车位类型区分规则，并共享周末/节假日日历
+type Schedule struct {
+	Rules map[models.ParkingType]Rule `json:"rules"`
+	// 视为周末的星期（0=周日 ... 6=周六），为空时默认周六、周日
+	WeekendDays []time.Weekday `json:"weekend_days"`
+	// 节假日列表，格式 YYYY-MM-DD
+	Holidays []string `json:"holidays"`
+}
+
+// DayCharge 单日费用明细
+type DayCharge struct {
+	Date   string  `json:"date"`
+	Amount float64 `json:"amount"`
+	Night  float64 `json:"night"`
+	Capped bool    `json:"capped"`
+}
+
+// Quote 计费结果
+type Quote struct {
+	// 计费时的车位类型和车位自身费率
+	SpotType models.ParkingType `json:"spot_type"`
+	BaseRate float64            `json:"base_rate"`
+	// 短租车位在租期内停车，免费
+	LeaseCovered    bool        `json:"lease_covered,omitempty"`
+	Total           float64     `json:"total"`
+	BillableMinutes int         `json:"billable_minutes"`
+	FreeMinutes     int         `json:"free_minutes"`
+	Increments      int         `json:"increments"`
+	Days            []DayCharge `json:"days"`
+}
+
+// DefaultSchedule 未配置资费时使用的默认规则：临时与短租车位按车位费率逐分钟计费
+func DefaultSchedule() *Schedule {
+	return &Schedule{
+		Rules: map[models.ParkingType]Rule{
+			models.Temporary: {IncrementMinutes: 1},
+			models.ShortTerm: {IncrementMinutes: 1},
+		},
+	}
+}
+
+// Validate 校验规则集是否合法
+func (s *Schedule) Validate() error {
+	if len(s.Rules) == 0 {
+		return errors.New("资费规则不能为空")
+	}
+	for spotType, rule := range s.Rules {
+		switch spotType {
+		case models.Permanent, models.ShortTerm, models.Temporary:
+		default:
+			return fmt.Errorf("未知的车位类型: %s", spotType)
+		}
+		if rule.FreeMinutes < 0 {
+			return fmt.Errorf("%s: 免费时长不能为负数", spotType)
+		}
+		if rule.IncrementMinutes < 1 || rule.IncrementMinutes > 24*60 {
+			return fmt.Errorf("%s: 计费单位必须在 1 到 1440 分钟之间", spotType)
+		}
+		if rule.HourlyRate < 0 || rule.HolidayHourlyRate < 0 || rule.DailyCap < 0 {
+			return fmt.Errorf("%s: 费率与封顶金额不能为负数", spotType)
+		}
+		if rule.Night != nil {
+			if _, err := parseClock(rule.Night.Start); err != nil {
+				return fmt.Errorf("%s: 夜间开始时间格式错误: %w", spotType, err)
+			}
+			if _, err := parseClock(rule.Night.End); err != nil {
+				return fmt.Errorf("%s: 夜间结束时间格式错误: %w", spotType, err)
+			}
+			if rule.Night.FlatRate < 0 {
+				return fmt.Errorf("%s: 夜间统一价不能为负数", spotType)
+			}
+		}
+	}
+	for _, day := range s.WeekendDays {
+		if day < time.Sunday || day > time.Saturday {
+			return fmt.Errorf("无效的周末设置: %d", day)
+		}
+	}
+	for _, h := range s.Holidays {
+		if _, err := time.Parse(dateLayout, h); err != nil {
+			return fmt.Errorf("节假日格式错误: %s", h)
+		}
+	}
+	return nil
+}
+
+// Calculate 计算一次停车的费用；未配置该车位类型规则时费用为 0
+func (s *Schedule) Calculate(spotType models.ParkingType, baseRate float64, entry, exit time.Time) *Quote {
+	quote := &Quote{SpotType: spotType, BaseRate: baseRate, Days: []DayCharge{}}
+	rule, ok := s.Rules[spotType]
+	if !ok || !exit.After(entry) {
+		return quote
+	}
+
+	// 未配置该字段的历史规则按 60 分钟计费
+	increment := rule.IncrementMinutes
+	if increment == 0 {
+		increment = 60
+	}
+	step := time.Duration(increment) * time.Minute
+
+	billableFrom := entry.Add(time.Duration(rule.FreeMinutes) * time.Minute)
+	if !exit.After(billableFrom) {
+		quote.FreeMinutes = int(exit.Sub(entry).Minutes())
+		return quote
+	}
+	quote.FreeMinutes = rule.FreeMinutes
+	quote.BillableMinutes = int(math.Ceil(exit.Sub(billableFrom).Minutes()))
+	quote.Increments = int(math.Ceil(float64(exit.Sub(billableFrom)) / float64(step)))
+
+	var nightStart, nightEnd int
+	if rule.Night != nil {
+		nightStart, _ = parseClock(rule.Night.Start)
+		nightEnd, _ = parseClock(rule.Night.End)
+	}
+
+	// 按自然日累计，便于执行每日封顶
+	dayIndex := make(map[string]int)
+	chargedNights := make(map[string]bool)
+	bucket := func(date string) *DayCharge {
+		idx, ok := dayIndex[date]
+		if !ok {
+			quote.Days = append(quote.Days, DayCharge{Date: date})
+			idx = len(quote.Days) - 1
+			dayIndex[date] = idx
+		}
+		return &quote.Days[idx]
+	}
+
+	for i := 0; i < quote.Increments; i++ {
+		t := billableFrom.Add(time.Duration(i) * step)
+
+		if rule.Night != nil && inWindow(t, nightStart, nightEnd) {
+			// 夜间区间归属到夜晚开始的那一天
+			night := t
+			if nightStart > nightEnd && minuteOfDay(t) < nightEnd {
+				night = t.AddDate(0, 0, -1)
+			}
+			key := night.Format(dateLayout)
+			if !chargedNights[key] {
+				chargedNights[key] = true
+				day := bucket(key)
+				day.Amount += rule.Night.FlatRate
+				day.Night += rule.Night.FlatRate
+			}
+			continue
+		}
+
+		rate := rule.HourlyRate
+		if rate == 0 {
+			rate = baseRate
+		}
+		if rule.HolidayHourlyRate > 0 && s.isHoliday(t) {
+			rate = rule.HolidayHourlyRate
+		}
+		bucket(t.Format(dateLayout)).Amount += rate * float64(increment) / 60
+	}
+
+	for i := range quote.Days {
+		day := &quote.Days[i]
+		if rule.DailyCap > 0 && day.Amount > rule.DailyCap {
+			day.Amount = rule.DailyCap
+			day.Capped = true
+		}
+		day.Amount = roundCents(day.Amount)
+		quote.Total += day.Amount
+	}
+	quote.Total = roundCents(quote.Total)
+	return quote
+}
+
+func (s *Schedule) isHoliday(t time.Time) bool {
+	weekend := s.WeekendDays
+	if len(weekend) == 0 {
+		weekend = []time.Weekday{time.Saturday, time.Sunday}
+	}
+	for _, day := range weekend {
+		if t.Weekday() == day {
+			return true
+		}
+	}
+	date := t.Format(dateLayout)
+	for _, h := range s.Holidays {
+		if h == date {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock 将 HH:MM 转换为当日分钟数
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func minuteOfDay(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
+
+func inWindow(t time.Time, start, end int) bool {
+	m := minuteOfDay(t)
+	if start == end {
+		return false
+	}
+	if start < end {
+		return m >= start && m < end
+	}
+	return m >= start || m < end
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
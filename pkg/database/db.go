@@ -32,7 +32,38 @@ func Migrate(db *gorm.DB) {
 		&models.PurchaseRecord{},
 		&models.DailyReport{},
 		&models.MaintenanceRecord{},
+		&models.MaintenanceHistory{},
+		&models.SpotRecoveryPolicy{},
+		&models.AuditEvent{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.WebhookAttempt{},
+		&models.OutboxEvent{},
+		&models.OutboxDelivery{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.UserToken{},
+		&models.LoginAttempt{},
+		&models.UserTwoFactor{},
+		&models.RecoveryCode{},
+		&models.SigningKey{},
+		&models.RoleDefinition{},
+		&models.RolePermission{},
 		&models.AdminLoginRequest{},
+		&models.Tariff{},
+		&models.Reservation{},
+		&models.Payment{},
+		&models.Refund{},
+		&models.LeaseRenewal{},
+		&models.LeaseReminder{},
+		&models.SpotListing{},
+		&models.ListingWindow{},
+		&models.BookingRequest{},
+		&models.LedgerAccount{},
+		&models.JournalEntry{},
+		&models.JournalLine{},
 	)
 	if err != nil {
 		log.Fatal("数据库迁移失败:", err)
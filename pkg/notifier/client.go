@@ -1,26 +1,71 @@
 // pkg/notifier/client.go
 package notifier
 
+import (
+	"fmt"
+	"time"
+)
+
+// Client 通知发送接口
 type Client interface {
 	SendNotification(to, subject, message string) error
 }
 
+// 发送方式
+const (
+	// ModeSMTP 通过 SMTP 服务器发送邮件
+	ModeSMTP = "smtp"
+	// ModeFile 将邮件写入本地目录，便于开发时查看
+	ModeFile = "file"
+	// ModeMemory 将邮件保存在内存中，便于测试断言
+	ModeMemory = "memory"
+)
+
 type Config struct {
+	// 发送方式：smtp / file / memory，默认 smtp
+	Mode         string
 	SMTPHost     string
 	SMTPPort     int
 	SMTPUser     string
 	SMTPPassword string
+	// 发件人地址，为空时使用 SMTPUser
+	From string
+	// file 模式下邮件的保存目录
+	SinkDir string
 }
 
-type emailClient struct {
-	cfg Config
+// Message 已发送的邮件，file 与 memory 模式下用于查看
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+	SentAt  time.Time
 }
 
-func NewClient(cfg Config) Client {
-	return &emailClient{cfg: cfg}
+// NewClient 根据配置创建通知客户端
+func NewClient(cfg Config) (Client, error) {
+	switch cfg.Mode {
+	case "", ModeSMTP:
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("未配置 SMTP 服务器地址")
+		}
+		return newSMTPClient(cfg), nil
+	case ModeFile:
+		if cfg.SinkDir == "" {
+			return nil, fmt.Errorf("file 模式必须配置邮件保存目录")
+		}
+		return NewFileClient(cfg.SinkDir, cfg.sender())
+	case ModeMemory:
+		return NewMemoryClient(cfg.sender()), nil
+	default:
+		return nil, fmt.Errorf("不支持的通知发送方式: %s", cfg.Mode)
+	}
 }
 
-func (c *emailClient) SendNotification(to, subject, message string) error {
-	// 实现邮件发送逻辑
-	return nil
+func (cfg Config) sender() string {
+	if cfg.From != "" {
+		return cfg.From
+	}
+	return cfg.SMTPUser
 }
@@ -0,0 +1,79 @@
+// pkg/notifier/sink.go
+package notifier
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MemoryClient 将邮件保存在内存中，不实际发送
+type MemoryClient struct {
+	from     string
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryClient(from string) *MemoryClient {
+	return &MemoryClient{from: from}
+}
+
+func (c *MemoryClient) SendNotification(to, subject, message string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, Message{
+		From:    c.from,
+		To:      to,
+		Subject: subject,
+		Body:    message,
+		SentAt:  time.Now(),
+	})
+	return nil
+}
+
+// Messages 返回已发送邮件的副本
+func (c *MemoryClient) Messages() []Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Message(nil), c.messages...)
+}
+
+// Reset 清空已发送的邮件
+func (c *MemoryClient) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = nil
+}
+
+// FileClient 将每封邮件写成目录下的一个 .eml 文件，可直接用邮件客户端打开
+type FileClient struct {
+	dir  string
+	from string
+	seq  uint64
+}
+
+func NewFileClient(dir, from string) (*FileClient, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("创建邮件保存目录失败: %w", err)
+	}
+	return &FileClient{dir: dir, from: from}, nil
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._@-]`)
+
+func (c *FileClient) SendNotification(to, subject, message string) error {
+	now := time.Now()
+	name := fmt.Sprintf("%s-%04d-%s.eml",
+		now.Format("20060102-150405.000"),
+		atomic.AddUint64(&c.seq, 1)%10000,
+		unsafeFileChars.ReplaceAllString(to, "_"))
+	data := buildMessage(c.from, to, subject, message, now)
+	if err := os.WriteFile(filepath.Join(c.dir, name), data, 0o644); err != nil {
+		return fmt.Errorf("保存邮件失败: %w", err)
+	}
+	return nil
+}
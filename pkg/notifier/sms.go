@@ -0,0 +1,42 @@
+// pkg/notifier/sms.go
+package notifier
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// SMSSender 短信发送接口，接入真实短信服务商时实现该接口即可
+type SMSSender interface {
+	SendSMS(phone, message string) error
+}
+
+// NewSMSSender 根据服务商名称创建短信发送器，目前仅支持 console
+func NewSMSSender(provider string) (SMSSender, error) {
+	switch provider {
+	case "", "console":
+		return NewConsoleSMSSender(os.Stdout), nil
+	default:
+		return nil, fmt.Errorf("不支持的短信服务商: %s", provider)
+	}
+}
+
+// ConsoleSMSSender 将短信内容打印到输出流，用于开发环境
+type ConsoleSMSSender struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewConsoleSMSSender(w io.Writer) *ConsoleSMSSender {
+	return &ConsoleSMSSender{w: w}
+}
+
+func (s *ConsoleSMSSender) SendSMS(phone, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := fmt.Fprintf(s.w, "[SMS %s] to %s: %s\n", time.Now().Format("2006-01-02 15:04:05"), phone, message)
+	return err
+}
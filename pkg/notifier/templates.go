@@ -0,0 +1,283 @@
+// pkg/notifier/templates.go
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateName 通知模板名称
+type TemplateName string
+
+const (
+	TemplateLeaseCreated  TemplateName = "lease_created"
+	TemplateLeaseExpiring TemplateName = "lease_expiring"
+	TemplateExitReceipt   TemplateName = "exit_receipt"
+	TemplatePasswordReset TemplateName = "password_reset"
+	// 模板名与通知事件 email_verification 一致
+	TemplateEmailVerification TemplateName = "email_verification"
+)
+
+// 模板语言
+const (
+	LangZH = "zh"
+	LangEN = "en"
+)
+
+// DefaultLang 用户未设置语言或模板缺少对应语言时使用中文
+const DefaultLang = LangZH
+
+// LeaseCreatedData 租赁订单创建通知
+type LeaseCreatedData struct {
+	LeaseID   uint
+	SpotID    uint
+	StartDate string
+	EndDate   string
+	Amount    float64
+}
+
+// LeaseExpiringData 租赁即将到期提醒；IsOwner 为 true 时发给车位业主
+type LeaseExpiringData struct {
+	LeaseID  uint
+	SpotID   uint
+	EndDate  string
+	DaysLeft int
+	IsOwner  bool
+}
+
+// ExitReceiptData 出场停车费收据
+type ExitReceiptData struct {
+	RecordID  uint
+	SpotID    uint
+	License   string
+	EntryTime string
+	ExitTime  string
+	Duration  string
+	Amount    float64
+}
+
+// PasswordResetData 密码重置通知
+type PasswordResetData struct {
+	Token     string
+	ResetURL  string
+	ExpiresIn string
+}
+
+// EmailVerificationData 邮箱验证通知
+type EmailVerificationData struct {
+	Email     string
+	Token     string
+	VerifyURL string
+	ExpiresIn string
+}
+
+// Envelope 模板的根对象：Recipient 为收件人称呼，Data 为对应模板的数据
+type Envelope struct {
+	Recipient string
+	Data      interface{}
+}
+
+type messageTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+var templates = map[TemplateName]map[string]messageTemplate{
+	TemplateLeaseCreated: {
+		LangZH: mustTemplate(
+			"租赁订单 #{{.Data.LeaseID}} 已创建",
+			`{{.Recipient}}，您好：
+
+您的车位租赁订单已创建，请尽快完成支付。
+
+订单号：{{.Data.LeaseID}}
+车位：{{.Data.SpotID}}
+租期：{{.Data.StartDate}} 至 {{.Data.EndDate}}
+应付金额：{{printf "%.2f" .Data.Amount}} 元
+`),
+		LangEN: mustTemplate(
+			"Lease #{{.Data.LeaseID}} created",
+			`Hello {{.Recipient}},
+
+Your parking lease has been created. Please complete the payment.
+
+Lease: {{.Data.LeaseID}}
+Spot: {{.Data.SpotID}}
+Term: {{.Data.StartDate}} to {{.Data.EndDate}}
+Amount due: {{printf "%.2f" .Data.Amount}}
+`),
+	},
+	TemplateLeaseExpiring: {
+		LangZH: mustTemplate(
+			"租赁订单 #{{.Data.LeaseID}} 将在 {{.Data.DaysLeft}} 天后到期",
+			`{{.Recipient}}，您好：
+
+{{if .Data.IsOwner}}您名下车位 {{.Data.SpotID}} 的租赁订单{{else}}您租用的车位 {{.Data.SpotID}} 的租赁订单{{end}} #{{.Data.LeaseID}} 将于 {{.Data.EndDate}} 到期，距今还有 {{.Data.DaysLeft}} 天。
+{{if not .Data.IsOwner}}如需继续使用，请及时续租或开启自动续租。
+{{end}}`),
+		LangEN: mustTemplate(
+			"Lease #{{.Data.LeaseID}} expires in {{.Data.DaysLeft}} day(s)",
+			`Hello {{.Recipient}},
+
+{{if .Data.IsOwner}}The lease #{{.Data.LeaseID}} on your spot {{.Data.SpotID}}{{else}}Your lease #{{.Data.LeaseID}} for spot {{.Data.SpotID}}{{end}} expires on {{.Data.EndDate}}, {{.Data.DaysLeft}} day(s) from now.
+{{if not .Data.IsOwner}}To keep the spot, please renew or turn on auto-renewal.
+{{end}}`),
+	},
+	TemplateExitReceipt: {
+		LangZH: mustTemplate(
+			"停车收据：{{.Data.License}}",
+			`{{.Recipient}}，您好：
+
+车辆 {{.Data.License}} 已从车位 {{.Data.SpotID}} 出场。
+
+入场时间：{{.Data.EntryTime}}
+出场时间：{{.Data.ExitTime}}
+停车时长：{{.Data.Duration}}
+停车费：{{printf "%.2f" .Data.Amount}} 元
+记录编号：{{.Data.RecordID}}
+`),
+		LangEN: mustTemplate(
+			"Parking receipt: {{.Data.License}}",
+			`Hello {{.Recipient}},
+
+Vehicle {{.Data.License}} has left spot {{.Data.SpotID}}.
+
+Entry: {{.Data.EntryTime}}
+Exit: {{.Data.ExitTime}}
+Duration: {{.Data.Duration}}
+Fee: {{printf "%.2f" .Data.Amount}}
+Record: {{.Data.RecordID}}
+`),
+	},
+	TemplatePasswordReset: {
+		LangZH: mustTemplate(
+			"重置您的密码",
+			`{{.Recipient}}，您好：
+
+我们收到了重置您账户密码的请求。{{if .Data.ResetURL}}请打开以下链接设置新密码：
+
+{{.Data.ResetURL}}{{else}}您的重置令牌为：
+
+{{.Data.Token}}{{end}}
+
+该请求将在 {{.Data.ExpiresIn}} 后失效。如果这不是您本人的操作，请忽略本邮件。
+`),
+		LangEN: mustTemplate(
+			"Reset your password",
+			`Hello {{.Recipient}},
+
+We received a request to reset your password. {{if .Data.ResetURL}}Open the link below to choose a new one:
+
+{{.Data.ResetURL}}{{else}}Your reset token is:
+
+{{.Data.Token}}{{end}}
+
+The request expires in {{.Data.ExpiresIn}}. If you did not ask for this, you can ignore this email.
+`),
+	},
+	TemplateEmailVerification: {
+		LangZH: mustTemplate(
+			"请验证您的邮箱",
+			`{{.Recipient}}，您好：
+
+请确认 {{.Data.Email}} 是您的邮箱。{{if .Data.VerifyURL}}打开以下链接完成验证：
+
+{{.Data.VerifyURL}}{{else}}您的验证令牌为：
+
+{{.Data.Token}}{{end}}
+
+该链接将在 {{.Data.ExpiresIn}} 后失效。完成验证前无法租用或购买车位。
+`),
+		LangEN: mustTemplate(
+			"Verify your email address",
+			`Hello {{.Recipient}},
+
+Please confirm that {{.Data.Email}} is your email address. {{if .Data.VerifyURL}}Open the link below to verify it:
+
+{{.Data.VerifyURL}}{{else}}Your verification token is:
+
+{{.Data.Token}}{{end}}
+
+The link expires in {{.Data.ExpiresIn}}. You cannot lease or purchase spots until your email is verified.
+`),
+	},
+}
+
+func mustTemplate(subject, body string) messageTemplate {
+	return messageTemplate{
+		subject: template.Must(template.New("subject").Parse(subject)),
+		body:    template.Must(template.New("body").Parse(body)),
+	}
+}
+
+// Render 渲染指定语言的模板，不支持的语言回退到中文
+func Render(name TemplateName, lang, recipient string, data interface{}) (subject, body string, err error) {
+	variants, ok := templates[name]
+	if !ok {
+		return "", "", fmt.Errorf("通知模板不存在: %s", name)
+	}
+	tpl, ok := variants[normalizeLang(lang)]
+	if !ok {
+		tpl = variants[DefaultLang]
+	}
+
+	envelope := Envelope{Recipient: recipient, Data: data}
+	var buf bytes.Buffer
+	if err := tpl.subject.Execute(&buf, envelope); err != nil {
+		return "", "", fmt.Errorf("渲染通知标题失败: %w", err)
+	}
+	subject = buf.String()
+
+	buf.Reset()
+	if err := tpl.body.Execute(&buf, envelope); err != nil {
+		return "", "", fmt.Errorf("渲染通知内容失败: %w", err)
+	}
+	return subject, buf.String(), nil
+}
+
+// SendTemplate 渲染模板并通过客户端发送
+func SendTemplate(c Client, to, lang, recipient string, name TemplateName, data interface{}) error {
+	subject, body, err := Render(name, lang, recipient, data)
+	if err != nil {
+		return err
+	}
+	return c.SendNotification(to, subject, body)
+}
+
+// FormatDuration 以对应语言描述有效期，例如 "2 小时" / "2 hour(s)"
+func FormatDuration(d time.Duration, lang string) string {
+	en := normalizeLang(lang) == LangEN
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		if en {
+			return fmt.Sprintf("%d day(s)", d/(24*time.Hour))
+		}
+		return fmt.Sprintf("%d 天", d/(24*time.Hour))
+	case d >= time.Hour && d%time.Hour == 0:
+		if en {
+			return fmt.Sprintf("%d hour(s)", d/time.Hour)
+		}
+		return fmt.Sprintf("%d 小时", d/time.Hour)
+	default:
+		minutes := int(d.Round(time.Minute) / time.Minute)
+		if en {
+			return fmt.Sprintf("%d minute(s)", minutes)
+		}
+		return fmt.Sprintf("%d 分钟", minutes)
+	}
+}
+
+// normalizeLang 将 zh-CN、en_US 等语言标签归一为模板语言
+func normalizeLang(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	switch {
+	case strings.HasPrefix(lang, LangEN):
+		return LangEN
+	case strings.HasPrefix(lang, LangZH):
+		return LangZH
+	}
+	return DefaultLang
+}
@@ -0,0 +1,57 @@
+// pkg/payments/gateway.go
+package payments
+
+import (
+	"context"
+	"fmt"
+)
+
+type IntentStatus string
+
+const (
+	IntentRequiresConfirmation IntentStatus = "requires_confirmation"
+	IntentSucceeded            IntentStatus = "succeeded"
+	IntentFailed               IntentStatus = "failed"
+)
+
+// Intent 网关侧的支付意图
+type Intent struct {
+	ID            string
+	Amount        float64
+	Currency      string
+	Description   string
+	Status        IntentStatus
+	FailureReason string
+	Refunded      float64
+}
+
+// Gateway 支付网关接口，接入真实渠道时实现该接口即可
+type Gateway interface {
+	// Name 网关名称，记录在支付单上
+	Name() string
+	// CreateIntent 创建支付意图，此时尚未扣款
+	CreateIntent(ctx context.Context, amount float64, currency, description string) (*Intent, error)
+	// Confirm 确认扣款，扣款被拒时返回状态为 failed 的意图而不是错误
+	Confirm(ctx context.Context, intentID string) (*Intent, error)
+	// Refund 对已扣款的意图发起（部分）退款
+	Refund(ctx context.Context, intentID string, amount float64) (*Intent, error)
+}
+
+type Config struct {
+	// 网关类型，目前仅支持 mock
+	Provider string
+	// 默认币种
+	Currency string
+	// mock 网关：金额大于该值的扣款会被拒绝，0 表示全部成功
+	MockDeclineAbove float64
+}
+
+// NewGateway 根据配置创建支付网关
+func NewGateway(cfg Config) (Gateway, error) {
+	switch cfg.Provider {
+	case "", "mock":
+		return NewMockGateway(cfg.MockDeclineAbove), nil
+	default:
+		return nil, fmt.Errorf("不支持的支付网关: %s", cfg.Provider)
+	}
+}
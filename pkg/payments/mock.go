@@ -0,0 +1,91 @@
+// pkg/payments/mock.go
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+const mockIntentPrefix = "mock_pi_"
+
+// MockGateway 进程内模拟网关，用于开发和测试。
+// 意图ID按创建顺序递增；网关本身不保存状态，金额编码在意图ID中，
+// 因此多个实例（如 API 与定时任务）之间结果一致：金额超过 declineAbove 的扣款总是被拒绝，其余总是成功。
+// 累计退款额度由调用方根据支付单自行校验。
+type MockGateway struct {
+	seq          uint64
+	declineAbove float64
+}
+
+func NewMockGateway(declineAbove float64) *MockGateway {
+	return &MockGateway{declineAbove: declineAbove}
+}
+
+func (g *MockGateway) Name() string {
+	return "mock"
+}
+
+func (g *MockGateway) CreateIntent(_ context.Context, amount float64, currency, description string) (*Intent, error) {
+	if amount <= 0 {
+		return nil, errors.New("支付金额必须为正数")
+	}
+
+	seq := atomic.AddUint64(&g.seq, 1)
+	return &Intent{
+		ID:          fmt.Sprintf("%s%06d_%d", mockIntentPrefix, seq, toCents(amount)),
+		Amount:      amount,
+		Currency:    currency,
+		Description: description,
+		Status:      IntentRequiresConfirmation,
+	}, nil
+}
+
+func (g *MockGateway) Confirm(_ context.Context, intentID string) (*Intent, error) {
+	amount, err := parseMockIntent(intentID)
+	if err != nil {
+		return nil, err
+	}
+
+	intent := &Intent{ID: intentID, Amount: amount, Status: IntentSucceeded}
+	if g.declineAbove > 0 && amount > g.declineAbove {
+		intent.Status = IntentFailed
+		intent.FailureReason = "模拟网关拒绝扣款"
+	}
+	return intent, nil
+}
+
+func (g *MockGateway) Refund(_ context.Context, intentID string, amount float64) (*Intent, error) {
+	captured, err := parseMockIntent(intentID)
+	if err != nil {
+		return nil, err
+	}
+	if g.declineAbove > 0 && captured > g.declineAbove {
+		return nil, errors.New("支付未成功，无法退款")
+	}
+	if amount <= 0 || toCents(amount) > toCents(captured) {
+		return nil, errors.New("退款金额超出可退范围")
+	}
+	return &Intent{ID: intentID, Amount: captured, Status: IntentSucceeded, Refunded: amount}, nil
+}
+
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// parseMockIntent 从意图ID中解析金额
+func parseMockIntent(intentID string) (float64, error) {
+	idx := strings.LastIndex(intentID, "_")
+	if !strings.HasPrefix(intentID, mockIntentPrefix) || idx < len(mockIntentPrefix) {
+		return 0, fmt.Errorf("支付意图不存在: %s", intentID)
+	}
+	cents, err := strconv.ParseInt(intentID[idx+1:], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("支付意图不存在: %s", intentID)
+	}
+	return float64(cents) / 100, nil
+}